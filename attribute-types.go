@@ -191,3 +191,11 @@ func RemoveQuotes(quotedString string) string{
 	return ""
 }
 */
+
+// unquote returns the string of a quoted-string attribute value and reports whether value is a valid quoted-string.
+func unquote(value string) (string, bool) {
+	if !IsQuotedString(value) {
+		return "", false
+	}
+	return value[1 : len(value)-1], true
+}
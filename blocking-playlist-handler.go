@@ -0,0 +1,130 @@
+package HLS
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// advancePartLimit is the number of Partial Segments a blocking request can be ahead of the last Partial Segment.
+const advancePartLimit = 3
+
+// BlockingPlaylistHandler is a http.Handler that serves a LiveMediaPlaylist with Low-Latency HLS Blocking Playlist Reload.
+// Requests with the _HLS_msn and optionally the _HLS_part query parameters are held until the playlist contains the
+// requested segment or Partial Segment. Waiting requests are woken when the playlist changes and do not poll.
+// Requests without _HLS_msn are answered immediately. The playlist should announce CAN-BLOCK-RELOAD=YES in its ServerControl.
+// Requests with _HLS_skip=YES or _HLS_skip=v2 are answered with a Playlist Delta Update if the ServerControl has a CAN-SKIP-UNTIL.
+// EXT-X-DATERANGE tags are not supported yet, so _HLS_skip=v2 requests get the same delta as _HLS_skip=YES requests.
+type BlockingPlaylistHandler struct {
+	Playlist *LiveMediaPlaylist
+	// Handler serves the playlist once it is available.
+	Handler *PlaylistHandler
+	// DeltaHandler serves the Playlist Delta Updates of _HLS_skip=YES and _HLS_skip=v2 requests.
+	DeltaHandler *PlaylistHandler
+	// Timeout is the longest time a request is held. If Timeout is 0 three target durations are used.
+	// Requests that time out are answered with 503 Service Unavailable.
+	Timeout time.Duration
+}
+
+// NewBlockingPlaylistHandler returns a new BlockingPlaylistHandler that serves playlist with a new PlaylistHandler.
+func NewBlockingPlaylistHandler(playlist *LiveMediaPlaylist) *BlockingPlaylistHandler {
+	return &BlockingPlaylistHandler{
+		Playlist:     playlist,
+		Handler:      NewPlaylistHandler(playlist),
+		DeltaHandler: NewPlaylistHandler(PlaylistSourceFunc(playlist.DeltaPlaylist)),
+	}
+}
+
+// handler returns the handler for the _HLS_skip query parameter of r. ok is false if _HLS_skip is invalid.
+func (bh *BlockingPlaylistHandler) handler(r *http.Request) (handler *PlaylistHandler, ok bool) {
+	switch r.URL.Query().Get("_HLS_skip") {
+	case "":
+		return bh.Handler, true
+	case "YES", "v2":
+		return bh.DeltaHandler, true
+	}
+	return nil, false
+}
+
+// parseBlockingRequest returns the _HLS_msn and _HLS_part query parameters of r. part is -1 if _HLS_part is missing.
+// ok is false if _HLS_msn is missing and err is not nil if the parameters are invalid.
+func parseBlockingRequest(r *http.Request) (msn uint64, part int, ok bool, err error) {
+	query := r.URL.Query()
+	part = -1
+	if !query.Has("_HLS_msn") {
+		if query.Has("_HLS_part") {
+			return 0, part, false, errors.New("_HLS_part requires _HLS_msn")
+		}
+		return 0, part, false, nil
+	}
+	if msn, err = strconv.ParseUint(query.Get("_HLS_msn"), 10, 64); err != nil {
+		return 0, part, false, err
+	}
+	if query.Has("_HLS_part") {
+		value, err := strconv.ParseUint(query.Get("_HLS_part"), 10, 31)
+		if err != nil {
+			return 0, part, false, err
+		}
+		part = int(value)
+	}
+	return msn, part, true, nil
+}
+
+// tooFarAhead reports whether the requested segment or Partial Segment is more than two segments after the last segment
+// or more than the advance part limit after the last Partial Segment.
+func (bh *BlockingPlaylistHandler) tooFarAhead(msn uint64, part int) bool {
+	next, parts := bh.Playlist.NextPosition()
+	if msn > next+1 {
+		return true
+	} else if part < 0 || msn < next {
+		return false
+	}
+
+	//Partial Segments of the segment after the next one are counted from the expected number of parts per segment.
+	partsPerSegment := 0
+	if bh.Playlist.PartInf != nil {
+		partsPerSegment = int(math.Ceil(float64(bh.Playlist.TargetDuration) / bh.Playlist.PartInf.PartTarget))
+	}
+	ahead := int(msn-next)*partsPerSegment + part - (parts - 1)
+	return ahead > advancePartLimit
+}
+
+func (bh *BlockingPlaylistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		bh.Handler.ServeHTTP(w, r)
+		return
+	}
+	handler, ok := bh.handler(r)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	msn, part, ok, err := parseBlockingRequest(r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	} else if !ok {
+		handler.ServeHTTP(w, r)
+		return
+	} else if bh.tooFarAhead(msn, part) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	timeout := bh.Timeout
+	if timeout == 0 {
+		timeout = time.Duration(3*bh.Playlist.TargetDuration) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	if err := bh.Playlist.Wait(ctx, msn, part); err != nil {
+		if r.Context().Err() == nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
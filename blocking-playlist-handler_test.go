@@ -0,0 +1,119 @@
+package HLS_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestBlockingPlaylistHandler(t *testing.T) {
+	livePlaylist := HLS.NewLiveMediaPlaylist(1, 0)
+	livePlaylist.PartInf = &HLS.PartInf{PartTarget: 0.5}
+	livePlaylist.ServerControl = &HLS.ServerControl{PartHoldBack: 1.5, CanBlockReload: true}
+	for i := range 3 {
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.mp4", i), Duration: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := HLS.NewBlockingPlaylistHandler(livePlaylist)
+	handler.Timeout = time.Second
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/live.m3u8"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	{
+		for _, query := range []string{"", "?_HLS_msn=2", "?_HLS_msn=0&_HLS_part=5"} {
+			if w := serve(query); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "seg2.mp4") {
+				t.Fatal("Expected a immediate response for", query, "but got", w.Code, w.Body.String())
+			}
+		}
+		for _, query := range []string{"?_HLS_msn=5", "?_HLS_part=1", "?_HLS_msn=-1", "?_HLS_msn=3&_HLS_part=4", "?_HLS_msn=4&_HLS_part=2"} {
+			if w := serve(query); w.Code != http.StatusBadRequest {
+				t.Fatal("Expected", http.StatusBadRequest, "for", query, "but got", w.Code)
+			}
+		}
+	}
+
+	{
+		var wg sync.WaitGroup
+		responses := make([]*httptest.ResponseRecorder, 100)
+		for i := range responses {
+			wg.Go(func() {
+				if i%2 == 0 {
+					responses[i] = serve("?_HLS_msn=3&_HLS_part=1")
+				} else {
+					responses[i] = serve("?_HLS_msn=3")
+				}
+			})
+		}
+		time.Sleep(50 * time.Millisecond)
+		for _, uri := range []string{"part3.0.mp4", "part3.1.mp4"} {
+			if err := livePlaylist.AddPart(HLS.Part{URI: uri, Duration: 0.5}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: "seg3.mp4", Duration: 1}); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		for i, w := range responses {
+			expected := "part3.1.mp4"
+			if i%2 == 1 {
+				expected = "seg3.mp4"
+			}
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), expected) {
+				t.Fatal("Expected", expected, "but got", w.Code, w.Body.String())
+			}
+		}
+	}
+
+	{
+		handler.Timeout = 20 * time.Millisecond
+		if w := serve("?_HLS_msn=4&_HLS_part=0"); w.Code != http.StatusServiceUnavailable {
+			t.Fatal("Expected", http.StatusServiceUnavailable, "but got", w.Code)
+		}
+		livePlaylist.End()
+		if w := serve("?_HLS_msn=5"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "#EXT-X-ENDLIST") {
+			t.Fatal("Expected the ended playlist but got", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestBlockingPlaylistHandler_Skip(t *testing.T) {
+	livePlaylist := HLS.NewLiveMediaPlaylist(1, 0)
+	livePlaylist.ServerControl = &HLS.ServerControl{CanSkipUntil: 6, CanSkipDateRanges: true, CanBlockReload: true}
+	for i := range 10 {
+		livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 1})
+	}
+	handler := HLS.NewBlockingPlaylistHandler(livePlaylist)
+
+	cases := map[string]string{
+		"":                         "#EXTINF:1,\nseg0.ts",
+		"?_HLS_skip=YES":           "#EXT-X-SKIP:SKIPPED-SEGMENTS=4\n",
+		"?_HLS_skip=v2&_HLS_msn=9": "#EXT-X-SKIP:SKIPPED-SEGMENTS=4\n",
+	}
+	for query, expected := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live.m3u8"+query, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), expected) {
+			t.Fatal("Expected", expected, "for", query, "but got", w.Code, w.Body.String())
+		} else if strings.Contains(w.Body.String(), "DATERANGES") {
+			t.Fatal("Expected date ranges not to be skipped but got", w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live.m3u8?_HLS_skip=NO", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatal("Expected", http.StatusBadRequest, "but got", w.Code)
+	}
+}
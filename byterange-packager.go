@@ -0,0 +1,257 @@
+package HLS
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"time"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// ByteRangePackage is the VOD packaging of a single media file.
+type ByteRangePackage struct {
+	// MediaPlaylist lists the segments of the file as EXT-X-BYTERANGE sub-ranges.
+	MediaPlaylist MediaPlaylist
+	// IFramePlaylist is a EXT-X-I-FRAMES-ONLY Media Playlist that lists the keyframes of the file as EXT-X-BYTERANGE sub-ranges.
+	IFramePlaylist MediaPlaylist
+}
+
+// ByteRangePackager packages one MPEG-TS or fragmented MP4 file into Media Playlists whose segments are byte ranges of the file.
+// Segments are split at keyframes of MPEG-TS files and at fragments starting with a sync sample of fragmented MP4 files.
+// The file is served as a whole so any server supporting range requests like http.ServeContent can serve the segments.
+type ByteRangePackager struct {
+	// URI is the URI of the file used by every segment.
+	URI string
+	// TargetDuration is the duration after which a segment is split at the next keyframe.
+	TargetDuration time.Duration
+}
+
+// NewByteRangePackager returns a new ByteRangePackager.
+func NewByteRangePackager(uri string, targetDuration time.Duration) ByteRangePackager {
+	return ByteRangePackager{
+		URI:            uri,
+		TargetDuration: targetDuration,
+	}
+}
+
+// Package reads a MPEG-TS or fragmented MP4 file from r and returns its ByteRangePackage.
+// The container is detected from the first byte of the file.
+func (brp *ByteRangePackager) Package(r io.Reader) (ByteRangePackage, error) {
+	rd := bufio.NewReader(r)
+	b, err := rd.Peek(1)
+	if err != nil {
+		return ByteRangePackage{}, err
+	}
+	if b[0] == mpegts.SyncByte {
+		return brp.packageTS(rd)
+	}
+	return brp.packageFMP4(rd)
+}
+
+func (brp *ByteRangePackager) packageTS(r io.Reader) (ByteRangePackage, error) {
+	pkg := newByteRangePackage()
+	packetReader := mpegts.NewPacketReader(r)
+	cutter := newTSCutter(brp.TargetDuration)
+	cuts := make([]tsCut, 0)
+	for {
+		packet, err := packetReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return pkg, err
+		}
+
+		c, err := cutter.push(packet)
+		if err != nil {
+			return pkg, err
+		}
+		cuts = append(cuts, c...)
+	}
+	c, err := cutter.flush()
+	if err != nil {
+		return pkg, err
+	}
+	cuts = append(cuts, c...)
+
+	//Segments that do not start with a PAT and a PMT get them from the EXT-X-MAP tag.
+	var psi *MediaInitializationSection
+	if cutter.psiRange != nil {
+		psi = &MediaInitializationSection{
+			URI:       brp.URI,
+			ByteRange: cutter.psiRange,
+		}
+	}
+
+	for _, cut := range cuts {
+		segment := MediaSegment{
+			URI:           brp.URI,
+			Duration:      cut.duration,
+			ByteRange:     &ByteRange{Length: cut.size, Offset: cut.offset},
+			Discontinuity: cut.discontinuity,
+		}
+		if !cut.psi {
+			segment.Map = psi
+		}
+		pkg.MediaPlaylist.Segments = append(pkg.MediaPlaylist.Segments, segment)
+		pkg.MediaPlaylist.IndependentSegments = pkg.MediaPlaylist.IndependentSegments && cut.keyframe
+	}
+
+	for i, keyframe := range cutter.keyframes {
+		_, discontinuity := cutter.runEnds[i-1]
+		end, ok := cutter.runEnds[i]
+		if !ok && i+1 < len(cutter.keyframes) {
+			end = cutter.keyframes[i+1].PTS
+		}
+		pkg.IFramePlaylist.Segments = append(pkg.IFramePlaylist.Segments, MediaSegment{
+			URI:           brp.URI,
+			Duration:      roundSeconds(float64(end-keyframe.PTS) / mpegts.ClockRate),
+			ByteRange:     &ByteRange{Length: keyframe.End - keyframe.Offset, Offset: keyframe.Offset},
+			Discontinuity: discontinuity,
+			Map:           psi,
+		})
+	}
+	pkg.finish()
+	return pkg, nil
+}
+
+func (brp *ByteRangePackager) packageFMP4(r io.Reader) (ByteRangePackage, error) {
+	pkg := newByteRangePackage()
+	fragmentReader := fmp4.NewFragmentReader(r, fmp4.InitSegment{})
+	var initSection *MediaInitializationSection
+	var trackID, timescale uint32
+
+	type syncFragment struct {
+		byteRange  ByteRange
+		decodeTime uint64
+	}
+	iFrames := make([]syncFragment, 0)
+	var target uint64
+
+	var segment *MediaSegment
+	var segmentStart, end uint64
+	finishSegment := func() {
+		if segment != nil {
+			segment.Duration = roundSeconds(float64(end-segmentStart) / float64(timescale))
+			pkg.MediaPlaylist.Segments = append(pkg.MediaPlaylist.Segments, *segment)
+		}
+	}
+
+	for {
+		fragment, err := fragmentReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return pkg, err
+		}
+		fragment.Raw = nil
+
+		if initSection == nil {
+			initSegment := fragmentReader.InitSegment()
+			if initSegment.Size == 0 {
+				return pkg, fmp4.MissingMovieBox
+			}
+			trackID, timescale = referenceTrack(initSegment)
+			target = uint64(brp.TargetDuration.Seconds() * float64(timescale))
+			initSection = &MediaInitializationSection{
+				URI:       brp.URI,
+				ByteRange: &ByteRange{Length: initSegment.Size, Offset: 0},
+			}
+		}
+
+		var tf *fmp4.TrackFragment
+		for i := range fragment.Tracks {
+			if fragment.Tracks[i].TrackID == trackID && len(fragment.Tracks[i].Samples) > 0 {
+				tf = &fragment.Tracks[i]
+			}
+		}
+		if tf == nil {
+			if segment != nil {
+				segment.ByteRange.Length = fragment.Offset + fragment.Size - segment.ByteRange.Offset
+			}
+			continue
+		}
+
+		sync := tf.Samples[0].IsSync()
+		if segment == nil || sync && tf.BaseMediaDecodeTime-segmentStart >= target {
+			finishSegment()
+			segment = &MediaSegment{
+				URI:       brp.URI,
+				ByteRange: &ByteRange{Offset: fragment.Offset},
+				Map:       initSection,
+			}
+			segmentStart = tf.BaseMediaDecodeTime
+			pkg.MediaPlaylist.IndependentSegments = pkg.MediaPlaylist.IndependentSegments && sync
+		}
+		segment.ByteRange.Length = fragment.Offset + fragment.Size - segment.ByteRange.Offset
+		end = tf.BaseMediaDecodeTime + tf.Duration()
+
+		//The I-frame range covers the moof box, the mdat header and the first sample.
+		if sync {
+			first := tf.Samples[0]
+			iFrames = append(iFrames, syncFragment{
+				byteRange: ByteRange{
+					Offset: fragment.Offset,
+					Length: first.Offset + int64(first.Size) - fragment.Offset,
+				},
+				decodeTime: tf.BaseMediaDecodeTime,
+			})
+		}
+	}
+	finishSegment()
+
+	for i, frame := range iFrames {
+		next := end
+		if i+1 < len(iFrames) {
+			next = iFrames[i+1].decodeTime
+		}
+		byteRange := frame.byteRange
+		pkg.IFramePlaylist.Segments = append(pkg.IFramePlaylist.Segments, MediaSegment{
+			URI:       brp.URI,
+			Duration:  roundSeconds(float64(next-frame.decodeTime) / float64(timescale)),
+			ByteRange: &byteRange,
+			Map:       initSection,
+		})
+	}
+	pkg.finish()
+	return pkg, nil
+}
+
+func newByteRangePackage() ByteRangePackage {
+	return ByteRangePackage{
+		MediaPlaylist: MediaPlaylist{
+			PlaylistType:        VOD,
+			IndependentSegments: true,
+			EndList:             true,
+		},
+		IFramePlaylist: MediaPlaylist{
+			PlaylistType: VOD,
+			IFramesOnly:  true,
+			EndList:      true,
+		},
+	}
+}
+
+func (pkg *ByteRangePackage) finish() {
+	pkg.MediaPlaylist.TargetDuration = pkg.MediaPlaylist.ComputeTargetDuration()
+	pkg.IFramePlaylist.TargetDuration = pkg.IFramePlaylist.ComputeTargetDuration()
+}
+
+// referenceTrack returns the id and the timescale of the first video track or the first track of initSegment.
+func referenceTrack(initSegment fmp4.InitSegment) (uint32, uint32) {
+	for _, track := range initSegment.Tracks {
+		if track.HandlerType == "vide" {
+			return track.ID, track.Timescale
+		}
+	}
+	if len(initSegment.Tracks) > 0 {
+		return initSegment.Tracks[0].ID, initSegment.Tracks[0].Timescale
+	}
+	return 0, 1
+}
+
+// roundSeconds rounds seconds to microseconds.
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*1e6) / 1e6
+}
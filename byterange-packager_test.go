@@ -0,0 +1,123 @@
+package HLS_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestByteRangePackager_TS(t *testing.T) {
+	stream := exampleStream(t)
+	packager := HLS.NewByteRangePackager("video.ts", 6*time.Second)
+	pkg, err := packager.Package(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offset int64
+	var total float64
+	for _, segment := range pkg.MediaPlaylist.Segments {
+		if segment.URI != "video.ts" {
+			t.Fatal("Expected URI video.ts but got", segment.URI)
+		} else if segment.ByteRange.Offset != offset {
+			t.Fatal("Expected byte range offset", offset, "but got", segment.ByteRange.Offset)
+		}
+		offset += segment.ByteRange.Length
+		total += segment.Duration
+
+		b := stream[segment.ByteRange.Offset : segment.ByteRange.Offset+segment.ByteRange.Length]
+		if segment.Map == nil && b[1]&0x1F != 0 && b[2] != 0 && b[2] != 0x11 {
+			t.Fatal("Expected the segment to start with PSI packets or to have a EXT-X-MAP tag")
+		}
+		info, err := mpegts.Inspect(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		} else if !info.StartsWithKeyframe {
+			t.Fatal("Expected segment", segment.ByteRange, "to start with a keyframe")
+		}
+	}
+	if offset != int64(len(stream)) {
+		t.Fatal("Expected the segments to cover", len(stream), "bytes but they cover", offset)
+	} else if math.Abs(total-59.966667) > 0.00001 {
+		t.Fatal("Expected a total duration of 59.966667 but got", total)
+	}
+
+	//The example segments contain 16 keyframes.
+	if len(pkg.IFramePlaylist.Segments) != 16 {
+		t.Fatal("Expected 16 I-frames but got", len(pkg.IFramePlaylist.Segments))
+	}
+	total = 0
+	for _, segment := range pkg.IFramePlaylist.Segments {
+		total += segment.Duration
+		b := stream[segment.ByteRange.Offset : segment.ByteRange.Offset+segment.ByteRange.Length]
+		if len(mpegts.SplitNALUnits(b)) == 0 {
+			t.Fatal("Expected the I-frame byte range to contain NAL units")
+		}
+	}
+	if math.Abs(total-59.966667) > 0.00001 {
+		t.Fatal("Expected a total I-frame duration of 59.966667 but got", total)
+	}
+}
+
+func TestByteRangePackager_FMP4(t *testing.T) {
+	f, err := os.Open("fmp4/testdata/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	packager := HLS.NewByteRangePackager("video.mp4", 2*time.Second)
+	pkg, err := packager.Package(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkg.MediaPlaylist.Segments) != 2 {
+		t.Fatal("Expected 2 segments but got", len(pkg.MediaPlaylist.Segments))
+	}
+	for _, segment := range pkg.MediaPlaylist.Segments {
+		if segment.Duration != 2 {
+			t.Fatal("Expected a duration of 2 but got", segment.Duration)
+		} else if segment.Map == nil || *segment.Map.ByteRange != (HLS.ByteRange{Length: 648, Offset: 0}) {
+			t.Fatal("Expected EXT-X-MAP of the init segment but got", segment.Map)
+		}
+	}
+	if last := pkg.MediaPlaylist.Segments[1].ByteRange; last.Offset+last.Length != 76328 {
+		t.Fatal("Expected the last segment to end at the end of the file but got", last)
+	}
+
+	if len(pkg.IFramePlaylist.Segments) != 4 {
+		t.Fatal("Expected 4 I-frames but got", len(pkg.IFramePlaylist.Segments))
+	}
+
+	playlist, err := pkg.IFramePlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:1
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-I-FRAMES-ONLY
+#EXT-X-MAP:URI="video.mp4",BYTERANGE="648@0"
+#EXTINF:1,
+#EXT-X-BYTERANGE:4140@648
+video.mp4
+`
+	if !strings.HasPrefix(string(b), expected) {
+		t.Fatal("Expected the playlist to start with", expected, "but got", string(b))
+	}
+}
@@ -0,0 +1,222 @@
+package HLS
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// UnexpectedStatus is matched by the StatusError returned by Client if the server does not answer a request with
+	// 200 OK or 206 Partial Content for byte range requests.
+	UnexpectedStatus error = errors.New("Unexpected HTTP status")
+)
+
+// StatusError is the error of a response with a unexpected HTTP status. It matches UnexpectedStatus with errors.Is,
+// the status code is read with errors.As.
+type StatusError struct {
+	Code int
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("%s %d %s", UnexpectedStatus, se.Code, http.StatusText(se.Code))
+}
+
+func (se *StatusError) Is(target error) bool {
+	return target == UnexpectedStatus
+}
+
+// Gone reports whether the resource does not exist on the server, that is the status is 404 Not Found or 410 Gone.
+func (se *StatusError) Gone() bool {
+	return se.Code == http.StatusNotFound || se.Code == http.StatusGone
+}
+
+// maxPlaylistSize is the largest playlist a Client reads.
+const maxPlaylistSize = 16 << 20
+
+// Client fetches the playlists of a HLS stream. Every URI of the returned playlists is resolved against the URL of the
+// playlist that contains it as described by RFC 3986, so the playlists only contain absolute URIs.
+// Data URIs and URIs with a scheme like skd:// are absolute already and are not changed.
+type Client struct {
+	HTTPClient *http.Client
+	// URL is the URL of the Master Playlist.
+	URL *url.URL
+}
+
+// NewClient returns a new Client of the Master Playlist at masterURL. If httpClient is nil http.DefaultClient is used.
+func NewClient(httpClient *http.Client, masterURL string) (*Client, error) {
+	u, err := url.Parse(masterURL)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{HTTPClient: httpClient, URL: u}, nil
+}
+
+// ResolveURI resolves uri against base as described by RFC 3986. URIs with a scheme like data: or skd:// are absolute
+// and returned unchanged, data URIs are not parsed at all because their data does not have to be a valid URI path.
+func ResolveURI(base *url.URL, uri string) (string, error) {
+	if uri == "" || len(uri) >= 5 && strings.EqualFold(uri[:5], "data:") {
+		return uri, nil
+	}
+	reference, err := url.Parse(uri)
+	if err != nil {
+		return uri, err
+	} else if reference.Scheme != "" {
+		return uri, nil
+	}
+	return base.ResolveReference(reference).String(), nil
+}
+
+// open requests u and returns the body and the URL of the response after redirects. If byteRange is not nil only the
+// byte range is requested.
+func (c *Client) open(ctx context.Context, u *url.URL, byteRange *ByteRange) (io.ReadCloser, *url.URL, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, u, err
+	}
+	status := http.StatusOK
+	if byteRange != nil {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", byteRange.Offset, byteRange.Offset+byteRange.Length-1))
+		status = http.StatusPartialContent
+	}
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, u, err
+	}
+	if response.StatusCode != status {
+		response.Body.Close()
+		return nil, u, &StatusError{Code: response.StatusCode}
+	}
+	return response.Body, response.Request.URL, nil
+}
+
+// get requests u and returns the body and the URL of the response after redirects.
+func (c *Client) get(ctx context.Context, u *url.URL) ([]byte, *url.URL, error) {
+	body, u, err := c.open(ctx, u, nil)
+	if err != nil {
+		return nil, u, err
+	}
+	defer body.Close()
+	b, err := io.ReadAll(io.LimitReader(body, maxPlaylistSize))
+	return b, u, err
+}
+
+// resolve resolves the URI at uri against base in place.
+func resolve(base *url.URL, uri *string) error {
+	resolved, err := ResolveURI(base, *uri)
+	*uri = resolved
+	return err
+}
+
+// MasterPlaylist fetches and decodes the Master Playlist at c.URL. The URIs of the variant streams, I-frame streams,
+// renditions, session data and session keys are resolved.
+func (c *Client) MasterPlaylist(ctx context.Context) (MasterPlaylist, error) {
+	b, base, err := c.get(ctx, c.URL)
+	if err != nil {
+		return MasterPlaylist{}, err
+	}
+	mp, err := DecodeMasterPlaylist(bytes.NewReader(b))
+	if err != nil {
+		return mp, err
+	}
+
+	uris := make([]*string, 0, 2*len(mp.Variants)+len(mp.Renditions))
+	for i := range mp.Variants {
+		uris = append(uris, &mp.Variants[i].URI)
+		if mp.Variants[i].IFrameStream != nil {
+			uris = append(uris, &mp.Variants[i].IFrameStream.URI)
+		}
+	}
+	for i := range mp.IFrameStreams {
+		uris = append(uris, &mp.IFrameStreams[i].URI)
+	}
+	for i := range mp.Renditions {
+		uris = append(uris, &mp.Renditions[i].URI)
+	}
+	for i := range mp.SessionData {
+		uris = append(uris, &mp.SessionData[i].URI)
+	}
+	for i := range mp.SessionKeys {
+		uris = append(uris, &mp.SessionKeys[i].URI)
+	}
+	for _, uri := range uris {
+		if err := resolve(base, uri); err != nil {
+			return mp, err
+		}
+	}
+	return mp, nil
+}
+
+// MediaPlaylist fetches and decodes the Media Playlist at uri. uri can be relative to c.URL.
+// The URIs of the segments, keys, Media Initialization Sections, Partial Segments, preload hints and rendition reports
+// are resolved.
+func (c *Client) MediaPlaylist(ctx context.Context, uri string) (MediaPlaylist, error) {
+	u, err := c.URL.Parse(uri)
+	if err != nil {
+		return MediaPlaylist{}, err
+	}
+	b, base, err := c.get(ctx, u)
+	if err != nil {
+		return MediaPlaylist{}, err
+	}
+	mp, err := DecodeMediaPlaylist(bytes.NewReader(b))
+	if err != nil {
+		return mp, err
+	}
+	return mp, resolveMediaPlaylist(base, &mp)
+}
+
+// resolveMediaPlaylist resolves every URI of mp against base.
+// Segments share their keys and Media Initialization Section, so each is resolved once and the copies are shared again.
+func resolveMediaPlaylist(base *url.URL, mp *MediaPlaylist) error {
+	maps := make(map[*MediaInitializationSection]*MediaInitializationSection)
+	uris := make([]*string, 0, len(mp.Segments))
+	for i := range mp.Segments {
+		segment := &mp.Segments[i]
+		uris = append(uris, &segment.URI)
+		if i == 0 || !equalKeys(segment.Keys, mp.Segments[i-1].Keys) {
+			segment.Keys = append([]Key(nil), segment.Keys...)
+			for j := range segment.Keys {
+				uris = append(uris, &segment.Keys[j].URI)
+			}
+		} else {
+			segment.Keys = mp.Segments[i-1].Keys
+		}
+		if segment.Map != nil {
+			resolved, ok := maps[segment.Map]
+			if !ok {
+				resolved = &MediaInitializationSection{URI: segment.Map.URI, ByteRange: segment.Map.ByteRange}
+				uris = append(uris, &resolved.URI)
+				maps[segment.Map] = resolved
+			}
+			segment.Map = resolved
+		}
+		segment.Parts = append([]Part(nil), segment.Parts...)
+		for j := range segment.Parts {
+			uris = append(uris, &segment.Parts[j].URI)
+		}
+	}
+	for i := range mp.Parts {
+		uris = append(uris, &mp.Parts[i].URI)
+	}
+	for i := range mp.PreloadHints {
+		uris = append(uris, &mp.PreloadHints[i].URI)
+	}
+	for i := range mp.RenditionReports {
+		uris = append(uris, &mp.RenditionReports[i].URI)
+	}
+	for _, uri := range uris {
+		if err := resolve(base, uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package HLS_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+const clientMasterPlaylist = `#EXTM3U
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="../keys/session.key"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,URI="audio/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac"
+low/index.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="/iframes/low.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO="aac"
+https://cdn.example.com/mid/index.m3u8
+`
+
+const clientMediaPlaylist = `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:4
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=3.0
+#EXT-X-PART-INF:PART-TARGET=1.0
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="../../keys/1.key"
+#EXTINF:4.0,
+seg0.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="data:text/plain;base64,AAECAwQFBgcICQoLDA0ODw=="
+#EXTINF:4.0,
+?segment=1
+#EXT-X-PART:DURATION=1.0,URI="seg2.0.m4s",INDEPENDENT=YES
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="seg2.1.m4s"
+#EXT-X-RENDITION-REPORT:URI="../mid/index.m3u8",LAST-MSN=1,LAST-PART=0
+`
+
+func TestClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/live/master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(clientMasterPlaylist))
+	})
+	mux.Handle("/live/old/master.m3u8", http.RedirectHandler("/live/master.m3u8", http.StatusMovedPermanently))
+	mux.HandleFunc("/live/low/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(clientMediaPlaylist))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	{
+		//The URIs are resolved against the URL the playlist was redirected to.
+		client, err := HLS.NewClient(server.Client(), server.URL+"/live/old/master.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		masterPlaylist, err := client.MasterPlaylist(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for expected, uri := range map[string]string{
+			server.URL + "/live/low/index.m3u8":      masterPlaylist.Variants[0].URI,
+			server.URL + "/iframes/low.m3u8":         masterPlaylist.IFrameStreams[0].URI,
+			"https://cdn.example.com/mid/index.m3u8": masterPlaylist.Variants[1].URI,
+			server.URL + "/live/audio/en.m3u8":       masterPlaylist.Renditions[0].URI,
+			server.URL + "/keys/session.key":         masterPlaylist.SessionKeys[0].URI,
+		} {
+			if uri != expected {
+				t.Fatal("Expected", expected, "but got", uri)
+			}
+		}
+
+		mediaPlaylist, err := client.MediaPlaylist(context.Background(), masterPlaylist.Variants[0].URI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for expected, uri := range map[string]string{
+			server.URL + "/live/low/seg0.m4s":                 mediaPlaylist.Segments[0].URI,
+			server.URL + "/live/low/index.m3u8?segment=1":     mediaPlaylist.Segments[1].URI,
+			server.URL + "/keys/1.key":                        mediaPlaylist.Segments[0].Keys[0].URI,
+			"data:text/plain;base64,AAECAwQFBgcICQoLDA0ODw==": mediaPlaylist.Segments[1].Keys[0].URI,
+			server.URL + "/live/low/init.mp4":                 mediaPlaylist.Segments[1].Map.URI,
+			server.URL + "/live/low/seg2.0.m4s":               mediaPlaylist.Parts[0].URI,
+			server.URL + "/live/low/seg2.1.m4s":               mediaPlaylist.PreloadHints[0].URI,
+			server.URL + "/live/mid/index.m3u8":               mediaPlaylist.RenditionReports[0].URI,
+		} {
+			if uri != expected {
+				t.Fatal("Expected", expected, "but got", uri)
+			}
+		}
+		if mediaPlaylist.Segments[0].Map != mediaPlaylist.Segments[1].Map {
+			t.Fatal("Expected the segments to share the Media Initialization Section")
+		}
+	}
+	{
+		client, err := HLS.NewClient(server.Client(), server.URL+"/live/master.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var statusErr *HLS.StatusError
+		if _, err := client.MediaPlaylist(context.Background(), "missing.m3u8"); !errors.Is(err, HLS.UnexpectedStatus) {
+			t.Fatal("Expected", HLS.UnexpectedStatus, "but got", err)
+		} else if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound || !statusErr.Gone() {
+			t.Fatal("Expected the status", http.StatusNotFound, "but got", err)
+		}
+		//Relative URIs of MediaPlaylist are relative to the Master Playlist.
+		if mediaPlaylist, err := client.MediaPlaylist(context.Background(), "low/index.m3u8"); err != nil {
+			t.Fatal(err)
+		} else if mediaPlaylist.Segments[0].URI != server.URL+"/live/low/seg0.m4s" {
+			t.Fatal("Unexpected segment URI", mediaPlaylist.Segments[0].URI)
+		}
+	}
+	{
+		for base, expected := range map[string]string{
+			"http://a/b/c/d;p?q": "http://a/b/c/g",
+			"http://a/b/c/":      "http://a/b/c/g",
+		} {
+			u, _ := HLS.NewClient(nil, base)
+			if uri, err := HLS.ResolveURI(u.URL, "g"); err != nil || uri != expected {
+				t.Fatal("Expected", expected, "but got", uri, err)
+			}
+		}
+		u, _ := HLS.NewClient(nil, "http://a/b/c/d;p?q")
+		for uri, expected := range map[string]string{
+			"../g":    "http://a/b/g",
+			"../../g": "http://a/g",
+			"//g":     "http://g",
+			"?y":      "http://a/b/c/d;p?y",
+			"#s":      "http://a/b/c/d;p?q#s",
+			"skd://1": "skd://1",
+			//URIs with a scheme are not parsed into a different form.
+			"skd://key-id?a=b%zz#f":                 "skd://key-id?a=b%zz#f",
+			"SKD://Key":                             "SKD://Key",
+			"data:text/plain;charset=utf-8,100%#1":  "data:text/plain;charset=utf-8,100%#1",
+			"DATA:text/plain;base64,AAECAw==#frag":  "DATA:text/plain;base64,AAECAw==#frag",
+			"https://cdn.example.com/a/../b.m3u8?x": "https://cdn.example.com/a/../b.m3u8?x",
+		} {
+			if resolved, err := HLS.ResolveURI(u.URL, uri); err != nil || resolved != expected {
+				t.Fatal("Expected", expected, "but got", resolved, err)
+			}
+		}
+	}
+}
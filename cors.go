@@ -0,0 +1,63 @@
+package HLS
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is the Cross-Origin Resource Sharing policy of a handler. Browser players need CORS headers when the page and the
+// stream are served from different origins.
+type CORS struct {
+	// AllowOrigin is the Access-Control-Allow-Origin. e.g. "*" or "https://player.example.com"
+	AllowOrigin  string
+	AllowMethods []string
+	AllowHeaders []string
+	// ExposeHeaders are the response headers readable by scripts. e.g. "Content-Length" for bandwidth estimation.
+	ExposeHeaders []string
+	// MaxAge is how long the result of a preflight request can be cached. It is omitted if it is 0.
+	MaxAge time.Duration
+}
+
+// NewCORS returns a new CORS that allows GET, HEAD and OPTIONS requests with a Range header from allowOrigin.
+func NewCORS(allowOrigin string) *CORS {
+	return &CORS{
+		AllowOrigin:   allowOrigin,
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		AllowHeaders:  []string{"Range"},
+		ExposeHeaders: []string{"Content-Length", "Content-Range"},
+	}
+}
+
+// SetHeaders sets the CORS headers of a response.
+func (cors *CORS) SetHeaders(header http.Header) {
+	header.Set("Access-Control-Allow-Origin", cors.AllowOrigin)
+	if cors.AllowOrigin != "*" {
+		header.Add("Vary", "Origin")
+	}
+	if len(cors.AllowMethods) > 0 {
+		header.Set("Access-Control-Allow-Methods", strings.Join(cors.AllowMethods, ", "))
+	}
+	if len(cors.AllowHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+	}
+	if len(cors.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposeHeaders, ", "))
+	}
+	if cors.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+	}
+}
+
+// Handler returns a handler that sets the CORS headers and answers preflight requests before calling next.
+func (cors *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cors.SetHeaders(w.Header())
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package HLS
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	InvalidDataURI error = errors.New("Invalid data URI")
+)
+
+// DataURI is a [RFC 2397] data URI. Data URIs can be used as the URI attribute of the EXT-X-KEY, EXT-X-SESSION-KEY and
+// EXT-X-SESSION-DATA tags to carry the key or the session data inside the playlist.
+//
+// [RFC 2397]: https://datatracker.ietf.org/doc/html/rfc2397
+type DataURI struct {
+	// MediaType is the media type and its parameters. e.g. "application/json" or "text/plain;charset=UTF-16".
+	// If MediaType is empty the media type is text/plain;charset=US-ASCII.
+	MediaType string
+	// Base64 reports whether the data is base64 encoded instead of percent-encoded.
+	Base64 bool
+	Data   []byte
+}
+
+// ParseDataURI parses a data URI.
+func ParseDataURI(uri string) (DataURI, error) {
+	dataURI := DataURI{}
+	header, payload, ok := strings.Cut(uri, ",")
+	mediaType, found := strings.CutPrefix(header, "data:")
+	if !ok || !found {
+		return dataURI, InvalidDataURI
+	}
+	dataURI.MediaType, dataURI.Base64 = strings.CutSuffix(mediaType, ";base64")
+
+	var err error
+	if dataURI.Base64 {
+		dataURI.Data, err = base64.StdEncoding.DecodeString(payload)
+	} else {
+		var data string
+		data, err = url.PathUnescape(payload)
+		dataURI.Data = []byte(data)
+	}
+	if err != nil {
+		return dataURI, InvalidDataURI
+	}
+	return dataURI, nil
+}
+
+// String returns the data URI. Percent-encoded data escapes every byte that is not printable ASCII, '"', '%' and spaces
+// so WrapQuotes(dataURI.String()) is always a valid quoted-string.
+func (du *DataURI) String() string {
+	var builder strings.Builder
+	builder.WriteString("data:" + du.MediaType)
+	if du.Base64 {
+		builder.WriteString(";base64," + base64.StdEncoding.EncodeToString(du.Data))
+		return builder.String()
+	}
+
+	builder.WriteByte(',')
+	for _, b := range du.Data {
+		if b <= ' ' || b >= 0x7F || b == '"' || b == '%' {
+			builder.WriteByte('%')
+			builder.WriteByte("0123456789ABCDEF"[b>>4])
+			builder.WriteByte("0123456789ABCDEF"[b&0x0F])
+		} else {
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
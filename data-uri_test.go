@@ -0,0 +1,57 @@
+package HLS_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestParseDataURI(t *testing.T) {
+	{
+		dataURI, err := HLS.ParseDataURI("data:,A%20brief%20note")
+		if err != nil {
+			t.Fatal(err)
+		} else if dataURI.MediaType != "" || dataURI.Base64 || string(dataURI.Data) != "A brief note" {
+			t.Fatal("Unexpected data URI", dataURI)
+		}
+	}
+
+	{
+		dataURI, err := HLS.ParseDataURI("data:text/plain;charset=UTF-8;base64,SGVsbG8sIFdvcmxkIQ==")
+		if err != nil {
+			t.Fatal(err)
+		} else if dataURI.MediaType != "text/plain;charset=UTF-8" || !dataURI.Base64 || string(dataURI.Data) != "Hello, World!" {
+			t.Fatal("Unexpected data URI", dataURI)
+		}
+	}
+
+	for _, uri := range []string{"https://example.com/key", "data:text/plain", "data:;base64,!!", "data:,%fg"} {
+		if _, err := HLS.ParseDataURI(uri); err != HLS.InvalidDataURI {
+			t.Fatal("Expected", HLS.InvalidDataURI, "for", uri, "but got", err)
+		}
+	}
+}
+
+func TestDataURIString(t *testing.T) {
+	data := []byte("{\"title\": \"Café\",\n\"rating\": 100%}")
+	for _, base64 := range []bool{false, true} {
+		dataURI := HLS.DataURI{MediaType: "application/json", Base64: base64, Data: data}
+		uri := dataURI.String()
+		if !HLS.IsQuotedString(HLS.WrapQuotes(uri)) {
+			t.Fatal("Expected a valid quoted-string but got", uri)
+		}
+
+		parsed, err := HLS.ParseDataURI(uri)
+		if err != nil {
+			t.Fatal(err)
+		} else if parsed.MediaType != dataURI.MediaType || parsed.Base64 != base64 || !bytes.Equal(parsed.Data, data) {
+			t.Fatal("Expected", dataURI, "but got", parsed)
+		}
+	}
+
+	dataURI := HLS.DataURI{Data: []byte(`say "hi"`)}
+	if expected := "data:,say%20%22hi%22"; dataURI.String() != expected {
+		t.Fatal("Expected", expected, "but got", dataURI.String())
+	}
+}
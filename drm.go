@@ -0,0 +1,171 @@
+package HLS
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+// KeyFormat is the KEYFORMAT attribute of the EXT-X-KEY and EXT-X-SESSION-KEY tags.
+type KeyFormat string
+
+const (
+	// IdentityKeyFormat is the default KEYFORMAT. The key is the content of the URI.
+	IdentityKeyFormat KeyFormat = "identity"
+	// FairPlayKeyFormat is the KEYFORMAT of Apple FairPlay Streaming. The URI is a skd:// URI.
+	FairPlayKeyFormat KeyFormat = "com.apple.streamingkeydelivery"
+	// WidevineKeyFormat is the KEYFORMAT of Widevine. The URI is a data URI of a pssh box.
+	WidevineKeyFormat KeyFormat = "urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"
+	// PlayReadyKeyFormat is the KEYFORMAT of PlayReady. The URI is a data URI of a pssh box.
+	PlayReadyKeyFormat KeyFormat = "com.microsoft.playready"
+)
+
+var (
+	InvalidKey           error = errors.New("Invalid EXT-X-KEY tag")
+	UnsupportedDRMSystem error = errors.New("Unsupported DRM system")
+	InvalidSessionKey    error = errors.New("EXT-X-SESSION-KEY METHOD must not be NONE")
+)
+
+// KeyFormatSystemID returns the DRM system id of keyFormat.
+func KeyFormatSystemID(keyFormat KeyFormat) (fmp4.SystemID, bool) {
+	switch keyFormat {
+	case FairPlayKeyFormat:
+		return fmp4.FairPlaySystemID, true
+	case WidevineKeyFormat:
+		return fmp4.WidevineSystemID, true
+	case PlayReadyKeyFormat:
+		return fmp4.PlayReadySystemID, true
+	}
+	return fmp4.SystemID{}, false
+}
+
+// systemKeyFormat returns the KEYFORMAT of a DRM system id.
+func systemKeyFormat(systemID fmp4.SystemID) (KeyFormat, bool) {
+	for _, keyFormat := range []KeyFormat{FairPlayKeyFormat, WidevineKeyFormat, PlayReadyKeyFormat} {
+		if id, _ := KeyFormatSystemID(keyFormat); id == systemID {
+			return keyFormat, true
+		}
+	}
+	return "", false
+}
+
+// PSSH returns the pssh box of a key whose URI is a base64 data URI of the box like Widevine and PlayReady keys.
+func (key *Key) PSSH() (fmp4.PSSH, error) {
+	dataURI, err := ParseDataURI(key.URI)
+	if err != nil {
+		return fmp4.PSSH{}, err
+	} else if !dataURI.Base64 {
+		return fmp4.PSSH{}, InvalidDataURI
+	}
+	return fmp4.ParsePSSH(dataURI.Data)
+}
+
+// ToSessionKeyTag returns the EXT-X-SESSION-KEY tag of the key. The attributes are the attributes of the EXT-X-KEY tag.
+func (key *Key) ToSessionKeyTag() HLSTag {
+	tag := key.ToHLSTag()
+	tag.TagName = EXT_X_SESSION_KEY
+	return tag
+}
+
+// ParseKey parses a EXT-X-KEY or a EXT-X-SESSION-KEY tag.
+func ParseKey(tag HLSTag) (Key, error) {
+	key := Key{}
+	if tag.TagName != EXT_X_KEY && tag.TagName != EXT_X_SESSION_KEY {
+		return key, InvalidKey
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return key, InvalidKey
+	}
+
+	key.Method = EncryptionMethod(attributes["METHOD"])
+	switch key.Method {
+	case NONE:
+		//METHOD=NONE only ends the key of its KEYFORMAT.
+		if value, exists := attributes["KEYFORMAT"]; exists {
+			value, ok := unquote(value)
+			if !ok {
+				return key, InvalidKey
+			}
+			key.KeyFormat = KeyFormat(value)
+		}
+		return key, nil
+	case AES_128, SAMPLE_AES, SAMPLE_AES_CTR:
+	default:
+		return key, InvalidKey
+	}
+
+	for name, value := range attributes {
+		switch name {
+		case "URI", "KEYFORMAT", "KEYFORMATVERSIONS":
+			var ok bool
+			if value, ok = unquote(value); !ok {
+				return key, InvalidKey
+			}
+		}
+		switch name {
+		case "URI":
+			key.URI = value
+		case "KEYFORMAT":
+			key.KeyFormat = KeyFormat(value)
+		case "KEYFORMATVERSIONS":
+			key.KeyFormatVersions = value
+		case "IV":
+			if !strings.HasPrefix(value, "0x") && !strings.HasPrefix(value, "0X") {
+				return key, InvalidKey
+			}
+			if key.IV, err = hex.DecodeString(value[2:]); err != nil || len(key.IV) != 16 {
+				return key, InvalidKey
+			}
+		}
+	}
+	if key.URI == "" {
+		return key, InvalidKey
+	}
+	return key, nil
+}
+
+// DRMKeySet is the keys of every DRM system that protects the same content.
+// The same Keys are written as EXT-X-KEY tags in Media Playlists and as EXT-X-SESSION-KEY tags in the Master Playlist
+// so clients can start the key exchange before loading a Media Playlist.
+type DRMKeySet struct {
+	Method EncryptionMethod
+	// IV is the IV attribute of every key. It can be nil.
+	IV []byte
+	// FairPlayURI is the skd:// URI of the FairPlay key. FairPlay is omitted if FairPlayURI is empty.
+	FairPlayURI string
+	// PSSH are the pssh boxes of the other DRM systems. The KEYFORMAT of each key is derived from the system id.
+	PSSH []fmp4.PSSH
+}
+
+// Keys returns the keys of the set in a stable order: FairPlay first, then the pssh boxes in order.
+// Keys returns UnsupportedDRMSystem if the system id of a pssh box has no known KEYFORMAT.
+func (set *DRMKeySet) Keys() ([]Key, error) {
+	keys := make([]Key, 0, 1+len(set.PSSH))
+	if set.FairPlayURI != "" {
+		keys = append(keys, Key{
+			Method:            set.Method,
+			URI:               set.FairPlayURI,
+			IV:                set.IV,
+			KeyFormat:         FairPlayKeyFormat,
+			KeyFormatVersions: "1",
+		})
+	}
+	for _, pssh := range set.PSSH {
+		keyFormat, ok := systemKeyFormat(pssh.SystemID)
+		if !ok || keyFormat == FairPlayKeyFormat {
+			return nil, UnsupportedDRMSystem
+		}
+		dataURI := DataURI{MediaType: "text/plain", Base64: true, Data: pssh.Encode()}
+		keys = append(keys, Key{
+			Method:            set.Method,
+			URI:               dataURI.String(),
+			IV:                set.IV,
+			KeyFormat:         keyFormat,
+			KeyFormatVersions: "1",
+		})
+	}
+	return keys, nil
+}
@@ -0,0 +1,152 @@
+package HLS_test
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+// keyTags returns the keys of the tagName tags of a encoded playlist.
+func keyTags(t *testing.T, playlist string, tagName string) []HLS.Key {
+	t.Helper()
+	keys := make([]HLS.Key, 0)
+	for line := range strings.SplitSeq(playlist, "\n") {
+		if !strings.HasPrefix(line, "#"+tagName+":") {
+			continue
+		}
+		tag, err := HLS.ParseHLSTag(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := HLS.ParseKey(tag)
+		if err != nil {
+			t.Fatal(line, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestDRMKeySet(t *testing.T) {
+	keyID := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	set := HLS.DRMKeySet{
+		Method:      HLS.SAMPLE_AES,
+		FairPlayURI: "skd://key-1",
+		PSSH: []fmp4.PSSH{
+			{SystemID: fmp4.WidevineSystemID, KeyIDs: [][16]byte{keyID}, Data: []byte{0x12, 0x10}},
+			{SystemID: fmp4.PlayReadySystemID, KeyIDs: [][16]byte{keyID}},
+		},
+	}
+	keys, err := set.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFormats := []HLS.KeyFormat{HLS.FairPlayKeyFormat, HLS.WidevineKeyFormat, HLS.PlayReadyKeyFormat}
+	for i, key := range keys {
+		if key.KeyFormat != expectedFormats[i] || key.KeyFormatVersions != "1" {
+			t.Fatal("Expected", expectedFormats[i], "but got", key.KeyFormat, key.KeyFormatVersions)
+		}
+	}
+
+	mediaPlaylist := HLS.MediaPlaylist{
+		Segments: []HLS.MediaSegment{
+			{URI: "0.ts", Duration: 4, Keys: keys},
+			{URI: "1.ts", Duration: 4, Keys: keys},
+		},
+	}
+	masterPlaylist := HLS.MasterPlaylist{
+		SessionKeys: keys,
+		Variants:    []HLS.VariantStream{{URI: "video.m3u8", Bandwidth: 1000000}},
+	}
+	encoded := make([]string, 0, 2)
+	for _, encode := range []func() (HLS.Playlist, error){mediaPlaylist.Encode, masterPlaylist.Encode} {
+		playlist, err := encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(&playlist)
+		if err != nil {
+			t.Fatal(err)
+		} else if !strings.Contains(string(b), "#EXT-X-VERSION:5\n") {
+			t.Fatal("Expected version 5 but got", string(b))
+		}
+		encoded = append(encoded, string(b))
+	}
+
+	mediaKeys := keyTags(t, encoded[0], HLS.EXT_X_KEY)
+	sessionKeys := keyTags(t, encoded[1], HLS.EXT_X_SESSION_KEY)
+	if len(mediaKeys) != len(keys) || len(sessionKeys) != len(keys) {
+		t.Fatal("Expected", len(keys), "keys but got", len(mediaKeys), "and", len(sessionKeys))
+	}
+	for i := range keys {
+		if mediaKeys[i].ToHLSTag() != keys[i].ToHLSTag() || sessionKeys[i].ToHLSTag() != keys[i].ToHLSTag() {
+			t.Fatal("Expected", keys[i], "but got", mediaKeys[i], "and", sessionKeys[i])
+		}
+	}
+
+	pssh, err := sessionKeys[1].PSSH()
+	if err != nil {
+		t.Fatal(err)
+	} else if pssh.SystemID != fmp4.WidevineSystemID || !slices.Equal(pssh.KeyIDs, [][16]byte{keyID}) {
+		t.Fatal("Unexpected pssh", pssh)
+	} else if systemID, _ := HLS.KeyFormatSystemID(sessionKeys[1].KeyFormat); systemID != pssh.SystemID {
+		t.Fatal("Expected", pssh.SystemID, "but got", systemID)
+	}
+	if _, err := sessionKeys[0].PSSH(); err != HLS.InvalidDataURI {
+		t.Fatal("Expected", HLS.InvalidDataURI, "but got", err)
+	}
+
+	set.PSSH = append(set.PSSH, fmp4.PSSH{SystemID: fmp4.CommonSystemID})
+	if _, err := set.Keys(); err != HLS.UnsupportedDRMSystem {
+		t.Fatal("Expected", HLS.UnsupportedDRMSystem, "but got", err)
+	}
+	masterPlaylist.SessionKeys = []HLS.Key{{Method: HLS.NONE}}
+	if _, err := masterPlaylist.Encode(); err != HLS.InvalidSessionKey {
+		t.Fatal("Expected", HLS.InvalidSessionKey, "but got", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tag, err := HLS.ParseHLSTag(`#EXT-X-KEY:METHOD=AES-128,URI="https://example.com/key?a=1,b=2",IV=0x000102030405060708090A0B0C0D0E0F`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := HLS.ParseKey(tag)
+	if err != nil {
+		t.Fatal(err)
+	} else if key.Method != HLS.AES_128 || key.URI != "https://example.com/key?a=1,b=2" || len(key.IV) != 16 || key.IV[15] != 0x0F {
+		t.Fatal("Unexpected key", key)
+	}
+
+	//Widevine and PlayReady keys of CENC streams use SAMPLE-AES-CTR.
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAAA==",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:4.0,
+seg0.m4s
+#EXT-X-ENDLIST
+`
+	mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(playlist))
+	if err != nil {
+		t.Fatal(err)
+	} else if key := mediaPlaylist.Segments[0].Keys[0]; key.Method != HLS.SAMPLE_AES_CTR || key.KeyFormat != HLS.WidevineKeyFormat {
+		t.Fatal("Unexpected key", key)
+	}
+	mediaPlaylist.Version = 0
+	encoded, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	} else if b, _ := io.ReadAll(&encoded); !strings.Contains(string(b), "#EXT-X-VERSION:5\n") || !strings.Contains(string(b), "METHOD=SAMPLE-AES-CTR") {
+		t.Fatal("Unexpected playlist", string(b))
+	}
+
+	for _, value := range []string{"METHOD=AES-128", "METHOD=AES-256,URI=\"key\"", "METHOD=AES-128,URI=\"key\",IV=0x01"} {
+		if _, err := HLS.ParseKey(HLS.HLSTag{TagName: HLS.EXT_X_KEY, Value: value}); err != HLS.InvalidKey {
+			t.Fatal("Expected", HLS.InvalidKey, "for", value, "but got", err)
+		}
+	}
+}
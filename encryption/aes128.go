@@ -0,0 +1,156 @@
+// Package encryption encrypts and decrypts Media Segments as defined by RFC 8216 section 5.2.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// KeySize is the size of a AES-128 key and IV in bytes.
+const KeySize = aes.BlockSize
+
+var (
+	InvalidKeySize error = errors.New("AES-128 key and IV must be 16 bytes")
+	InvalidPadding error = errors.New("Invalid PKCS7 padding")
+)
+
+// SequenceIV returns the IV used when the EXT-X-KEY tag has no IV attribute.
+// The Media Sequence Number is used as the IV by putting its big-endian binary representation into a 16-octet buffer and padding (on the left) with zeros.
+func SequenceIV(sequenceNumber uint64) []byte {
+	iv := make([]byte, KeySize)
+	binary.BigEndian.PutUint64(iv[8:], sequenceNumber)
+	return iv
+}
+
+func newBlock(key, iv []byte) (cipher.Block, error) {
+	if len(key) != KeySize || len(iv) != KeySize {
+		return nil, InvalidKeySize
+	}
+	return aes.NewCipher(key)
+}
+
+// Encrypt encrypts a whole segment using AES-128 CBC with PKCS7 padding.
+func Encrypt(plaintext, key, iv []byte) ([]byte, error) {
+	block, err := newBlock(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	padding := KeySize - len(plaintext)%KeySize
+	ciphertext := append(bytes.Clone(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	return ciphertext, nil
+}
+
+// Decrypt decrypts a segment encrypted with Encrypt and removes the PKCS7 padding.
+func Decrypt(ciphertext, key, iv []byte) ([]byte, error) {
+	block, err := newBlock(key, iv)
+	if err != nil {
+		return nil, err
+	} else if len(ciphertext) == 0 || len(ciphertext)%KeySize != 0 {
+		return nil, InvalidPadding
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	return unpad(plaintext)
+}
+
+func unpad(b []byte) ([]byte, error) {
+	padding := int(b[len(b)-1])
+	if padding == 0 || padding > KeySize || padding > len(b) {
+		return nil, InvalidPadding
+	}
+	for _, v := range b[len(b)-padding:] {
+		if int(v) != padding {
+			return nil, InvalidPadding
+		}
+	}
+	return b[:len(b)-padding], nil
+}
+
+// cbcReader encrypts or decrypts a stream block by block.
+type cbcReader struct {
+	src     io.Reader
+	mode    cipher.BlockMode
+	decrypt bool
+	// in holds read bytes that are not processed yet and out holds processed bytes that are not returned yet.
+	in  []byte
+	out []byte
+	eof bool
+}
+
+// NewEncrypter returns a reader that encrypts r using AES-128 CBC with PKCS7 padding.
+func NewEncrypter(r io.Reader, key, iv []byte) (io.Reader, error) {
+	block, err := newBlock(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cbcReader{src: r, mode: cipher.NewCBCEncrypter(block, iv)}, nil
+}
+
+// NewDecrypter returns a reader that decrypts r using AES-128 CBC and removes the PKCS7 padding.
+// Read returns InvalidPadding at the end of r if the padding is invalid.
+func NewDecrypter(r io.Reader, key, iv []byte) (io.Reader, error) {
+	block, err := newBlock(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return &cbcReader{src: r, mode: cipher.NewCBCDecrypter(block, iv), decrypt: true}, nil
+}
+
+func (cr *cbcReader) Read(p []byte) (int, error) {
+	for len(cr.out) == 0 {
+		if cr.eof {
+			return 0, io.EOF
+		}
+		if err := cr.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cr.out)
+	cr.out = cr.out[n:]
+	return n, nil
+}
+
+// fill reads the next chunk of src and processes every complete block.
+// The last block is kept back while decrypting because it contains the padding.
+func (cr *cbcReader) fill() error {
+	buf := make([]byte, 32*KeySize)
+	n, err := cr.src.Read(buf)
+	cr.in = append(cr.in, buf[:n]...)
+	if err == io.EOF {
+		cr.eof = true
+	} else if err != nil {
+		return err
+	}
+
+	size := len(cr.in) - len(cr.in)%KeySize
+	if cr.decrypt && !cr.eof && size == len(cr.in) {
+		size -= KeySize
+	}
+	if cr.eof && !cr.decrypt {
+		padding := KeySize - len(cr.in)%KeySize
+		cr.in = append(cr.in, bytes.Repeat([]byte{byte(padding)}, padding)...)
+		size = len(cr.in)
+	} else if cr.eof && size != len(cr.in) || cr.eof && size == 0 {
+		return InvalidPadding
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	out := make([]byte, size)
+	cr.mode.CryptBlocks(out, cr.in[:size])
+	cr.in = cr.in[size:]
+	if cr.decrypt && cr.eof {
+		var err error
+		if out, err = unpad(out); err != nil {
+			return err
+		}
+	}
+	cr.out = out
+	return nil
+}
@@ -0,0 +1,91 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/udan-jayanith/HLS/encryption"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEncrypt(t *testing.T) {
+	//NIST SP 800-38A F.2.1 CBC-AES128.Encrypt
+	key := decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	iv := decodeHex(t, "000102030405060708090a0b0c0d0e0f")
+	plaintext := decodeHex(t, "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51")
+	expected := decodeHex(t, "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2")
+
+	ciphertext, err := encryption.Encrypt(plaintext, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ciphertext) != 48 {
+		t.Fatal("Expected a full padding block but got", len(ciphertext), "bytes")
+	} else if !bytes.Equal(ciphertext[:32], expected) {
+		t.Fatal("Expected", expected, "but got", ciphertext[:32])
+	}
+
+	decrypted, err := encryption.Decrypt(ciphertext, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(decrypted, plaintext) {
+		t.Fatal("Expected", plaintext, "but got", decrypted)
+	}
+
+	if _, err := encryption.Encrypt(plaintext, key[:8], iv); err != encryption.InvalidKeySize {
+		t.Fatal("Expected", encryption.InvalidKeySize, "but got", err)
+	} else if _, err := encryption.Decrypt(ciphertext[:47], key, iv); err != encryption.InvalidPadding {
+		t.Fatal("Expected", encryption.InvalidPadding, "but got", err)
+	}
+}
+
+func TestEncrypter(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, encryption.KeySize)
+	iv := encryption.SequenceIV(42)
+	for _, size := range []int{0, 1, 16, 1000, 4096} {
+		plaintext := bytes.Repeat([]byte{0xAB}, size)
+		expected, err := encryption.Encrypt(plaintext, key, iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encrypter, err := encryption.NewEncrypter(iotest.OneByteReader(bytes.NewReader(plaintext)), key, iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := io.ReadAll(encrypter)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(ciphertext, expected) {
+			t.Fatal("Expected the stream to be encrypted like Encrypt for size", size)
+		}
+
+		decrypter, err := encryption.NewDecrypter(iotest.HalfReader(bytes.NewReader(ciphertext)), key, iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := io.ReadAll(decrypter)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(decrypted, plaintext) {
+			t.Fatal("Expected the decrypted stream to equal the plaintext for size", size)
+		}
+	}
+}
+
+func TestSequenceIV(t *testing.T) {
+	expected := decodeHex(t, "00000000000000000000000000000102")
+	if iv := encryption.SequenceIV(0x102); !bytes.Equal(iv, expected) {
+		t.Fatal("Expected", expected, "but got", iv)
+	}
+}
@@ -0,0 +1,383 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+var (
+	UnsupportedStreamType error = errors.New("Stream type cannot be encrypted with SAMPLE-AES")
+	InvalidAudioFrame     error = errors.New("Invalid ADTS or AC-3 frame")
+)
+
+// sampleAESStreamTypes maps the clear stream types to their SAMPLE-AES stream types and private_data_indicator values.
+var sampleAESStreamTypes = map[mpegts.StreamType]struct {
+	streamType mpegts.StreamType
+	indicator  string
+}{
+	mpegts.H264: {mpegts.SampleAESH264, "zavc"},
+	mpegts.AAC:  {mpegts.SampleAESAAC, "aacd"},
+	mpegts.AC3:  {mpegts.SampleAESAC3, "ac3d"},
+}
+
+const (
+	privateDataIndicatorDescriptor = 0x0F
+	registrationDescriptor         = 0x05
+)
+
+// sampleCipher encrypts or decrypts the protected blocks of a sample. The CBC chain restarts with the IV for every sample.
+type sampleCipher struct {
+	block   cipher.Block
+	iv      []byte
+	encrypt bool
+}
+
+func newSampleCipher(key, iv []byte, encrypt bool) (sampleCipher, error) {
+	block, err := newBlock(key, iv)
+	return sampleCipher{block: block, iv: iv, encrypt: encrypt}, err
+}
+
+// crypt encrypts or decrypts the 16 byte blocks of sample starting at the offsets in place as one CBC chain.
+func (sc *sampleCipher) crypt(sample []byte, offsets []int) {
+	if len(offsets) == 0 {
+		return
+	}
+	blocks := make([]byte, 0, len(offsets)*KeySize)
+	for _, offset := range offsets {
+		blocks = append(blocks, sample[offset:offset+KeySize]...)
+	}
+	if sc.encrypt {
+		cipher.NewCBCEncrypter(sc.block, sc.iv).CryptBlocks(blocks, blocks)
+	} else {
+		cipher.NewCBCDecrypter(sc.block, sc.iv).CryptBlocks(blocks, blocks)
+	}
+	for i, offset := range offsets {
+		copy(sample[offset:], blocks[i*KeySize:(i+1)*KeySize])
+	}
+}
+
+// cryptNALUnit encrypts or decrypts a H.264 NAL unit. Only slices (NAL types 1 and 5) longer than 48 bytes are protected.
+// The first 32 bytes stay clear, then one block of every 10 blocks is encrypted until 16 bytes or less are left.
+// The pattern is applied to the NAL unit without emulation prevention bytes which are inserted again afterwards.
+func (sc *sampleCipher) cryptNALUnit(nal []byte) []byte {
+	if t := mpegts.H264NALType(nal); t != mpegts.H264NonIDRSlice && t != mpegts.H264IDRSlice {
+		return nal
+	}
+	rbsp := mpegts.UnescapeRBSP(nal)
+	if len(rbsp) <= 48 {
+		return nal
+	}
+	offsets := make([]int, 0, len(rbsp)/160+1)
+	for i := 32; len(rbsp)-i > KeySize; i += 10 * KeySize {
+		offsets = append(offsets, i)
+	}
+	sc.crypt(rbsp, offsets)
+	return mpegts.EscapeRBSP(rbsp)
+}
+
+// cryptH264 encrypts or decrypts the NAL units of Annex B formatted data. Start codes and the bytes between NAL units are kept.
+func (sc *sampleCipher) cryptH264(data []byte) []byte {
+	out := make([]byte, 0, len(data)+len(data)/64)
+	start := bytes.Index(data, []byte{0, 0, 1})
+	if start < 0 {
+		return data
+	}
+	start += 3
+	out = append(out, data[:start]...)
+	for start <= len(data) {
+		next := bytes.Index(data[start:], []byte{0, 0, 1})
+		end := len(data)
+		if next >= 0 {
+			end = start + next
+		}
+		//Trailing zero bytes belong to the next start code.
+		nalEnd := end
+		for nalEnd > start && data[nalEnd-1] == 0 {
+			nalEnd--
+		}
+		out = append(out, sc.cryptNALUnit(bytes.Clone(data[start:nalEnd]))...)
+		if next < 0 {
+			out = append(out, data[nalEnd:]...)
+			break
+		}
+		out = append(out, data[nalEnd:end+3]...)
+		start = end + 3
+	}
+	return out
+}
+
+// cryptAudioFrame encrypts or decrypts a audio frame after its header. The first 16 bytes after the header stay clear,
+// then every complete 16 byte block is encrypted and the remaining bytes stay clear.
+func (sc *sampleCipher) cryptAudioFrame(frame []byte, headerLength int) {
+	offsets := make([]int, 0, len(frame)/KeySize)
+	for i := headerLength + KeySize; i+KeySize <= len(frame); i += KeySize {
+		offsets = append(offsets, i)
+	}
+	sc.crypt(frame, offsets)
+}
+
+// cryptAudio encrypts or decrypts the ADTS or AC-3 frames of data.
+func (sc *sampleCipher) cryptAudio(data []byte) ([]byte, error) {
+	out := bytes.Clone(data)
+	for i := 0; i < len(out); {
+		length, headerLength, err := audioFrame(out[i:])
+		if err != nil {
+			return nil, err
+		}
+		sc.cryptAudioFrame(out[i:i+length], headerLength)
+		i += length
+	}
+	return out, nil
+}
+
+// ac3Bitrates are the AC-3 bit rates in kbit/s of frmsizecod/2.
+var ac3Bitrates = []int{32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 448, 512, 576, 640}
+
+// audioFrame returns the length and the length of the clear header of the ADTS or AC-3 frame at the beginning of b.
+// AC-3 frames have no clear header because the clear leader includes the sync frame header.
+func audioFrame(b []byte) (int, int, error) {
+	var length, headerLength int
+	switch {
+	case len(b) >= 7 && b[0] == 0xFF && b[1]&0xF0 == 0xF0:
+		length = int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5])>>5
+		headerLength = 7
+		if b[1]&0x01 == 0 {
+			headerLength = 9
+		}
+	case len(b) >= 5 && b[0] == 0x0B && b[1] == 0x77:
+		fscod, frmsizecod := int(b[4]>>6), int(b[4]&0x3F)
+		if fscod == 3 || frmsizecod/2 >= len(ac3Bitrates) {
+			return 0, 0, InvalidAudioFrame
+		}
+		//frame size in 16 bit words for 48, 44.1 and 32 kHz.
+		bitrate := ac3Bitrates[frmsizecod/2]
+		words := []int{bitrate * 2, bitrate*96000/44100 + frmsizecod&1, bitrate * 3}[fscod]
+		length = words * 2
+	default:
+		return 0, 0, InvalidAudioFrame
+	}
+	if length < headerLength || length > len(b) {
+		return 0, 0, InvalidAudioFrame
+	}
+	return length, headerLength, nil
+}
+
+// audioSetupInformation returns the audio_setup_information of the first frame of a AAC or AC-3 stream.
+func audioSetupInformation(streamType mpegts.StreamType, frame []byte) ([]byte, error) {
+	var audioType string
+	var setupData []byte
+	switch {
+	case streamType == mpegts.AAC && len(frame) >= 7:
+		//AudioSpecificConfig: audioObjectType, samplingFrequencyIndex and channelConfiguration.
+		audioType = "zaac"
+		objectType := frame[2]>>6 + 1
+		frequencyIndex := frame[2] >> 2 & 0x0F
+		channels := frame[2]&0x01<<2 | frame[3]>>6
+		setupData = []byte{objectType<<3 | frequencyIndex>>1, frequencyIndex<<7 | channels<<3}
+	case streamType == mpegts.AC3 && len(frame) >= 8:
+		//AC3SpecificBox: fscod, bsid, bsmod, acmod, lfeon and bit_rate_code.
+		audioType = "zac3"
+		fscod, bitRateCode := frame[4]>>6, frame[4]&0x3F>>1
+		bsid, bsmod, acmod := frame[5]>>3, frame[5]&0x07, frame[6]>>5
+		//lfeon follows the optional cmixlev, surmixlev and dsurmod fields.
+		bit := 3
+		if acmod&0x01 != 0 && acmod != 1 {
+			bit += 2
+		}
+		if acmod&0x04 != 0 {
+			bit += 2
+		}
+		if acmod == 2 {
+			bit += 2
+		}
+		bits := uint16(frame[6])<<8 | uint16(frame[7])
+		lfeon := byte(bits >> (15 - bit) & 0x01)
+		v := uint32(fscod)<<22 | uint32(bsid)<<17 | uint32(bsmod)<<14 | uint32(acmod)<<11 | uint32(lfeon)<<10 | uint32(bitRateCode)<<5
+		setupData = []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	default:
+		return nil, InvalidAudioFrame
+	}
+
+	info := []byte(audioType)
+	info = binary.BigEndian.AppendUint16(info, 0) //priming
+	info = append(info, 1, byte(len(setupData)))  //version and setup_data_length
+	return append(info, setupData...), nil
+}
+
+// EncryptSampleAES encrypts the H.264, AAC and AC-3 streams of a transport stream segment with METHOD=SAMPLE-AES.
+// The stream types in the PMT are replaced by their SAMPLE-AES stream types and private_data_indicator descriptors are added.
+// Audio streams also get a registration descriptor with the audio_setup_information.
+// EncryptSampleAES returns UnsupportedStreamType for other audio and video streams.
+func EncryptSampleAES(segment, key, iv []byte) ([]byte, error) {
+	sc, err := newSampleCipher(key, iv, true)
+	if err != nil {
+		return nil, err
+	}
+
+	//The audio_setup_information is taken from the first frame of each audio stream.
+	firstFrames, err := firstAudioFrames(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	rewriter := mpegts.Rewriter{
+		PMT: func(pmt mpegts.PMT) (mpegts.PMT, error) {
+			streams := make([]mpegts.ElementaryStream, 0, len(pmt.Streams))
+			for _, stream := range pmt.Streams {
+				encrypted, ok := sampleAESStreamTypes[stream.StreamType]
+				if !ok && (stream.StreamType.IsAudio() || stream.StreamType.IsVideo()) {
+					return pmt, UnsupportedStreamType
+				} else if !ok {
+					streams = append(streams, stream)
+					continue
+				}
+
+				descriptors := append(bytes.Clone(stream.Descriptors), privateDataIndicatorDescriptor, 4)
+				descriptors = append(descriptors, encrypted.indicator...)
+				if stream.StreamType.IsAudio() {
+					info, err := audioSetupInformation(stream.StreamType, firstFrames[stream.PID])
+					if err != nil {
+						return pmt, err
+					}
+					descriptors = append(descriptors, registrationDescriptor, byte(4+len(info)))
+					descriptors = append(append(descriptors, "apad"...), info...)
+				}
+				stream.StreamType = encrypted.streamType
+				stream.Descriptors = descriptors
+				streams = append(streams, stream)
+			}
+			pmt.Streams = streams
+			return pmt, nil
+		},
+		Data: func(stream mpegts.ElementaryStream, data []byte) ([]byte, error) {
+			switch stream.StreamType {
+			case mpegts.H264:
+				return sc.cryptH264(data), nil
+			case mpegts.AAC, mpegts.AC3:
+				return sc.cryptAudio(data)
+			}
+			return data, nil
+		},
+	}
+	return rewriter.Rewrite(segment)
+}
+
+// firstAudioFrames returns the data of the first PES packet of every audio stream of segment by PID.
+func firstAudioFrames(segment []byte) (map[uint16][]byte, error) {
+	firstFrames := make(map[uint16][]byte)
+	packetReader := mpegts.NewPacketReader(bytes.NewReader(segment))
+	demuxer := mpegts.NewDemuxer()
+	for {
+		packet, err := packetReader.Advance()
+		if err == io.EOF {
+			return firstFrames, nil
+		} else if err != nil {
+			return nil, err
+		}
+		completed, err := demuxer.Push(packet)
+		if err != nil {
+			return nil, err
+		}
+		for _, pes := range completed {
+			if _, ok := firstFrames[pes.PID]; !ok && pes.StreamType.IsAudio() {
+				firstFrames[pes.PID] = bytes.Clone(pes.Data)
+			}
+		}
+	}
+}
+
+// DecryptSampleAES decrypts a transport stream segment encrypted with EncryptSampleAES and restores the clear stream types.
+func DecryptSampleAES(segment, key, iv []byte) ([]byte, error) {
+	sc, err := newSampleCipher(key, iv, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rewriter := mpegts.Rewriter{
+		PMT: func(pmt mpegts.PMT) (mpegts.PMT, error) {
+			streams := make([]mpegts.ElementaryStream, 0, len(pmt.Streams))
+			for _, stream := range pmt.Streams {
+				for clearType, encrypted := range sampleAESStreamTypes {
+					if stream.StreamType == encrypted.streamType {
+						stream.StreamType = clearType
+						stream.Descriptors = removeSampleAESDescriptors(stream.Descriptors)
+					}
+				}
+				streams = append(streams, stream)
+			}
+			pmt.Streams = streams
+			return pmt, nil
+		},
+		Data: func(stream mpegts.ElementaryStream, data []byte) ([]byte, error) {
+			switch stream.StreamType {
+			case mpegts.SampleAESH264:
+				return sc.cryptH264(data), nil
+			case mpegts.SampleAESAAC, mpegts.SampleAESAC3:
+				return sc.cryptAudio(data)
+			}
+			return data, nil
+		},
+	}
+	return rewriter.Rewrite(segment)
+}
+
+// removeSampleAESDescriptors removes the private_data_indicator and the apad registration descriptors of a ES_info descriptor loop.
+func removeSampleAESDescriptors(descriptors []byte) []byte {
+	kept := make([]byte, 0, len(descriptors))
+	for i := 0; i+2 <= len(descriptors); {
+		end := min(i+2+int(descriptors[i+1]), len(descriptors))
+		descriptor := descriptors[i:end]
+		sampleAES := descriptor[0] == privateDataIndicatorDescriptor ||
+			descriptor[0] == registrationDescriptor && bytes.HasPrefix(descriptor[2:], []byte("apad"))
+		if !sampleAES {
+			kept = append(kept, descriptor...)
+		}
+		i = end
+	}
+	return kept
+}
+
+// skipID3 returns the length of the ID3 tags at the beginning of a packed audio segment.
+func skipID3(data []byte) int {
+	i := 0
+	for len(data)-i >= 10 && bytes.HasPrefix(data[i:], []byte("ID3")) {
+		//The tag size is a 28 bit synchsafe integer that excludes the 10 byte header.
+		size := int(data[i+6]&0x7F)<<21 | int(data[i+7]&0x7F)<<14 | int(data[i+8]&0x7F)<<7 | int(data[i+9]&0x7F)
+		i = min(i+10+size, len(data))
+	}
+	return i
+}
+
+// EncryptPackedAudio encrypts a packed audio segment of ADTS or AC-3 frames with METHOD=SAMPLE-AES.
+// ID3 tags at the beginning of the segment like the timestamp tag stay clear.
+func EncryptPackedAudio(data, key, iv []byte) ([]byte, error) {
+	sc, err := newSampleCipher(key, iv, true)
+	if err != nil {
+		return nil, err
+	}
+	i := skipID3(data)
+	frames, err := sc.cryptAudio(data[i:])
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(data[:i]), frames...), nil
+}
+
+// DecryptPackedAudio decrypts a packed audio segment encrypted with EncryptPackedAudio.
+func DecryptPackedAudio(data, key, iv []byte) ([]byte, error) {
+	sc, err := newSampleCipher(key, iv, false)
+	if err != nil {
+		return nil, err
+	}
+	i := skipID3(data)
+	frames, err := sc.cryptAudio(data[i:])
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(data[:i]), frames...), nil
+}
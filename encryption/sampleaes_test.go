@@ -0,0 +1,186 @@
+package encryption_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/encryption"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// nistBlocks are the first two plaintext and ciphertext blocks of NIST SP 800-38A F.2.1 CBC-AES128.Encrypt.
+const (
+	nistKey        = "2b7e151628aed2a6abf7158809cf4f3c"
+	nistIV         = "000102030405060708090a0b0c0d0e0f"
+	nistPlaintext  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51"
+	nistCiphertext = "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2"
+)
+
+// encodePackets returns the packets carrying payload on pid.
+func encodePackets(t *testing.T, pid uint16, payload []byte) []byte {
+	t.Helper()
+	b := make([]byte, 0)
+	for i := 0; len(payload) > 0; i++ {
+		n := min(len(payload), mpegts.PacketSize-4)
+		packet, err := mpegts.EncodePacket(mpegts.Packet{
+			PID:               pid,
+			PayloadUnitStart:  i == 0,
+			ContinuityCounter: uint8(i),
+			Payload:           payload[:n],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b = append(b, packet...)
+		payload = payload[n:]
+	}
+	return b
+}
+
+func TestEncryptSampleAES(t *testing.T) {
+	key, iv := decodeHex(t, nistKey), decodeHex(t, nistIV)
+
+	//A IDR slice with the first protected block at 32 and the second one 144 clear bytes later.
+	nal := bytes.Repeat([]byte{0x11}, 220)
+	nal[0] = 0x65
+	plaintext := decodeHex(t, nistPlaintext)
+	copy(nal[32:], plaintext[:16])
+	copy(nal[192:], plaintext[16:])
+
+	pes := []byte{0, 0, 1, 0xE0, 0, 0, 0x80, 0x80, 5, 0x21, 0, 1, 0, 1, 0, 0, 0, 1}
+	pes = append(pes, nal...)
+	pat := decodeHex(t, "00b00d0001c100000001f0002ab104b2")
+	pmt := mpegts.EncodePMT(mpegts.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		Streams:       []mpegts.ElementaryStream{{PID: 0x100, StreamType: mpegts.H264}},
+	})
+
+	segment := make([]byte, 0)
+	for _, s := range [][]byte{pat, pmt} {
+		payload, err := mpegts.EncodeSection(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pid := uint16(0)
+		if s[0] == 0x02 {
+			pid = 0x1000
+		}
+		segment = append(segment, encodePackets(t, pid, payload)...)
+	}
+	segment = append(segment, encodePackets(t, 0x100, pes)...)
+
+	encrypted, err := encryption.EncryptSampleAES(segment, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, err := mpegts.ParsePacket(encrypted[mpegts.PacketSize : 2*mpegts.PacketSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedPMT, err := mpegts.ParsePMT(packet.Payload)
+	if err != nil {
+		t.Fatal(err)
+	} else if encryptedPMT.Streams[0].StreamType != mpegts.SampleAESH264 {
+		t.Fatal("Expected", mpegts.SampleAESH264, "but got", encryptedPMT.Streams[0].StreamType)
+	} else if !bytes.Equal(encryptedPMT.Streams[0].Descriptors, []byte{0x0F, 4, 'z', 'a', 'v', 'c'}) {
+		t.Fatal("Expected a private_data_indicator descriptor but got", encryptedPMT.Streams[0].Descriptors)
+	}
+
+	data := make([]byte, 0)
+	for offset := 2 * mpegts.PacketSize; offset < len(encrypted); offset += mpegts.PacketSize {
+		packet, err := mpegts.ParsePacket(encrypted[offset : offset+mpegts.PacketSize])
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, packet.Payload...)
+	}
+	encryptedNAL := data[len(pes)-len(nal):]
+	ciphertext := decodeHex(t, nistCiphertext)
+	if !bytes.Equal(encryptedNAL[32:48], ciphertext[:16]) || !bytes.Equal(encryptedNAL[192:208], ciphertext[16:]) {
+		t.Fatal("Expected", ciphertext, "but got", encryptedNAL[32:48], encryptedNAL[192:208])
+	} else if !bytes.Equal(encryptedNAL[:32], nal[:32]) || !bytes.Equal(encryptedNAL[48:192], nal[48:192]) || !bytes.Equal(encryptedNAL[208:], nal[208:]) {
+		t.Fatal("Expected the clear bytes to be unchanged")
+	}
+
+	decrypted, err := encryption.DecryptSampleAES(encrypted, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(decrypted, segment) {
+		t.Fatal("Expected the decrypted segment to be the original segment")
+	}
+}
+
+func TestEncryptSampleAES_Segment(t *testing.T) {
+	segment, err := os.ReadFile("../examples/serving-a-video/video-fragments/seg001.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := bytes.Repeat([]byte{3}, encryption.KeySize)
+	iv := encryption.SequenceIV(1)
+
+	encrypted, err := encryption.EncryptSampleAES(segment, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(encrypted, segment) {
+		t.Fatal("Expected the segment to be encrypted")
+	}
+
+	originalInfo, err := mpegts.Inspect(bytes.NewReader(segment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := mpegts.Inspect(bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	} else if info.Duration != originalInfo.Duration || len(info.Keyframes) != len(originalInfo.Keyframes) {
+		t.Fatal("Expected the timing of the segment to be unchanged")
+	}
+	for _, stream := range info.Streams {
+		if stream.StreamType != mpegts.SampleAESH264 && stream.StreamType != mpegts.SampleAESAAC {
+			t.Fatal("Expected a SAMPLE-AES stream type but got", stream.StreamType)
+		}
+	}
+
+	decrypted, err := encryption.DecryptSampleAES(encrypted, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(decrypted, segment) {
+		t.Fatal("Expected the decrypted segment to be the original segment")
+	}
+}
+
+func TestEncryptPackedAudio(t *testing.T) {
+	key, iv := decodeHex(t, nistKey), decodeHex(t, nistIV)
+
+	//A ID3 tag followed by a ADTS frame of a 7 byte header, the 16 byte clear leader, 2 blocks and 5 trailing bytes.
+	id3 := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 0}
+	length := 7 + 16 + 32 + 5
+	frame := []byte{0xFF, 0xF1, 0x50, 0x80 | byte(length>>11), byte(length >> 3), byte(length&0x07)<<5 | 0x1F, 0xFC}
+	frame = append(frame, bytes.Repeat([]byte{0x22}, 16)...)
+	frame = append(frame, decodeHex(t, nistPlaintext)...)
+	frame = append(frame, bytes.Repeat([]byte{0x33}, 5)...)
+	data := append(bytes.Clone(id3), frame...)
+
+	encrypted, err := encryption.EncryptPackedAudio(data, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(bytes.Clone(data[:10+23]), decodeHex(t, nistCiphertext)...)
+	expected = append(expected, data[10+55:]...)
+	if !bytes.Equal(encrypted, expected) {
+		t.Fatal("Expected", expected, "but got", encrypted)
+	}
+
+	decrypted, err := encryption.DecryptPackedAudio(encrypted, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(decrypted, data) {
+		t.Fatal("Expected", data, "but got", decrypted)
+	}
+
+	if _, err := encryption.EncryptPackedAudio(data[:len(data)-1], key, iv); err != encryption.InvalidAudioFrame {
+		t.Fatal("Expected", encryption.InvalidAudioFrame, "but got", err)
+	}
+}
@@ -0,0 +1,172 @@
+package HLS
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	InvalidVersion error = errors.New("Segment requires a higher EXT-X-VERSION than the playlist")
+)
+
+// EventPlaylist writes a EXT-X-PLAYLIST-TYPE:EVENT Media Playlist to a io.Writer as segments are appended.
+// The bytes written before are never rewritten, so the playlist can be appended to a file that is served while it grows.
+// If the writer has a Sync method like *os.File it is called after every segment.
+//
+// A failed write or sync can leave a partial line behind. If the writer has Truncate and Seek methods like *os.File it
+// is truncated back to the last complete line and the append can be retried. Otherwise the playlist is broken and every
+// later call returns the error.
+//
+// Converting the finished playlist to VOD changes the EXT-X-PLAYLIST-TYPE tag at the beginning of the playlist, so the
+// VOD playlist returned by VOD must be written to a new file that replaces the EVENT playlist.
+type EventPlaylist struct {
+	w              io.Writer
+	targetDuration int
+	version        int
+	segments       []MediaSegment
+	headerWritten  bool
+	ended          bool
+	// err is the error that broke the playlist.
+	err error
+	// size is the number of bytes written to w.
+	size int64
+}
+
+// NewEventPlaylist returns a new EventPlaylist that writes to w. The target duration and the version are written in the
+// header and cannot change. Segments must not exceed them.
+func NewEventPlaylist(w io.Writer, targetDuration int, version int) *EventPlaylist {
+	return &EventPlaylist{
+		w:              w,
+		targetDuration: targetDuration,
+		version:        max(version, 1),
+	}
+}
+
+// AppendSegment appends segment to the playlist. The header is written with the first segment.
+// AppendSegment returns InvalidTargetDuration or InvalidVersion if the segment does not fit the header and PlaylistEnded after End.
+func (ep *EventPlaylist) AppendSegment(segment MediaSegment) error {
+	if ep.err != nil {
+		return ep.err
+	} else if ep.ended {
+		return PlaylistEnded
+	} else if int(math.Round(segment.Duration)) > ep.targetDuration {
+		return InvalidTargetDuration
+	}
+	single := MediaPlaylist{Segments: []MediaSegment{segment}}
+	if single.MinimumVersion() > ep.version {
+		return InvalidVersion
+	}
+
+	playlist := NewPlaylist()
+	if err := ep.appendHeader(&playlist); err != nil {
+		return err
+	}
+	var previous *MediaSegment
+	if len(ep.segments) > 0 {
+		previous = &ep.segments[len(ep.segments)-1]
+	}
+	if err := appendSegment(&playlist, &segment, previous); err != nil {
+		return err
+	} else if err := ep.write(&playlist); err != nil {
+		return err
+	}
+	ep.segments = append(ep.segments, segment)
+	return nil
+}
+
+// End appends the EXT-X-ENDLIST tag. Nothing can be appended after End.
+func (ep *EventPlaylist) End() error {
+	if ep.err != nil {
+		return ep.err
+	} else if ep.ended {
+		return PlaylistEnded
+	}
+	playlist := NewPlaylist()
+	if err := ep.appendHeader(&playlist); err != nil {
+		return err
+	} else if err := playlist.AppendTag(HLSTag{TagName: EXT_X_ENDLIST}); err != nil {
+		return err
+	} else if err := ep.write(&playlist); err != nil {
+		return err
+	}
+	ep.ended = true
+	return nil
+}
+
+// appendHeader appends the header to playlist if it was not written yet.
+func (ep *EventPlaylist) appendHeader(playlist *Playlist) error {
+	if ep.headerWritten {
+		return nil
+	}
+	header := MediaPlaylist{
+		Version:        ep.version,
+		TargetDuration: ep.targetDuration,
+		PlaylistType:   EVENT,
+	}
+	return header.AppendTo(playlist)
+}
+
+// write writes playlist to w and syncs w. If the write or the sync fails w is rolled back or the playlist is broken.
+func (ep *EventPlaylist) write(playlist *Playlist) error {
+	if err := playlist.Close(); err != nil {
+		return err
+	}
+	b, err := io.ReadAll(playlist)
+	if err != nil {
+		return err
+	}
+	n, err := ep.w.Write(b)
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		if syncer, ok := ep.w.(interface{ Sync() error }); ok {
+			err = syncer.Sync()
+		}
+	}
+	if err != nil {
+		ep.rollback(err)
+		return err
+	}
+	ep.size += int64(n)
+	ep.headerWritten = true
+	return nil
+}
+
+// rollback truncates w to the bytes written before the failed write. The playlist is broken with err if w cannot be truncated.
+func (ep *EventPlaylist) rollback(err error) {
+	truncater, ok := ep.w.(interface {
+		Truncate(size int64) error
+		io.Seeker
+	})
+	if !ok || truncater.Truncate(ep.size) != nil {
+		ep.err = err
+	} else if _, seekErr := truncater.Seek(ep.size, io.SeekStart); seekErr != nil {
+		ep.err = err
+	}
+}
+
+// Size returns the number of bytes written so far.
+func (ep *EventPlaylist) Size() int64 {
+	return ep.size
+}
+
+// MediaPlaylist returns the EVENT playlist written so far.
+func (ep *EventPlaylist) MediaPlaylist() MediaPlaylist {
+	return MediaPlaylist{
+		Version:        ep.version,
+		TargetDuration: ep.targetDuration,
+		PlaylistType:   EVENT,
+		Segments:       append([]MediaSegment(nil), ep.segments...),
+		EndList:        ep.ended,
+	}
+}
+
+// VOD returns the playlist as a VOD playlist with the EXT-X-ENDLIST tag.
+func (ep *EventPlaylist) VOD() MediaPlaylist {
+	mediaPlaylist := ep.MediaPlaylist()
+	mediaPlaylist.PlaylistType = VOD
+	mediaPlaylist.EndList = true
+	return mediaPlaylist
+}
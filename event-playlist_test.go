@@ -0,0 +1,150 @@
+package HLS_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+// failingFile is a *os.File whose next write writes half of the bytes and fails.
+type failingFile struct {
+	*os.File
+	fail bool
+}
+
+func (ff *failingFile) Write(p []byte) (int, error) {
+	if ff.fail {
+		ff.fail = false
+		n, _ := ff.File.Write(p[:len(p)/2])
+		return n, errors.New("Disk full")
+	}
+	return ff.File.Write(p)
+}
+
+// failingWriter writes half of the bytes and fails.
+type failingWriter struct {
+	strings.Builder
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	n, _ := fw.Builder.Write(p[:len(p)/2])
+	return n, errors.New("Disk full")
+}
+
+func TestEventPlaylist(t *testing.T) {
+	file, err := os.Create(t.TempDir() + "/event.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	eventPlaylist := HLS.NewEventPlaylist(file, 6, 3)
+	previous := ""
+	for i, duration := range []float64{6, 5.5, 6.006} {
+		segment := HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: duration}
+		if err := eventPlaylist.AppendSegment(segment); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		} else if !strings.HasPrefix(string(b), previous) || len(b) <= len(previous) {
+			t.Fatal("Expected the playlist to be appended to but got", string(b))
+		} else if int64(len(b)) != eventPlaylist.Size() {
+			t.Fatal("Expected", len(b), "bytes but got", eventPlaylist.Size())
+		}
+		previous = string(b)
+	}
+
+	if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "long.ts", Duration: 7}); err != HLS.InvalidTargetDuration {
+		t.Fatal("Expected", HLS.InvalidTargetDuration, "but got", err)
+	} else if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "range.ts", Duration: 6, ByteRange: &HLS.ByteRange{Length: 10}}); err != HLS.InvalidVersion {
+		t.Fatal("Expected", HLS.InvalidVersion, "but got", err)
+	} else if err := eventPlaylist.End(); err != nil {
+		t.Fatal(err)
+	} else if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "late.ts", Duration: 6}); err != HLS.PlaylistEnded {
+		t.Fatal("Expected", HLS.PlaylistEnded, "but got", err)
+	}
+
+	b, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXTINF:6,
+seg0.ts
+#EXTINF:5.5,
+seg1.ts
+#EXTINF:6.006,
+seg2.ts
+#EXT-X-ENDLIST
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+
+	mediaPlaylist := eventPlaylist.MediaPlaylist()
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	} else if encoded, err := io.ReadAll(&playlist); err != nil || string(encoded) != expected {
+		t.Fatal("Expected the snapshot to match the written playlist but got", string(encoded), err)
+	}
+
+	vod := eventPlaylist.VOD()
+	playlist, err = vod.Encode()
+	if err != nil {
+		t.Fatal(err)
+	} else if encoded, err := io.ReadAll(&playlist); err != nil || string(encoded) != strings.Replace(expected, "EVENT", "VOD", 1) {
+		t.Fatal("Unexpected VOD playlist", string(encoded), err)
+	}
+
+	{
+		//A failed write is truncated so the append can be retried.
+		file, err := os.Create(t.TempDir() + "/event.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		failing := &failingFile{File: file}
+		eventPlaylist := HLS.NewEventPlaylist(failing, 6, 3)
+		if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "seg0.ts", Duration: 6}); err != nil {
+			t.Fatal(err)
+		}
+		failing.fail = true
+		if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "seg1.ts", Duration: 6}); err == nil {
+			t.Fatal("Expected a error")
+		} else if err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "seg1.ts", Duration: 6}); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		} else if !strings.HasSuffix(string(b), "#EXT-X-PLAYLIST-TYPE:EVENT\n#EXTINF:6,\nseg0.ts\n#EXTINF:6,\nseg1.ts\n") || int64(len(b)) != eventPlaylist.Size() {
+			t.Fatal("Unexpected playlist", string(b))
+		}
+	}
+	{
+		//A writer that cannot be truncated breaks the playlist.
+		eventPlaylist := HLS.NewEventPlaylist(&failingWriter{}, 6, 3)
+		err := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "seg0.ts", Duration: 6})
+		if err == nil {
+			t.Fatal("Expected a error")
+		} else if retry := eventPlaylist.AppendSegment(HLS.MediaSegment{URI: "seg0.ts", Duration: 6}); retry != err {
+			t.Fatal("Expected", err, "but got", retry)
+		} else if end := eventPlaylist.End(); end != err {
+			t.Fatal("Expected", err, "but got", end)
+		} else if eventPlaylist.Size() != 0 {
+			t.Fatal("Expected 0 bytes but got", eventPlaylist.Size())
+		}
+	}
+}
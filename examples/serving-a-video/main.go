@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/udan-jayanith/HLS"
 )
@@ -125,24 +126,17 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	cors := HLS.NewCORS("*")
 
-	// This function serves video segments.
-	http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		setCrossOrigin(w)
-		handler := http.StripPrefix("/", http.FileServer(http.Dir("./video-fragments")))
-		handler.ServeHTTP(w, r)
-	}))
+	// This handler serves video segments.
+	segmentHandler := HLS.NewSegmentHandler(HLS.NewDirSegmentStore("./video-fragments"))
+	segmentHandler.CORS = cors
+	http.Handle("/", segmentHandler)
 
-	// This function serves a trailer to a movie in a media-playlist file.
-	http.HandleFunc("/full-video.m3u8", func(w http.ResponseWriter, r *http.Request) {
-		setCrossOrigin(w)
-		w.Write([]byte(mediaPlaylist))
-	})
+	// This handler serves a trailer to a movie in a media-playlist file.
+	playlistHandler := HLS.NewPlaylistHandler(HLS.StaticPlaylist{Data: []byte(mediaPlaylist), ModTime: time.Now()})
+	playlistHandler.CORS = cors
+	http.Handle("/full-video.m3u8", playlistHandler)
 
 	http.ListenAndServe(":8080", http.DefaultServeMux)
 }
-
-func setCrossOrigin(w http.ResponseWriter) {
-	w.Header().Add("Access-Control-Allow-Origin", "*")
-	w.Header().Add("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-}
@@ -0,0 +1,155 @@
+// Package fmp4 implements a box reader for [ISO/IEC 14496-12] fragmented MP4 (CMAF) segments.
+// fmp4 reads the Media Initialization Section (ftyp/moov) referenced by EXT-X-MAP and the moof/traf/tfdt/trun boxes of
+// Media Segments to compute the decode start time and the exact duration of each segment.
+// fmp4 does not decode media samples.
+//
+// [ISO/IEC 14496-12]: https://www.iso.org/standard/83102.html
+package fmp4
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	InvalidBox error = errors.New("Invalid MP4 box")
+)
+
+// maxPreallocatedBox is the largest buffer BoxReader allocates before the body of a box is read.
+const maxPreallocatedBox = 1 << 20
+
+// Box is a MP4 box.
+type Box struct {
+	Type string
+	// Offset is the byte offset of the box from the beginning of the stream.
+	Offset int64
+	// Size is the size of the box including its header.
+	Size int64
+	// HeaderSize is the size of the box header.
+	HeaderSize int
+	// Raw is the whole box including its header.
+	Raw []byte
+}
+
+// Body returns the content of the box after the header.
+func (box *Box) Body() []byte {
+	return box.Raw[box.HeaderSize:]
+}
+
+// BoxReader reads top level boxes from a io.Reader.
+type BoxReader struct {
+	rd     *bufio.Reader
+	offset int64
+}
+
+// NewBoxReader returns a new BoxReader.
+func NewBoxReader(r io.Reader) BoxReader {
+	return BoxReader{
+		rd: bufio.NewReader(r),
+	}
+}
+
+// Advance reads the next box. Advance returns io.EOF when there are no more boxes.
+func (br *BoxReader) Advance() (Box, error) {
+	box := Box{
+		Offset: br.offset,
+	}
+
+	header := make([]byte, 8, 16)
+	if _, err := io.ReadFull(br.rd, header); err != nil {
+		return box, err
+	}
+	box.Type = string(header[4:8])
+	box.Size = int64(binary.BigEndian.Uint32(header))
+	box.HeaderSize = 8
+
+	switch box.Size {
+	case 0:
+		//The box extends to the end of the stream.
+		rest, err := io.ReadAll(br.rd)
+		if err != nil {
+			return box, err
+		}
+		box.Raw = append(header, rest...)
+		box.Size = int64(len(box.Raw))
+		br.offset += box.Size
+		return box, nil
+	case 1:
+		header = header[:16]
+		if _, err := io.ReadFull(br.rd, header[8:]); err != nil {
+			return box, io.ErrUnexpectedEOF
+		}
+		box.Size = int64(binary.BigEndian.Uint64(header[8:]))
+		box.HeaderSize = 16
+	}
+	if box.Size < int64(box.HeaderSize) {
+		return box, InvalidBox
+	}
+
+	//The size is read from the stream, so the body is read incrementally instead of allocating the size up front.
+	raw := bytes.NewBuffer(make([]byte, 0, min(box.Size, maxPreallocatedBox)))
+	raw.Write(header)
+	if _, err := io.CopyN(raw, br.rd, box.Size-int64(box.HeaderSize)); err == io.EOF {
+		return box, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return box, err
+	}
+	box.Raw = raw.Bytes()
+	br.offset += box.Size
+	return box, nil
+}
+
+// ParseBoxes parses the boxes contained in b. b is usually the body of a container box.
+// The Offset of the returned boxes is relative to b.
+func ParseBoxes(b []byte) ([]Box, error) {
+	boxes := make([]Box, 0, 4)
+	for i := 0; i < len(b); {
+		if len(b)-i < 8 {
+			return boxes, InvalidBox
+		}
+		box := Box{
+			Type:       string(b[i+4 : i+8]),
+			Offset:     int64(i),
+			Size:       int64(binary.BigEndian.Uint32(b[i:])),
+			HeaderSize: 8,
+		}
+		switch box.Size {
+		case 0:
+			box.Size = int64(len(b) - i)
+		case 1:
+			if len(b)-i < 16 {
+				return boxes, InvalidBox
+			}
+			box.Size = int64(binary.BigEndian.Uint64(b[i+8:]))
+			box.HeaderSize = 16
+		}
+		if box.Size < int64(box.HeaderSize) || box.Size > int64(len(b)-i) {
+			return boxes, InvalidBox
+		}
+		box.Raw = b[i : i+int(box.Size)]
+		boxes = append(boxes, box)
+		i += int(box.Size)
+	}
+	return boxes, nil
+}
+
+// findBox returns the first box of boxType in boxes.
+func findBox(boxes []Box, boxType string) (Box, bool) {
+	for _, box := range boxes {
+		if box.Type == boxType {
+			return box, true
+		}
+	}
+	return Box{}, false
+}
+
+// fullBox returns the version and the flags of a FullBox body and the rest of the body.
+func fullBox(body []byte) (uint8, uint32, []byte, error) {
+	if len(body) < 4 {
+		return 0, 0, nil, InvalidBox
+	}
+	return body[0], uint32(body[1])<<16 | uint32(body[2])<<8 | uint32(body[3]), body[4:], nil
+}
@@ -0,0 +1,66 @@
+package fmp4_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+func TestBoxReader(t *testing.T) {
+	b := []byte{
+		0, 0, 0, 12, 'f', 'r', 'e', 'e', 1, 2, 3, 4,
+		0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0, 0, 0, 0, 0, 0, 17, 9,
+	}
+
+	boxReader := fmp4.NewBoxReader(bytes.NewReader(b))
+	box, err := boxReader.Advance()
+	if err != nil {
+		t.Fatal(err)
+	} else if box.Type != "free" || box.Size != 12 || !bytes.Equal(box.Body(), []byte{1, 2, 3, 4}) {
+		t.Fatal("Unexpected box", box.Type, box.Size, box.Body())
+	}
+
+	box, err = boxReader.Advance()
+	if err != nil {
+		t.Fatal(err)
+	} else if box.Type != "mdat" || box.Offset != 12 || box.HeaderSize != 16 || !bytes.Equal(box.Body(), []byte{9}) {
+		t.Fatal("Unexpected box", box.Type, box.Offset, box.HeaderSize, box.Body())
+	}
+
+	if _, err := boxReader.Advance(); err != io.EOF {
+		t.Fatal("Expected", io.EOF, "but got", err)
+	}
+	//A truncated box with a huge size fails without allocating the size.
+	for _, truncated := range [][]byte{
+		{0, 0, 0, 1, 'm', 'd', 'a', 't', 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 9, 9},
+		{0xff, 0xff, 0xff, 0xf0, 'm', 'd', 'a', 't', 9, 9},
+	} {
+		boxReader := fmp4.NewBoxReader(bytes.NewReader(truncated))
+		if _, err := boxReader.Advance(); err != io.ErrUnexpectedEOF {
+			t.Fatal("Expected", io.ErrUnexpectedEOF, "but got", err)
+		}
+	}
+	boxReader = fmp4.NewBoxReader(bytes.NewReader([]byte{0, 0, 0, 1, 'm', 'd', 'a', 't', 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}))
+	if _, err := boxReader.Advance(); err != fmp4.InvalidBox {
+		t.Fatal("Expected", fmp4.InvalidBox, "but got", err)
+	}
+}
+
+func TestParseBoxes(t *testing.T) {
+	{
+		boxes, err := fmp4.ParseBoxes([]byte{0, 0, 0, 8, 'm', 'f', 'h', 'd', 0, 0, 0, 9, 't', 'r', 'a', 'f', 1})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(boxes) != 2 || boxes[1].Type != "traf" || boxes[1].Offset != 8 {
+			t.Fatal("Unexpected boxes", boxes)
+		}
+	}
+
+	{
+		if _, err := fmp4.ParseBoxes([]byte{0, 0, 0, 20, 'm', 'f', 'h', 'd'}); err != fmp4.InvalidBox {
+			t.Fatal("Expected", fmp4.InvalidBox, "but got", err)
+		}
+	}
+}
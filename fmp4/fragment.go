@@ -0,0 +1,297 @@
+package fmp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	UnknownTrack    error = errors.New("Track fragment references a track that is not in the initialization segment")
+	MissingMediaBox error = errors.New("Movie fragment has no mdat box")
+)
+
+// Sample is a sample of a track run.
+type Sample struct {
+	Duration              uint32
+	Size                  uint32
+	Flags                 uint32
+	CompositionTimeOffset int32
+	// Offset is the byte offset of the sample data from the beginning of the stream.
+	Offset int64
+}
+
+// IsSync reports whether the sample is a sync sample (keyframe).
+// A sample is a sync sample if sample_is_non_sync_sample is not set in its flags.
+func (sample *Sample) IsSync() bool {
+	return sample.Flags&0x00010000 == 0
+}
+
+// TrackFragment is a track fragment (traf) of a movie fragment.
+type TrackFragment struct {
+	TrackID uint32
+	// BaseMediaDecodeTime is the decode time of the first sample in the track timescale (tfdt).
+	BaseMediaDecodeTime uint64
+	Samples             []Sample
+}
+
+// Duration returns the sum of the sample durations in the track timescale.
+func (tf *TrackFragment) Duration() uint64 {
+	var duration uint64
+	for _, sample := range tf.Samples {
+		duration += uint64(sample.Duration)
+	}
+	return duration
+}
+
+// Fragment is a movie fragment which is a moof box followed by a mdat box.
+// A fragment is a CMAF chunk.
+type Fragment struct {
+	SequenceNumber uint32
+	// Offset is the byte offset of the first box of the fragment. Boxes like styp, prft and emsg in front of the moof box belong to the fragment.
+	Offset int64
+	// MoofOffset is the byte offset of the moof box.
+	MoofOffset int64
+	// Size is the size of the fragment in bytes up to the end of the mdat box.
+	Size   int64
+	Tracks []TrackFragment
+	// Raw is the whole fragment.
+	Raw []byte
+}
+
+// StartsWithSyncSample reports whether the first sample of every track fragment is a sync sample.
+func (fragment *Fragment) StartsWithSyncSample() bool {
+	for _, tf := range fragment.Tracks {
+		if len(tf.Samples) > 0 && !tf.Samples[0].IsSync() {
+			return false
+		}
+	}
+	return true
+}
+
+// FragmentReader reads movie fragments of a Media Segment or a fragmented MP4 file.
+type FragmentReader struct {
+	boxReader   BoxReader
+	initSegment InitSegment
+}
+
+// NewFragmentReader returns a new FragmentReader. initSegment provides the track defaults of the fragments.
+// initSegment can be empty if r is a whole fragmented MP4 file starting with a moov box.
+func NewFragmentReader(r io.Reader, initSegment InitSegment) FragmentReader {
+	return FragmentReader{
+		boxReader:   NewBoxReader(r),
+		initSegment: initSegment,
+	}
+}
+
+// InitSegment returns the InitSegment used by fr. It is the InitSegment passed to NewFragmentReader or the moov box read by fr.
+func (fr *FragmentReader) InitSegment() InitSegment {
+	return fr.initSegment
+}
+
+// Advance reads the next fragment. Advance returns io.EOF when there are no more fragments.
+// ftyp boxes are skipped and moov boxes replace the InitSegment of fr so a whole fragmented MP4 file can be read.
+func (fr *FragmentReader) Advance() (Fragment, error) {
+	fragment := Fragment{
+		Offset: -1,
+	}
+	for {
+		box, err := fr.boxReader.Advance()
+		if err == io.EOF && fragment.Offset >= 0 {
+			return fragment, MissingMediaBox
+		} else if err != nil {
+			return fragment, err
+		}
+
+		switch box.Type {
+		case "moov":
+			tracks, err := parseMovieBox(box.Body())
+			if err != nil {
+				return fragment, err
+			}
+			fr.initSegment = InitSegment{
+				Tracks: tracks,
+				Size:   box.Offset + box.Size,
+			}
+			continue
+		case "ftyp", "free", "skip", "sidx":
+			if fragment.Offset < 0 {
+				continue
+			}
+		}
+		if fragment.Offset < 0 {
+			fragment.Offset = box.Offset
+		}
+		fragment.Raw = append(fragment.Raw, box.Raw...)
+
+		switch box.Type {
+		case "moof":
+			fragment.MoofOffset = box.Offset
+			if err := fr.parseMovieFragment(&fragment, box); err != nil {
+				return fragment, err
+			}
+		case "mdat":
+			fragment.Size = box.Offset + box.Size - fragment.Offset
+			return fragment, nil
+		}
+	}
+}
+
+func (fr *FragmentReader) parseMovieFragment(fragment *Fragment, moof Box) error {
+	boxes, err := ParseBoxes(moof.Body())
+	if err != nil {
+		return err
+	}
+
+	if mfhd, ok := findBox(boxes, "mfhd"); ok {
+		_, _, b, err := fullBox(mfhd.Body())
+		if err != nil || len(b) < 4 {
+			return InvalidBox
+		}
+		fragment.SequenceNumber = binary.BigEndian.Uint32(b)
+	}
+
+	for _, box := range boxes {
+		if box.Type != "traf" {
+			continue
+		}
+		tf, err := fr.parseTrackFragment(box.Body(), moof.Offset)
+		if err != nil {
+			return err
+		}
+		fragment.Tracks = append(fragment.Tracks, tf)
+	}
+	return nil
+}
+
+func (fr *FragmentReader) parseTrackFragment(body []byte, moofOffset int64) (TrackFragment, error) {
+	tf := TrackFragment{}
+	boxes, err := ParseBoxes(body)
+	if err != nil {
+		return tf, err
+	}
+
+	tfhd, ok := findBox(boxes, "tfhd")
+	if !ok {
+		return tf, InvalidBox
+	}
+	_, flags, b, err := fullBox(tfhd.Body())
+	if err != nil || len(b) < 4 {
+		return tf, InvalidBox
+	}
+	tf.TrackID = binary.BigEndian.Uint32(b)
+	track, ok := fr.initSegment.Track(tf.TrackID)
+	if !ok {
+		return tf, UnknownTrack
+	}
+
+	b = b[4:]
+	baseDataOffset := moofOffset
+	defaultDuration := track.DefaultSampleDuration
+	defaultSize := track.DefaultSampleSize
+	defaultFlags := track.DefaultSampleFlags
+	fields := []struct {
+		flag uint32
+		size int
+		set  func([]byte)
+	}{
+		{0x01, 8, func(v []byte) { baseDataOffset = int64(binary.BigEndian.Uint64(v)) }},
+		{0x02, 4, func(v []byte) {}},
+		{0x08, 4, func(v []byte) { defaultDuration = binary.BigEndian.Uint32(v) }},
+		{0x10, 4, func(v []byte) { defaultSize = binary.BigEndian.Uint32(v) }},
+		{0x20, 4, func(v []byte) { defaultFlags = binary.BigEndian.Uint32(v) }},
+	}
+	for _, field := range fields {
+		if flags&field.flag == 0 {
+			continue
+		} else if len(b) < field.size {
+			return tf, InvalidBox
+		}
+		field.set(b[:field.size])
+		b = b[field.size:]
+	}
+
+	if tfdt, ok := findBox(boxes, "tfdt"); ok {
+		version, _, b, err := fullBox(tfdt.Body())
+		if err != nil {
+			return tf, err
+		} else if version == 1 && len(b) >= 8 {
+			tf.BaseMediaDecodeTime = binary.BigEndian.Uint64(b)
+		} else if version == 0 && len(b) >= 4 {
+			tf.BaseMediaDecodeTime = uint64(binary.BigEndian.Uint32(b))
+		} else {
+			return tf, InvalidBox
+		}
+	}
+
+	dataOffset := baseDataOffset
+	for _, box := range boxes {
+		if box.Type != "trun" {
+			continue
+		}
+		_, flags, b, err := fullBox(box.Body())
+		if err != nil || len(b) < 4 {
+			return tf, InvalidBox
+		}
+		count := int(binary.BigEndian.Uint32(b))
+		b = b[4:]
+		if flags&0x01 != 0 {
+			if len(b) < 4 {
+				return tf, InvalidBox
+			}
+			dataOffset = baseDataOffset + int64(int32(binary.BigEndian.Uint32(b)))
+			b = b[4:]
+		}
+		firstFlags, hasFirstFlags := uint32(0), flags&0x04 != 0
+		if hasFirstFlags {
+			if len(b) < 4 {
+				return tf, InvalidBox
+			}
+			firstFlags = binary.BigEndian.Uint32(b)
+			b = b[4:]
+		}
+
+		for i := range count {
+			sample := Sample{
+				Duration: defaultDuration,
+				Size:     defaultSize,
+				Flags:    defaultFlags,
+			}
+			if i == 0 && hasFirstFlags {
+				sample.Flags = firstFlags
+			}
+			if flags&0x100 != 0 {
+				if len(b) < 4 {
+					return tf, InvalidBox
+				}
+				sample.Duration = binary.BigEndian.Uint32(b)
+				b = b[4:]
+			}
+			if flags&0x200 != 0 {
+				if len(b) < 4 {
+					return tf, InvalidBox
+				}
+				sample.Size = binary.BigEndian.Uint32(b)
+				b = b[4:]
+			}
+			if flags&0x400 != 0 {
+				if len(b) < 4 {
+					return tf, InvalidBox
+				}
+				sample.Flags = binary.BigEndian.Uint32(b)
+				b = b[4:]
+			}
+			if flags&0x800 != 0 {
+				if len(b) < 4 {
+					return tf, InvalidBox
+				}
+				sample.CompositionTimeOffset = int32(binary.BigEndian.Uint32(b))
+				b = b[4:]
+			}
+			sample.Offset = dataOffset
+			dataOffset += int64(sample.Size)
+			tf.Samples = append(tf.Samples, sample)
+		}
+	}
+	return tf, nil
+}
@@ -0,0 +1,80 @@
+package fmp4_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+func TestFragmentReader(t *testing.T) {
+	initSegment := readInitSegment(t)
+	f, err := os.Open("testdata/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fragmentReader := fmp4.NewFragmentReader(f, initSegment)
+	offset := initSegment.Size
+	for i := 0; ; i++ {
+		fragment, err := fragmentReader.Advance()
+		if err == io.EOF {
+			if i != 12 {
+				t.Fatal("Expected 12 fragments but got", i)
+			}
+			return
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if fragment.Offset != offset {
+			t.Fatal("Expected fragment offset", offset, "but got", fragment.Offset)
+		} else if int64(len(fragment.Raw)) != fragment.Size {
+			t.Fatal("Expected", fragment.Size, "raw bytes but got", len(fragment.Raw))
+		} else if fragment.SequenceNumber != uint32(i+1) {
+			t.Fatal("Expected sequence number", i+1, "but got", fragment.SequenceNumber)
+		} else if fragment.StartsWithSyncSample() != (i%3 == 0) {
+			t.Fatal("Unexpected sync sample for fragment", i)
+		}
+		offset += fragment.Size
+
+		tf := fragment.Tracks[0]
+		if tf.BaseMediaDecodeTime != uint64(i*30000) {
+			t.Fatal("Expected decode time", i*30000, "but got", tf.BaseMediaDecodeTime)
+		} else if tf.Duration() != 30000 {
+			t.Fatal("Expected duration 30000 but got", tf.Duration())
+		}
+
+		//The first sample data is a length prefixed NAL unit.
+		sample := tf.Samples[0]
+		nal := fragment.Raw[sample.Offset-fragment.Offset+4]
+		if sample.IsSync() != (nal == 0x65) {
+			t.Fatal("Sample offset does not point at the sample data", sample.Offset)
+		}
+	}
+}
+
+func TestFragmentReader_MovieBox(t *testing.T) {
+	f, err := os.Open("testdata/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fragmentReader := fmp4.NewFragmentReader(f, fmp4.InitSegment{})
+	fragment, err := fragmentReader.Advance()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initSegment := fragmentReader.InitSegment()
+	if initSegment.Size != 648 {
+		t.Fatal("Expected init segment size 648 but got", initSegment.Size)
+	} else if fragment.Offset != 648 {
+		t.Fatal("Expected fragment offset 648 but got", fragment.Offset)
+	} else if _, ok := initSegment.Track(1); !ok {
+		t.Fatal("Expected track 1 in the moov box")
+	}
+}
@@ -0,0 +1,299 @@
+package fmp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	MissingMovieBox error = errors.New("Initialization segment has no moov box")
+)
+
+// Track is a track of a Media Initialization Section.
+type Track struct {
+	ID uint32
+	// Timescale is the number of time units per second of the track (mdhd).
+	Timescale uint32
+	// HandlerType is the handler_type of the track (hdlr). "vide" for video and "soun" for audio.
+	HandlerType string
+	// Codec is the RFC 6381 codec string of the first sample entry (stsd) used in the CODECS attribute. e.g. avc1.64001f or mp4a.40.2
+	// Codec is the sample entry type if the codec parameters are unknown.
+	Codec string
+	// Width and Height are the picture size of a video sample entry.
+	Width  int
+	Height int
+	// Default sample values of the track extends box (trex).
+	DefaultSampleDuration uint32
+	DefaultSampleSize     uint32
+	DefaultSampleFlags    uint32
+}
+
+// InitSegment is a Media Initialization Section.
+type InitSegment struct {
+	Tracks []Track
+	// Size is the byte size of the boxes up to and including the moov box.
+	Size int64
+}
+
+// Track returns the track with the id.
+func (initSegment *InitSegment) Track(id uint32) (Track, bool) {
+	for _, track := range initSegment.Tracks {
+		if track.ID == id {
+			return track, true
+		}
+	}
+	return Track{}, false
+}
+
+// ParseInitSegment reads boxes from r until the moov box and returns the InitSegment.
+func ParseInitSegment(r io.Reader) (InitSegment, error) {
+	initSegment := InitSegment{}
+	boxReader := NewBoxReader(r)
+	for {
+		box, err := boxReader.Advance()
+		if err == io.EOF {
+			return initSegment, MissingMovieBox
+		} else if err != nil {
+			return initSegment, err
+		}
+
+		if box.Type == "moov" {
+			initSegment.Size = box.Offset + box.Size
+			initSegment.Tracks, err = parseMovieBox(box.Body())
+			return initSegment, err
+		}
+	}
+}
+
+func parseMovieBox(body []byte) ([]Track, error) {
+	boxes, err := ParseBoxes(body)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]Track, 0, 2)
+	for _, box := range boxes {
+		if box.Type != "trak" {
+			continue
+		}
+		track, err := parseTrackBox(box.Body())
+		if err != nil {
+			return tracks, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	if mvex, ok := findBox(boxes, "mvex"); ok {
+		children, err := ParseBoxes(mvex.Body())
+		if err != nil {
+			return tracks, err
+		}
+		for _, box := range children {
+			if box.Type != "trex" {
+				continue
+			}
+			_, _, b, err := fullBox(box.Body())
+			if err != nil || len(b) < 20 {
+				return tracks, InvalidBox
+			}
+			id := binary.BigEndian.Uint32(b)
+			for i := range tracks {
+				if tracks[i].ID == id {
+					tracks[i].DefaultSampleDuration = binary.BigEndian.Uint32(b[8:])
+					tracks[i].DefaultSampleSize = binary.BigEndian.Uint32(b[12:])
+					tracks[i].DefaultSampleFlags = binary.BigEndian.Uint32(b[16:])
+				}
+			}
+		}
+	}
+	return tracks, nil
+}
+
+func parseTrackBox(body []byte) (Track, error) {
+	track := Track{}
+	boxes, err := ParseBoxes(body)
+	if err != nil {
+		return track, err
+	}
+
+	tkhd, ok := findBox(boxes, "tkhd")
+	if !ok {
+		return track, InvalidBox
+	}
+	version, _, b, err := fullBox(tkhd.Body())
+	if err != nil {
+		return track, err
+	}
+	//creation_time and modification_time are 64 bit in version 1.
+	idOffset := 8
+	if version == 1 {
+		idOffset = 16
+	}
+	if len(b) < idOffset+4 {
+		return track, InvalidBox
+	}
+	track.ID = binary.BigEndian.Uint32(b[idOffset:])
+
+	mdia, ok := findBox(boxes, "mdia")
+	if !ok {
+		return track, InvalidBox
+	}
+	mdiaBoxes, err := ParseBoxes(mdia.Body())
+	if err != nil {
+		return track, err
+	}
+
+	mdhd, ok := findBox(mdiaBoxes, "mdhd")
+	if !ok {
+		return track, InvalidBox
+	}
+	version, _, b, err = fullBox(mdhd.Body())
+	if err != nil {
+		return track, err
+	}
+	timescaleOffset := 8
+	if version == 1 {
+		timescaleOffset = 16
+	}
+	if len(b) < timescaleOffset+4 {
+		return track, InvalidBox
+	}
+	track.Timescale = binary.BigEndian.Uint32(b[timescaleOffset:])
+	if track.Timescale == 0 {
+		return track, InvalidBox
+	}
+
+	if hdlr, ok := findBox(mdiaBoxes, "hdlr"); ok {
+		_, _, b, err := fullBox(hdlr.Body())
+		if err != nil || len(b) < 8 {
+			return track, InvalidBox
+		}
+		track.HandlerType = string(b[4:8])
+	}
+
+	minf, ok := findBox(mdiaBoxes, "minf")
+	if !ok {
+		return track, nil
+	}
+	stbl, ok := findBox(childBoxes(minf.Body()), "stbl")
+	if !ok {
+		return track, nil
+	}
+	if stsd, ok := findBox(childBoxes(stbl.Body()), "stsd"); ok {
+		_, _, b, err := fullBox(stsd.Body())
+		if err != nil || len(b) < 4 {
+			return track, InvalidBox
+		}
+		entries, err := ParseBoxes(b[4:])
+		if err != nil {
+			return track, err
+		} else if len(entries) > 0 {
+			parseSampleEntry(&track, entries[0])
+		}
+	}
+	return track, nil
+}
+
+// childBoxes returns the boxes of body or no boxes if body is invalid.
+func childBoxes(body []byte) []Box {
+	boxes, err := ParseBoxes(body)
+	if err != nil {
+		return nil
+	}
+	return boxes
+}
+
+// parseSampleEntry sets the codec and the picture size of track from a sample entry.
+func parseSampleEntry(track *Track, entry Box) {
+	track.Codec = entry.Type
+	body := entry.Body()
+	switch entry.Type {
+	case "avc1", "avc3", "hvc1", "hev1":
+		//VisualSampleEntry: 8 bytes SampleEntry, 16 bytes pre_defined and reserved, width, height and 50 more bytes.
+		if len(body) < 78 {
+			return
+		}
+		track.Width = int(binary.BigEndian.Uint16(body[24:]))
+		track.Height = int(binary.BigEndian.Uint16(body[26:]))
+		if avcC, ok := findBox(childBoxes(body[78:]), "avcC"); ok && len(avcC.Body()) >= 4 {
+			b := avcC.Body()
+			track.Codec = fmt.Sprintf("%s.%02x%02x%02x", entry.Type, b[1], b[2], b[3])
+		}
+	case "mp4a":
+		//AudioSampleEntry: 8 bytes SampleEntry and 20 bytes of audio fields.
+		if len(body) < 28 {
+			return
+		}
+		esds, ok := findBox(childBoxes(body[28:]), "esds")
+		if !ok {
+			return
+		}
+		_, _, b, err := fullBox(esds.Body())
+		if err != nil {
+			return
+		}
+		if objectType, audioObjectType, ok := parseESDescriptor(b); ok {
+			track.Codec = fmt.Sprintf("mp4a.%x", objectType)
+			if audioObjectType > 0 {
+				track.Codec += fmt.Sprintf(".%d", audioObjectType)
+			}
+		}
+	}
+}
+
+// parseESDescriptor returns the objectTypeIndication of the DecoderConfigDescriptor and the audioObjectType of the AudioSpecificConfig of a ES_Descriptor.
+func parseESDescriptor(b []byte) (uint8, uint8, bool) {
+	var objectType, audioObjectType uint8
+	found := false
+	for len(b) >= 2 {
+		tag := b[0]
+		//The size is encoded in up to four bytes with 7 bits each.
+		size, i := 0, 1
+		for ; i < len(b) && i <= 4; i++ {
+			size = size<<7 | int(b[i]&0x7F)
+			if b[i]&0x80 == 0 {
+				break
+			}
+		}
+		b = b[min(i+1, len(b)):]
+		if size > len(b) {
+			size = len(b)
+		}
+
+		switch tag {
+		case 0x03: //ES_Descriptor
+			if size < 3 {
+				return 0, 0, false
+			}
+			flags := b[2]
+			skip := 3
+			if flags&0x80 != 0 {
+				skip += 2
+			}
+			if flags&0x40 != 0 && len(b) > skip {
+				skip += 1 + int(b[skip])
+			}
+			if flags&0x20 != 0 {
+				skip += 2
+			}
+			b = b[min(skip, len(b)):]
+		case 0x04: //DecoderConfigDescriptor
+			if size < 13 {
+				return 0, 0, false
+			}
+			objectType = b[0]
+			found = true
+			b = b[13:]
+		case 0x05: //DecoderSpecificInfo
+			if size > 0 {
+				audioObjectType = b[0] >> 3
+			}
+			return objectType, audioObjectType, found
+		default:
+			b = b[size:]
+		}
+	}
+	return objectType, audioObjectType, found
+}
@@ -0,0 +1,88 @@
+package fmp4_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+func readInitSegment(t *testing.T) fmp4.InitSegment {
+	t.Helper()
+	f, err := os.Open("testdata/init.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	initSegment, err := fmp4.ParseInitSegment(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return initSegment
+}
+
+func TestParseInitSegment(t *testing.T) {
+	initSegment := readInitSegment(t)
+	if len(initSegment.Tracks) != 1 {
+		t.Fatal("Expected 1 track but got", len(initSegment.Tracks))
+	} else if initSegment.Size != 648 {
+		t.Fatal("Expected size 648 but got", initSegment.Size)
+	}
+
+	track, ok := initSegment.Track(1)
+	if !ok {
+		t.Fatal("Expected track 1")
+	} else if track.Timescale != 90000 {
+		t.Fatal("Expected timescale 90000 but got", track.Timescale)
+	} else if track.HandlerType != "vide" {
+		t.Fatal("Expected handler type vide but got", track.HandlerType)
+	} else if track.DefaultSampleDuration != 3000 {
+		t.Fatal("Expected default sample duration 3000 but got", track.DefaultSampleDuration)
+	} else if track.Codec != "avc1.64001f" {
+		t.Fatal("Expected codec avc1.64001f but got", track.Codec)
+	} else if track.Width != 1280 || track.Height != 720 {
+		t.Fatal("Expected 1280x720 but got", track.Width, track.Height)
+	}
+
+	if _, err := fmp4.ParseInitSegment(bytes.NewReader(nil)); err != fmp4.MissingMovieBox {
+		t.Fatal("Expected", fmp4.MissingMovieBox, "but got", err)
+	}
+}
+
+func box(boxType string, payloads ...[]byte) []byte {
+	body := bytes.Join(payloads, nil)
+	size := len(body) + 8
+	return append([]byte{byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size), boxType[0], boxType[1], boxType[2], boxType[3]}, body...)
+}
+
+func TestParseInitSegment_Audio(t *testing.T) {
+	//ES_Descriptor with a DecoderConfigDescriptor for AAC (0x40) and a AudioSpecificConfig of AAC-LC (2).
+	esds := box("esds", make([]byte, 4), []byte{
+		0x03, 25, 0, 1, 0,
+		0x04, 17, 0x40, 0x15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0x05, 2, 0x12, 0x10,
+		0x06, 1, 2,
+	})
+	mp4a := box("mp4a", make([]byte, 28), esds)
+	stsd := box("stsd", make([]byte, 4), []byte{0, 0, 0, 1}, mp4a)
+	mdhd := box("mdhd", make([]byte, 12), []byte{0, 0, 0xAC, 0x44}, make([]byte, 8))
+	hdlr := box("hdlr", make([]byte, 8), []byte("soun"), make([]byte, 13))
+	mdia := box("mdia", mdhd, hdlr, box("minf", box("stbl", stsd)))
+	tkhd := box("tkhd", make([]byte, 12), []byte{0, 0, 0, 2}, make([]byte, 68))
+	moov := box("moov", box("trak", tkhd, mdia))
+
+	initSegment, err := fmp4.ParseInitSegment(bytes.NewReader(moov))
+	if err != nil {
+		t.Fatal(err)
+	}
+	track, ok := initSegment.Track(2)
+	if !ok {
+		t.Fatal("Expected track 2")
+	} else if track.Timescale != 44100 {
+		t.Fatal("Expected timescale 44100 but got", track.Timescale)
+	} else if track.Codec != "mp4a.40.2" {
+		t.Fatal("Expected codec mp4a.40.2 but got", track.Codec)
+	}
+}
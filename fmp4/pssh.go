@@ -0,0 +1,98 @@
+package fmp4
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	InvalidPSSH error = errors.New("Invalid pssh box")
+)
+
+// SystemID is the UUID of a DRM system.
+type SystemID [16]byte
+
+// String returns the UUID in its canonical form. e.g. edef8ba9-79d6-4ace-a3c8-27dcd51d21ed
+func (id SystemID) String() string {
+	s := hex.EncodeToString(id[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+var (
+	WidevineSystemID  = SystemID{0xed, 0xef, 0x8b, 0xa9, 0x79, 0xd6, 0x4a, 0xce, 0xa3, 0xc8, 0x27, 0xdc, 0xd5, 0x1d, 0x21, 0xed}
+	PlayReadySystemID = SystemID{0x9a, 0x04, 0xf0, 0x79, 0x98, 0x40, 0x42, 0x86, 0xab, 0x92, 0xe6, 0x5b, 0xe0, 0x88, 0x5f, 0x95}
+	FairPlaySystemID  = SystemID{0x94, 0xce, 0x86, 0xfb, 0x07, 0xff, 0x4f, 0x43, 0xad, 0xb8, 0x93, 0xd2, 0xfa, 0x96, 0x8c, 0xa2}
+	// CommonSystemID is the W3C common PSSH system id that only carries key ids.
+	CommonSystemID = SystemID{0x10, 0x77, 0xef, 0xec, 0xc0, 0xb2, 0x4d, 0x02, 0xac, 0xe3, 0x3c, 0x1e, 0x52, 0xe2, 0xfb, 0x4b}
+)
+
+// PSSH is a Protection System Specific Header box.
+type PSSH struct {
+	SystemID SystemID
+	// KeyIDs are the key ids of a version 1 box.
+	KeyIDs [][16]byte
+	// Data is the DRM system specific data.
+	Data []byte
+}
+
+// ParsePSSH parses a whole pssh box including its header.
+func ParsePSSH(b []byte) (PSSH, error) {
+	pssh := PSSH{}
+	boxes, err := ParseBoxes(b)
+	if err != nil || len(boxes) != 1 || boxes[0].Type != "pssh" {
+		return pssh, InvalidPSSH
+	}
+	version, _, body, err := fullBox(boxes[0].Body())
+	if err != nil || len(body) < 16 {
+		return pssh, InvalidPSSH
+	}
+	copy(pssh.SystemID[:], body)
+	body = body[16:]
+
+	if version > 0 {
+		if len(body) < 4 {
+			return pssh, InvalidPSSH
+		}
+		count := int(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if count > len(body)/16 {
+			return pssh, InvalidPSSH
+		}
+		pssh.KeyIDs = make([][16]byte, count)
+		for i := range pssh.KeyIDs {
+			copy(pssh.KeyIDs[i][:], body[i*16:])
+		}
+		body = body[count*16:]
+	}
+
+	if len(body) < 4 {
+		return pssh, InvalidPSSH
+	}
+	size := binary.BigEndian.Uint32(body)
+	if uint64(size) != uint64(len(body)-4) {
+		return pssh, InvalidPSSH
+	}
+	pssh.Data = body[4:]
+	return pssh, nil
+}
+
+// Encode returns the pssh box. A version 1 box is written if KeyIDs is not empty.
+func (pssh *PSSH) Encode() []byte {
+	version := byte(0)
+	if len(pssh.KeyIDs) > 0 {
+		version = 1
+	}
+	b := []byte{0, 0, 0, 0, 'p', 's', 's', 'h', version, 0, 0, 0}
+	b = append(b, pssh.SystemID[:]...)
+	if version > 0 {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(pssh.KeyIDs)))
+		for _, keyID := range pssh.KeyIDs {
+			b = append(b, keyID[:]...)
+		}
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(pssh.Data)))
+	b = append(b, pssh.Data...)
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	return b
+}
@@ -0,0 +1,46 @@
+package fmp4_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+func TestParsePSSH(t *testing.T) {
+	//The common PSSH box example of the W3C "cenc" initialization data format.
+	b, err := hex.DecodeString("0000003470737368010000001077efecc0b24d02ace33c1e52e2fb4b000000011234567890123456789012345678901200000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pssh, err := fmp4.ParsePSSH(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if pssh.SystemID != fmp4.CommonSystemID {
+		t.Fatal("Expected", fmp4.CommonSystemID, "but got", pssh.SystemID)
+	} else if len(pssh.KeyIDs) != 1 || hex.EncodeToString(pssh.KeyIDs[0][:]) != "12345678901234567890123456789012" {
+		t.Fatal("Unexpected key ids", pssh.KeyIDs)
+	} else if len(pssh.Data) != 0 {
+		t.Fatal("Expected no data but got", pssh.Data)
+	}
+	if encoded := pssh.Encode(); !bytes.Equal(encoded, b) {
+		t.Fatal("Expected", b, "but got", encoded)
+	}
+
+	{
+		pssh := fmp4.PSSH{SystemID: fmp4.WidevineSystemID, Data: []byte{0x12, 0x10, 1, 2}}
+		parsed, err := fmp4.ParsePSSH(pssh.Encode())
+		if err != nil {
+			t.Fatal(err)
+		} else if parsed.SystemID != pssh.SystemID || parsed.KeyIDs != nil || !bytes.Equal(parsed.Data, pssh.Data) {
+			t.Fatal("Expected", pssh, "but got", parsed)
+		}
+	}
+
+	if fmp4.WidevineSystemID.String() != "edef8ba9-79d6-4ace-a3c8-27dcd51d21ed" {
+		t.Fatal("Unexpected system id", fmp4.WidevineSystemID.String())
+	} else if _, err := fmp4.ParsePSSH(b[:len(b)-1]); err != fmp4.InvalidPSSH {
+		t.Fatal("Expected", fmp4.InvalidPSSH, "but got", err)
+	}
+}
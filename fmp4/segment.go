@@ -0,0 +1,119 @@
+package fmp4
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+	"time"
+)
+
+var (
+	MisalignedSegments error = errors.New("Segments are not sample accurately aligned")
+)
+
+// Segment is a Media Segment made of one or more fragments.
+type Segment struct {
+	Fragments []Fragment
+	// Size is the size of the segment in bytes.
+	Size int64
+}
+
+// ReadSegment reads a Media Segment from r. initSegment is the Media Initialization Section of the segment (EXT-X-MAP).
+// ReadSegment returns UnknownTrack if a fragment references a track that is not in initSegment.
+func ReadSegment(r io.Reader, initSegment InitSegment) (Segment, error) {
+	segment := Segment{}
+	fragmentReader := NewFragmentReader(r, initSegment)
+	for {
+		fragment, err := fragmentReader.Advance()
+		if err == io.EOF {
+			return segment, nil
+		} else if err != nil {
+			return segment, err
+		}
+		fragment.Raw = nil
+		segment.Size = fragment.Offset + fragment.Size
+		segment.Fragments = append(segment.Fragments, fragment)
+	}
+}
+
+// Timing returns the Timing of a track of the segment. initSegment must be the Media Initialization Section of the segment.
+// Timing returns false if the segment has no samples of the track.
+func (segment *Segment) Timing(initSegment InitSegment, trackID uint32) (Timing, bool) {
+	track, ok := initSegment.Track(trackID)
+	if !ok {
+		return Timing{}, false
+	}
+
+	timing := Timing{
+		TrackID:   trackID,
+		Timescale: track.Timescale,
+	}
+	found := false
+	var end uint64
+	for _, fragment := range segment.Fragments {
+		for _, tf := range fragment.Tracks {
+			if tf.TrackID != trackID || len(tf.Samples) == 0 {
+				continue
+			}
+			if !found || tf.BaseMediaDecodeTime < timing.DecodeTime {
+				timing.DecodeTime = tf.BaseMediaDecodeTime
+			}
+			end = max(end, tf.BaseMediaDecodeTime+tf.Duration())
+			found = true
+		}
+	}
+	timing.Duration = end - timing.DecodeTime
+	return timing, found
+}
+
+// StartsWithSyncSample reports whether the first fragment of the segment starts with a sync sample.
+func (segment *Segment) StartsWithSyncSample() bool {
+	return len(segment.Fragments) > 0 && segment.Fragments[0].StartsWithSyncSample()
+}
+
+// Timing is the decode time and the duration of a track in a segment in the track timescale.
+type Timing struct {
+	TrackID    uint32
+	Timescale  uint32
+	DecodeTime uint64
+	Duration   uint64
+}
+
+// Start returns the decode start time of the segment.
+func (timing *Timing) Start() time.Duration {
+	return toDuration(timing.DecodeTime, timing.Timescale)
+}
+
+// Seconds returns the duration of the segment in seconds. Seconds is the accurate value of the EXTINF tag of the segment.
+func (timing *Timing) Seconds() float64 {
+	return float64(timing.Duration) / float64(timing.Timescale)
+}
+
+// Aligned reports whether timing and other start and end at exactly the same time even if their timescales differ.
+func (timing *Timing) Aligned(other Timing) bool {
+	return equalTime(timing.DecodeTime, timing.Timescale, other.DecodeTime, other.Timescale) &&
+		equalTime(timing.DecodeTime+timing.Duration, timing.Timescale, other.DecodeTime+other.Duration, other.Timescale)
+}
+
+// CheckAlignment returns MisalignedSegments if the timings of the same segment of different renditions are not sample accurately aligned.
+func CheckAlignment(timings ...Timing) error {
+	for i := 1; i < len(timings); i++ {
+		if !timings[0].Aligned(timings[i]) {
+			return MisalignedSegments
+		}
+	}
+	return nil
+}
+
+// equalTime reports whether a/aTimescale == b/bTimescale without losing precision.
+func equalTime(a uint64, aTimescale uint32, b uint64, bTimescale uint32) bool {
+	aHi, aLo := bits.Mul64(a, uint64(bTimescale))
+	bHi, bLo := bits.Mul64(b, uint64(aTimescale))
+	return aHi == bHi && aLo == bLo
+}
+
+func toDuration(value uint64, timescale uint32) time.Duration {
+	seconds := value / uint64(timescale)
+	rest := value % uint64(timescale)
+	return time.Duration(seconds)*time.Second + time.Duration(rest)*time.Second/time.Duration(timescale)
+}
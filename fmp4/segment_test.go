@@ -0,0 +1,71 @@
+package fmp4_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+func readSegment(t *testing.T, initSegment fmp4.InitSegment, i int) fmp4.Segment {
+	t.Helper()
+	f, err := os.Open(fmt.Sprintf("testdata/seg%d.m4s", i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	segment, err := fmp4.ReadSegment(f, initSegment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return segment
+}
+
+func TestReadSegment(t *testing.T) {
+	initSegment := readInitSegment(t)
+	for i := range 2 {
+		segment := readSegment(t, initSegment, i)
+		if len(segment.Fragments) != 6 {
+			t.Fatal("Expected 6 fragments but got", len(segment.Fragments))
+		} else if !segment.StartsWithSyncSample() {
+			t.Fatal("Expected the segment to start with a sync sample")
+		}
+
+		timing, ok := segment.Timing(initSegment, 1)
+		if !ok {
+			t.Fatal("Expected timing of track 1")
+		} else if timing.Start() != time.Duration(i)*2*time.Second {
+			t.Fatal("Expected start", i*2, "but got", timing.Start())
+		} else if timing.Seconds() != 2 {
+			t.Fatal("Expected duration 2 but got", timing.Seconds())
+		}
+	}
+
+	{
+		f, err := os.Open("testdata/seg0.m4s")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if _, err := fmp4.ReadSegment(f, fmp4.InitSegment{}); err != fmp4.UnknownTrack {
+			t.Fatal("Expected", fmp4.UnknownTrack, "but got", err)
+		}
+	}
+}
+
+func TestCheckAlignment(t *testing.T) {
+	video := fmp4.Timing{TrackID: 1, Timescale: 90000, DecodeTime: 180000, Duration: 180000}
+	other := fmp4.Timing{TrackID: 1, Timescale: 30000, DecodeTime: 60000, Duration: 60000}
+	if err := fmp4.CheckAlignment(video, other); err != nil {
+		t.Fatal(err)
+	}
+
+	other.Duration++
+	if err := fmp4.CheckAlignment(video, other); err != fmp4.MisalignedSegments {
+		t.Fatal("Expected", fmp4.MisalignedSegments, "but got", err)
+	}
+}
@@ -0,0 +1,110 @@
+package HLS
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	MediaSequenceDecreased error = errors.New("Media sequence number of the reloaded playlist decreased")
+)
+
+// Segment is a Media Segment of a followed Media Playlist.
+type Segment struct {
+	MediaSegment
+	SequenceNumber uint64
+	// DiscontinuitySequence is the Discontinuity Sequence Number of the segment.
+	DiscontinuitySequence uint64
+	// Skipped is the number of segments in front of the segment that were removed from the playlist before they were loaded.
+	Skipped uint64
+	// Err is only set on the last value sent before the channel is closed. It is PlaylistEnded if the playlist has a
+	// EXT-X-ENDLIST tag, MediaSequenceDecreased if the playlist jumped back or the error of a failed reload.
+	Err error
+}
+
+// Follow loads the Media Playlist at uri and sends every segment of it and of each reloaded playlist exactly once in
+// order of the Media Sequence Numbers. uri can be relative to c.URL.
+// The playlist is reloaded as described by RFC 8216 section 6.3.4: A target duration after the last load started if the
+// playlist changed and half a target duration after the last load started if it did not.
+// The channel is closed after a value with Err or when ctx is done. Follow returns the error of the first load.
+func (c *Client) Follow(ctx context.Context, uri string) (<-chan Segment, error) {
+	loaded := time.Now()
+	mp, err := c.MediaPlaylist(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	segments := make(chan Segment)
+	go c.follow(ctx, uri, mp, loaded, segments)
+	return segments, nil
+}
+
+func (c *Client) follow(ctx context.Context, uri string, mp MediaPlaylist, loaded time.Time, segments chan<- Segment) {
+	defer close(segments)
+	send := func(segment Segment) bool {
+		select {
+		case segments <- segment:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	next, mediaSequence := mp.MediaSequence, mp.MediaSequence
+	reloaded := false
+	for {
+		if mp.MediaSequence < mediaSequence || mp.MediaSequence+uint64(len(mp.Segments)) < next {
+			send(Segment{Err: MediaSequenceDecreased})
+			return
+		}
+		mediaSequence = mp.MediaSequence
+
+		skipped := uint64(0)
+		if mp.MediaSequence > next {
+			skipped = mp.MediaSequence - next
+			next = mp.MediaSequence
+		}
+		changed := false
+		discontinuitySequence := mp.DiscontinuitySequence
+		for i, segment := range mp.Segments {
+			if segment.Discontinuity {
+				discontinuitySequence++
+			}
+			sequenceNumber := mp.MediaSequence + uint64(i)
+			if sequenceNumber < next {
+				continue
+			}
+			if !send(Segment{MediaSegment: segment, SequenceNumber: sequenceNumber, DiscontinuitySequence: discontinuitySequence, Skipped: skipped}) {
+				return
+			}
+			skipped = 0
+			next++
+			changed = true
+		}
+		if mp.EndList {
+			send(Segment{Err: PlaylistEnded})
+			return
+		}
+
+		wait := time.Duration(mp.TargetDuration) * time.Second
+		if reloaded && !changed {
+			wait /= 2
+		}
+		timer := time.NewTimer(time.Until(loaded.Add(wait)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		loaded, reloaded = time.Now(), true
+		var err error
+		if mp, err = c.MediaPlaylist(ctx, uri); err != nil {
+			if ctx.Err() == nil {
+				send(Segment{Err: err})
+			}
+			return
+		}
+	}
+}
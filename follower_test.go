@@ -0,0 +1,127 @@
+package HLS_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+// reloadServer serves the playlists one after another and repeats the last one.
+type reloadServer struct {
+	mu        sync.Mutex
+	playlists []string
+	requests  []time.Time
+}
+
+func (rs *reloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	playlist := rs.playlists[min(len(rs.requests), len(rs.playlists)-1)]
+	rs.requests = append(rs.requests, time.Now())
+	w.Write([]byte(playlist))
+}
+
+func TestFollow(t *testing.T) {
+	{
+		rs := &reloadServer{playlists: []string{
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1,\nseg0.ts\n#EXTINF:1,\nseg1.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1,\nseg0.ts\n#EXTINF:1,\nseg1.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:3\n#EXT-X-DISCONTINUITY-SEQUENCE:1\n#EXTINF:1,\nseg3.ts\n#EXT-X-DISCONTINUITY\n#EXTINF:1,\nseg4.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:3\n#EXT-X-DISCONTINUITY-SEQUENCE:1\n#EXTINF:1,\nseg3.ts\n#EXT-X-DISCONTINUITY\n#EXTINF:1,\nseg4.ts\n#EXTINF:1,\nseg5.ts\n#EXT-X-ENDLIST\n",
+		}}
+		server := httptest.NewServer(rs)
+		defer server.Close()
+		client, err := HLS.NewClient(server.Client(), server.URL+"/master.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments, err := client.Follow(context.Background(), "live.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []HLS.Segment{
+			{SequenceNumber: 0},
+			{SequenceNumber: 1},
+			{SequenceNumber: 3, DiscontinuitySequence: 1, Skipped: 1},
+			{SequenceNumber: 4, DiscontinuitySequence: 2},
+			{SequenceNumber: 5, DiscontinuitySequence: 2},
+			{Err: HLS.PlaylistEnded},
+		}
+		i := 0
+		for segment := range segments {
+			if i >= len(expected) {
+				t.Fatal("Unexpected segment", segment)
+			}
+			if segment.SequenceNumber != expected[i].SequenceNumber || segment.DiscontinuitySequence != expected[i].DiscontinuitySequence || segment.Skipped != expected[i].Skipped || segment.Err != expected[i].Err {
+				t.Fatal("Expected", expected[i], "but got", segment)
+			}
+			if segment.Err == nil && segment.URI != fmt.Sprintf("%s/seg%d.ts", server.URL, segment.SequenceNumber) {
+				t.Fatal("Unexpected URI", segment.URI)
+			}
+			i++
+		}
+		if i != len(expected) {
+			t.Fatal("Expected", len(expected), "values but got", i)
+		}
+
+		//The playlist is reloaded after a target duration if it changed and after half of it if it did not.
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		for j, wait := range []time.Duration{time.Second, time.Second / 2, time.Second} {
+			if elapsed := rs.requests[j+1].Sub(rs.requests[j]); elapsed < wait-10*time.Millisecond {
+				t.Fatal("Expected reload", j+1, "after", wait, "but got", elapsed)
+			}
+		}
+	}
+	{
+		rs := &reloadServer{playlists: []string{
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:5\n#EXTINF:1,\nseg5.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:2\n#EXTINF:1,\nseg2.ts\n",
+		}}
+		server := httptest.NewServer(rs)
+		defer server.Close()
+		client, err := HLS.NewClient(server.Client(), server.URL+"/live.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments, err := client.Follow(context.Background(), "live.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if segment := <-segments; segment.SequenceNumber != 5 || segment.Err != nil {
+			t.Fatal("Unexpected segment", segment)
+		}
+		if segment := <-segments; segment.Err != HLS.MediaSequenceDecreased {
+			t.Fatal("Expected", HLS.MediaSequenceDecreased, "but got", segment.Err)
+		}
+		if _, ok := <-segments; ok {
+			t.Fatal("Expected the channel to be closed")
+		}
+	}
+	{
+		rs := &reloadServer{playlists: []string{"#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg0.ts\n"}}
+		server := httptest.NewServer(rs)
+		defer server.Close()
+		client, err := HLS.NewClient(server.Client(), server.URL+"/live.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		segments, err := client.Follow(ctx, "live.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-segments
+		cancel()
+		if _, ok := <-segments; ok {
+			t.Fatal("Expected the channel to be closed after the context is canceled")
+		}
+	}
+}
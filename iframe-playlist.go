@@ -0,0 +1,238 @@
+package HLS
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// IFramePlaylistGenerator generates the I-frame playlist of a Media Playlist for trick play (fast forward, rewind and scrubbing).
+// Every I-frame of the segments becomes a EXT-X-BYTERANGE sub-range of its segment. Segments can be MPEG-TS or fragmented MP4.
+type IFramePlaylistGenerator struct {
+	// Open opens the resource of a Media Segment URI or a EXT-X-MAP URI.
+	Open func(uri string) (io.ReadCloser, error)
+}
+
+// NewIFramePlaylistGenerator returns a new IFramePlaylistGenerator that reads segments using open.
+func NewIFramePlaylistGenerator(open func(uri string) (io.ReadCloser, error)) IFramePlaylistGenerator {
+	return IFramePlaylistGenerator{
+		Open: open,
+	}
+}
+
+// iFrame is a I-frame of a segment. The byte range is relative to the segment data.
+type iFrame struct {
+	byteRange ByteRange
+	// duration is the duration until the next I-frame or the end of the segment in seconds.
+	duration float64
+}
+
+// Generate reads the segments of mediaPlaylist and returns its I-frame playlist and the EXT-X-I-FRAME-STREAM-INF tag of the I-frame playlist
+// for the Master Playlist. uri is the URI of the I-frame playlist.
+// The duration of each I-frame is the time until the next I-frame or the end of its segment.
+func (g *IFramePlaylistGenerator) Generate(mediaPlaylist MediaPlaylist, uri string) (MediaPlaylist, IFrameStream, error) {
+	iFramePlaylist := MediaPlaylist{
+		MediaSequence:         mediaPlaylist.MediaSequence,
+		DiscontinuitySequence: mediaPlaylist.DiscontinuitySequence,
+		PlaylistType:          mediaPlaylist.PlaylistType,
+		IFramesOnly:           true,
+		EndList:               mediaPlaylist.EndList,
+	}
+	stream := IFrameStream{
+		URI: uri,
+	}
+
+	var initSegment fmp4.InitSegment
+	var initSection *MediaInitializationSection
+	var totalSize int64
+	var totalDuration, peak float64
+	for _, segment := range mediaPlaylist.Segments {
+		data, err := g.read(segment.URI, segment.ByteRange)
+		if err != nil {
+			return iFramePlaylist, stream, err
+		}
+		var base int64
+		if segment.ByteRange != nil {
+			base = segment.ByteRange.Offset
+		}
+
+		var iFrames []iFrame
+		var psi *MediaInitializationSection
+		if len(data) > 0 && data[0] == mpegts.SyncByte {
+			info, err := mpegts.Inspect(bytes.NewReader(data))
+			if err != nil {
+				return iFramePlaylist, stream, err
+			}
+			iFrames = tsIFrames(info)
+			if info.SPS != nil {
+				stream.Codecs = []string{info.SPS.Codec()}
+				stream.Resolution = &Resolution{Width: info.SPS.Width, Height: info.SPS.Height}
+			}
+
+			//A I-frame does not contain the PAT and the PMT of its segment so they are referenced by a EXT-X-MAP tag.
+			if segment.Map != nil {
+				psi = segment.Map
+			} else if offset, ok := findPSI(data); ok {
+				psi = &MediaInitializationSection{
+					URI:       segment.URI,
+					ByteRange: &ByteRange{Length: 2 * mpegts.PacketSize, Offset: base + offset},
+				}
+			}
+		} else {
+			if segment.Map != nil && !segment.Map.equal(initSection) {
+				b, err := g.read(segment.Map.URI, segment.Map.ByteRange)
+				if err != nil {
+					return iFramePlaylist, stream, err
+				}
+				initSegment, err = fmp4.ParseInitSegment(bytes.NewReader(b))
+				if err != nil {
+					return iFramePlaylist, stream, err
+				}
+				initSection = segment.Map
+			}
+			var track fmp4.Track
+			iFrames, track, err = fmp4IFrames(data, initSegment)
+			if err != nil {
+				return iFramePlaylist, stream, err
+			}
+			if track.Width != 0 {
+				stream.Codecs = []string{track.Codec}
+				stream.Resolution = &Resolution{Width: track.Width, Height: track.Height}
+			}
+			psi = segment.Map
+		}
+
+		for i, frame := range iFrames {
+			frame.byteRange.Offset += base
+			iFramePlaylist.Segments = append(iFramePlaylist.Segments, MediaSegment{
+				URI:           segment.URI,
+				Duration:      roundSeconds(frame.duration),
+				ByteRange:     &frame.byteRange,
+				Discontinuity: i == 0 && segment.Discontinuity,
+				Map:           psi,
+			})
+
+			totalSize += frame.byteRange.Length
+			totalDuration += frame.duration
+			if frame.duration > 0 {
+				peak = max(peak, float64(frame.byteRange.Length*8)/frame.duration)
+			}
+		}
+	}
+
+	iFramePlaylist.TargetDuration = iFramePlaylist.ComputeTargetDuration()
+	stream.Bandwidth = int(math.Ceil(peak))
+	if totalDuration > 0 {
+		stream.AverageBandwidth = int(math.Ceil(float64(totalSize*8) / totalDuration))
+	}
+	return iFramePlaylist, stream, nil
+}
+
+// read returns the data of the resource with the uri or its sub-range if byteRange is not nil.
+func (g *IFramePlaylistGenerator) read(uri string, byteRange *ByteRange) ([]byte, error) {
+	rc, err := g.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	if byteRange == nil {
+		return io.ReadAll(rc)
+	}
+	if _, err := io.CopyN(io.Discard, rc, byteRange.Offset); err != nil {
+		return nil, err
+	}
+	b := make([]byte, byteRange.Length)
+	_, err = io.ReadFull(rc, b)
+	return b, err
+}
+
+// tsIFrames returns the I-frames of a transport stream segment.
+func tsIFrames(info mpegts.SegmentInfo) []iFrame {
+	iFrames := make([]iFrame, 0, len(info.Keyframes))
+	for i, keyframe := range info.Keyframes {
+		end := info.EndPTS
+		if i+1 < len(info.Keyframes) {
+			end = info.Keyframes[i+1].PTS
+		}
+		iFrames = append(iFrames, iFrame{
+			byteRange: ByteRange{Length: keyframe.End - keyframe.Offset, Offset: keyframe.Offset},
+			duration:  float64(end-keyframe.PTS) / mpegts.ClockRate,
+		})
+	}
+	return iFrames
+}
+
+// fmp4IFrames returns the sync samples of the reference track of a fragmented MP4 segment and the reference track.
+// The byte range of a sync sample starts at its fragment so the moof box is included.
+func fmp4IFrames(data []byte, initSegment fmp4.InitSegment) ([]iFrame, fmp4.Track, error) {
+	fragmentReader := fmp4.NewFragmentReader(bytes.NewReader(data), initSegment)
+	iFrames := make([]iFrame, 0)
+	decodeTimes := make([]uint64, 0)
+	var track fmp4.Track
+	var end uint64
+	for {
+		fragment, err := fragmentReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, track, err
+		}
+		initSegment := fragmentReader.InitSegment()
+		trackID, _ := referenceTrack(initSegment)
+		track, _ = initSegment.Track(trackID)
+
+		for _, tf := range fragment.Tracks {
+			if tf.TrackID != trackID {
+				continue
+			}
+			decodeTime := tf.BaseMediaDecodeTime
+			for _, sample := range tf.Samples {
+				if sample.IsSync() {
+					iFrames = append(iFrames, iFrame{
+						byteRange: ByteRange{Length: sample.Offset + int64(sample.Size) - fragment.Offset, Offset: fragment.Offset},
+					})
+					decodeTimes = append(decodeTimes, decodeTime)
+				}
+				decodeTime += uint64(sample.Duration)
+			}
+			end = max(end, decodeTime)
+		}
+	}
+
+	for i := range iFrames {
+		next := end
+		if i+1 < len(iFrames) {
+			next = decodeTimes[i+1]
+		}
+		iFrames[i].duration = float64(next-decodeTimes[i]) / float64(max(track.Timescale, 1))
+	}
+	return iFrames, track, nil
+}
+
+// findPSI returns the offset of the PAT directly followed by a PMT at the beginning of a transport stream segment.
+// SDT packets in front of the PAT are skipped.
+func findPSI(data []byte) (int64, bool) {
+	for offset := 0; offset+2*mpegts.PacketSize <= len(data); offset += mpegts.PacketSize {
+		pat, err := mpegts.ParsePacket(data[offset : offset+mpegts.PacketSize])
+		if err != nil {
+			return 0, false
+		} else if pat.PID == 0x11 {
+			continue
+		} else if _, err := mpegts.ParsePAT(pat.Payload); pat.PID != 0 || err != nil {
+			return 0, false
+		}
+
+		pmt, err := mpegts.ParsePacket(data[offset+mpegts.PacketSize : offset+2*mpegts.PacketSize])
+		if err != nil {
+			return 0, false
+		} else if _, err := mpegts.ParsePMT(pmt.Payload); err != nil {
+			return 0, false
+		}
+		return int64(offset), true
+	}
+	return 0, false
+}
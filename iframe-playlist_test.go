@@ -0,0 +1,100 @@
+package HLS_test
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func openFrom(dir string) func(uri string) (io.ReadCloser, error) {
+	return func(uri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, uri))
+	}
+}
+
+func TestIFramePlaylistGenerator_TS(t *testing.T) {
+	mediaPlaylist := HLS.MediaPlaylist{
+		PlaylistType: HLS.VOD,
+		EndList:      true,
+	}
+	for i, duration := range []float64{11.266667, 13.766667, 7.166667, 8.533333, 11.8, 7.433333} {
+		mediaPlaylist.Segments = append(mediaPlaylist.Segments, HLS.MediaSegment{
+			URI:      fmt.Sprintf("seg%03d.ts", i),
+			Duration: duration,
+		})
+	}
+
+	generator := HLS.NewIFramePlaylistGenerator(openFrom("examples/serving-a-video/video-fragments"))
+	iFramePlaylist, stream, err := generator.Generate(mediaPlaylist, "iframe.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(iFramePlaylist.Segments) != 16 {
+		t.Fatal("Expected 16 I-frames but got", len(iFramePlaylist.Segments))
+	}
+	var total float64
+	for _, segment := range iFramePlaylist.Segments {
+		total += segment.Duration
+		if segment.Map == nil || segment.Map.URI != segment.URI || *segment.Map.ByteRange != (HLS.ByteRange{Length: 376, Offset: 188}) {
+			t.Fatal("Expected the PAT and the PMT of the segment as EXT-X-MAP but got", segment.Map)
+		}
+	}
+	if math.Abs(total-59.966667) > 0.00001 {
+		t.Fatal("Expected a total duration of 59.966667 but got", total)
+	}
+
+	if stream.URI != "iframe.m3u8" || len(stream.Codecs) != 1 || stream.Codecs[0] != "avc1.64001f" {
+		t.Fatal("Unexpected I-frame stream", stream)
+	} else if stream.Resolution == nil || *stream.Resolution != (HLS.Resolution{Width: 576, Height: 1024}) {
+		t.Fatal("Expected resolution 576x1024 but got", stream.Resolution)
+	} else if stream.AverageBandwidth == 0 || stream.Bandwidth < stream.AverageBandwidth {
+		t.Fatal("Expected the peak bandwidth to be at least the average bandwidth but got", stream.Bandwidth, stream.AverageBandwidth)
+	}
+}
+
+func TestIFramePlaylistGenerator_FMP4(t *testing.T) {
+	initSection := &HLS.MediaInitializationSection{URI: "init.mp4"}
+	mediaPlaylist := HLS.MediaPlaylist{
+		PlaylistType: HLS.VOD,
+		Segments: []HLS.MediaSegment{
+			{URI: "seg0.m4s", Duration: 2, Map: initSection},
+			{URI: "seg1.m4s", Duration: 2, Map: initSection},
+		},
+		EndList: true,
+	}
+
+	generator := HLS.NewIFramePlaylistGenerator(openFrom("fmp4/testdata"))
+	iFramePlaylist, stream, err := generator.Generate(mediaPlaylist, "iframe.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(iFramePlaylist.Segments) != 4 {
+		t.Fatal("Expected 4 I-frames but got", len(iFramePlaylist.Segments))
+	}
+	for _, segment := range iFramePlaylist.Segments {
+		if segment.Duration != 1 {
+			t.Fatal("Expected a duration of 1 but got", segment.Duration)
+		} else if segment.Map != initSection {
+			t.Fatal("Expected the EXT-X-MAP of the segment but got", segment.Map)
+		}
+	}
+	if first := iFramePlaylist.Segments[0].ByteRange; first.Offset != 0 {
+		t.Fatal("Expected the first I-frame at the beginning of seg0.m4s but got", first)
+	}
+
+	if stream.Codecs[0] != "avc1.64001f" || *stream.Resolution != (HLS.Resolution{Width: 1280, Height: 720}) {
+		t.Fatal("Unexpected I-frame stream", stream)
+	}
+	if _, err := iFramePlaylist.Encode(); err != nil {
+		t.Fatal(err)
+	} else if iFramePlaylist.MinimumVersion() != 5 {
+		t.Fatal("Expected version 5 but got", iFramePlaylist.MinimumVersion())
+	}
+}
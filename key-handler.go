@@ -0,0 +1,173 @@
+package HLS
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	KeyNotFound  error = errors.New("Key not found")
+	InvalidKeyID error = errors.New("Invalid key id")
+)
+
+// KeyStore stores the keys of encrypted Media Segments by their key id.
+// SaveKey has the signature of the SaveKey field of the encrypters so a KeyStore can be used as their SaveKey.
+type KeyStore interface {
+	SaveKey(keyID string, key []byte) error
+	// Key returns the key with the id or a error that matches KeyNotFound with errors.Is.
+	Key(keyID string) ([]byte, error)
+}
+
+// validKeyID reports whether keyID is non empty and only contains letters, digits, '-' and '_'.
+// Key ids are used in URIs and file names so they must not contain separators.
+func validKeyID(keyID string) bool {
+	if keyID == "" {
+		return false
+	}
+	for _, r := range keyID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// MemoryKeyStore is a KeyStore that keeps the keys in memory. It is safe for concurrent use.
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+// NewMemoryKeyStore returns a new empty MemoryKeyStore.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: make(map[string][]byte),
+	}
+}
+
+func (ks *MemoryKeyStore) SaveKey(keyID string, key []byte) error {
+	if !validKeyID(keyID) {
+		return InvalidKeyID
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[keyID] = bytes.Clone(key)
+	return nil
+}
+
+func (ks *MemoryKeyStore) Key(keyID string) ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[keyID]
+	if !ok {
+		return nil, KeyNotFound
+	}
+	return bytes.Clone(key), nil
+}
+
+// FileKeyStore is a KeyStore that writes every key to a file named "<keyID>.key" in Dir.
+type FileKeyStore struct {
+	Dir string
+}
+
+// NewFileKeyStore returns a new FileKeyStore. The directory is created when the first key is saved.
+func NewFileKeyStore(dir string) FileKeyStore {
+	return FileKeyStore{
+		Dir: dir,
+	}
+}
+
+func (ks FileKeyStore) path(keyID string) string {
+	return filepath.Join(ks.Dir, keyID+".key")
+}
+
+// SaveKey writes the key to its file. Key files are only readable by the owner.
+func (ks FileKeyStore) SaveKey(keyID string, key []byte) error {
+	if !validKeyID(keyID) {
+		return InvalidKeyID
+	}
+	if err := os.MkdirAll(ks.Dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(ks.path(keyID), key, 0600)
+}
+
+func (ks FileKeyStore) Key(keyID string) ([]byte, error) {
+	if !validKeyID(keyID) {
+		return nil, KeyNotFound
+	}
+	key, err := os.ReadFile(ks.path(keyID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, KeyNotFound
+	}
+	return key, err
+}
+
+// KeyHandler is a http.Handler that serves the keys of a KeyStore. The key id is the last element of the request path.
+// Use KeyURI as the KeyURI of a encrypter and the KeyStore as its SaveKey so the URIs of the EXT-X-KEY tags resolve to the KeyHandler.
+//
+//	handler := HLS.NewKeyHandler(HLS.NewMemoryKeyStore(), "https://example.com/keys/")
+//	encrypter := HLS.NewAES128Encrypter(handler.KeyURI, 10)
+//	encrypter.SaveKey = handler.Store.SaveKey
+//	http.Handle("/keys/", handler)
+type KeyHandler struct {
+	Store KeyStore
+	// BaseURI is the URI the keys are served at. The URI of a key is BaseURI followed by the key id.
+	BaseURI string
+	// Authorize is called before a key is released e.g. to check a session token. If it returns a error the response is 403 Forbidden.
+	// Authorize can be nil.
+	Authorize func(r *http.Request, keyID string) error
+}
+
+// NewKeyHandler returns a new KeyHandler.
+func NewKeyHandler(store KeyStore, baseURI string) *KeyHandler {
+	return &KeyHandler{
+		Store:   store,
+		BaseURI: baseURI,
+	}
+}
+
+// KeyURI returns the URI of the key with the id.
+func (kh *KeyHandler) KeyURI(keyID string) string {
+	return kh.BaseURI + keyID
+}
+
+// ServeHTTP responds with the key of the request. Only GET and HEAD requests are allowed.
+// Responses are not cacheable by shared caches because keys must only be released to authorized clients.
+func (kh *KeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	keyID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if !validKeyID(keyID) {
+		http.NotFound(w, r)
+		return
+	}
+	if kh.Authorize != nil {
+		if err := kh.Authorize(r, keyID); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	key, err := kh.Store.Key(keyID)
+	if errors.Is(err, KeyNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(key)
+}
@@ -0,0 +1,144 @@
+package HLS_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestKeyStores(t *testing.T) {
+	stores := map[string]HLS.KeyStore{
+		"memory": HLS.NewMemoryKeyStore(),
+		"file":   HLS.NewFileKeyStore(t.TempDir() + "/keys"),
+	}
+	for name, store := range stores {
+		key := bytes.Repeat([]byte{9}, 16)
+		if err := store.SaveKey("0a1b2c", key); err != nil {
+			t.Fatal(name, err)
+		}
+		b, err := store.Key("0a1b2c")
+		if err != nil {
+			t.Fatal(name, err)
+		} else if !bytes.Equal(b, key) {
+			t.Fatal(name, "Expected", key, "but got", b)
+		}
+
+		if _, err := store.Key("missing"); err != HLS.KeyNotFound {
+			t.Fatal(name, "Expected", HLS.KeyNotFound, "but got", err)
+		} else if err := store.SaveKey("../escape", key); err != HLS.InvalidKeyID {
+			t.Fatal(name, "Expected", HLS.InvalidKeyID, "but got", err)
+		}
+	}
+
+	{
+		dir := t.TempDir()
+		if err := HLS.NewFileKeyStore(dir).SaveKey("key", []byte{1}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(dir + "/key.key")
+		if err != nil {
+			t.Fatal(err)
+		} else if info.Mode().Perm() != 0600 {
+			t.Fatal("Expected", os.FileMode(0600), "but got", info.Mode().Perm())
+		}
+	}
+}
+
+// wrappingKeyStore is a MemoryKeyStore that wraps its errors.
+type wrappingKeyStore struct {
+	*HLS.MemoryKeyStore
+}
+
+func (ws wrappingKeyStore) Key(keyID string) ([]byte, error) {
+	key, err := ws.MemoryKeyStore.Key(keyID)
+	if err != nil {
+		err = fmt.Errorf("key %s: %w", keyID, err)
+	}
+	return key, err
+}
+
+func TestKeyHandler(t *testing.T) {
+	store := HLS.NewMemoryKeyStore()
+	handler := HLS.NewKeyHandler(store, "")
+	handler.Authorize = func(r *http.Request, keyID string) error {
+		if r.URL.Query().Get("token") != "secret" {
+			return errors.New("Invalid token")
+		}
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/keys/", handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	handler.BaseURI = server.URL + "/keys/"
+
+	encrypter := HLS.NewAES128Encrypter(handler.KeyURI, 0)
+	encrypter.SaveKey = handler.Store.SaveKey
+	plaintext := []byte("segment data")
+	r, keys, err := encrypter.EncryptSegment(3, bytes.NewReader(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(keys[0].URI, handler.BaseURI) {
+		t.Fatal("Expected the key URI to resolve to the handler but got", keys[0].URI)
+	}
+
+	get := func(uri string) (int, []byte) {
+		t.Helper()
+		res, err := http.Get(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, b
+	}
+
+	{
+		status, key := get(keys[0].URI + "?token=secret")
+		if status != http.StatusOK {
+			t.Fatal("Expected", http.StatusOK, "but got", status)
+		}
+		decrypted, err := HLS.DecryptSegment(r, keys[0], key, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(decrypted)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(b, plaintext) {
+			t.Fatal("Expected", string(plaintext), "but got", string(b))
+		}
+	}
+
+	if status, _ := get(keys[0].URI); status != http.StatusForbidden {
+		t.Fatal("Expected", http.StatusForbidden, "but got", status)
+	} else if status, _ := get(handler.KeyURI("missing") + "?token=secret"); status != http.StatusNotFound {
+		t.Fatal("Expected", http.StatusNotFound, "but got", status)
+	}
+	//Wrapped errors are matched too.
+	handler.Store = wrappingKeyStore{store}
+	if status, _ := get(handler.KeyURI("missing") + "?token=secret"); status != http.StatusNotFound {
+		t.Fatal("Expected", http.StatusNotFound, "but got", status)
+	}
+	handler.Store = store
+
+	res, err := http.Post(keys[0].URI+"?token=secret", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatal("Expected", http.StatusMethodNotAllowed, "but got", res.StatusCode)
+	}
+}
@@ -0,0 +1,278 @@
+package HLS
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/udan-jayanith/HLS/encryption"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// EncryptionMethod is the METHOD attribute of the EXT-X-KEY tag.
+type EncryptionMethod string
+
+const (
+	// NONE means Media Segments are not encrypted.
+	NONE EncryptionMethod = "NONE"
+	// AES_128 means Media Segments are completely encrypted using AES-128 CBC with PKCS7 padding.
+	AES_128 EncryptionMethod = "AES-128"
+	// SAMPLE_AES means the media samples of Media Segments are encrypted.
+	SAMPLE_AES EncryptionMethod = "SAMPLE-AES"
+	// SAMPLE_AES_CTR means the media samples are encrypted with the AES-CTR mode of Common Encryption (cenc) as used by
+	// Widevine and PlayReady. Segments are decrypted by the DRM system, not by this package.
+	SAMPLE_AES_CTR EncryptionMethod = "SAMPLE-AES-CTR"
+)
+
+var (
+	UnsupportedEncryptionMethod error = errors.New("Unsupported encryption method")
+)
+
+// Key is a EXT-X-KEY tag which specifies how to decrypt Media Segments.
+type Key struct {
+	Method EncryptionMethod
+	// URI is the URI of the key. Only the KEYFORMAT is written if Method is NONE.
+	URI string
+	// IV is the 128-bit initialization vector. If IV is nil the Media Sequence Number of each segment is used as the IV.
+	IV []byte
+	// KeyFormat is the KEYFORMAT of the key. It is omitted if it is empty which means "identity".
+	KeyFormat KeyFormat
+	// KeyFormatVersions is the KEYFORMATVERSIONS of the key. e.g. "1" or "1/2/5"
+	KeyFormatVersions string
+}
+
+// ToHLSTag returns the EXT-X-KEY tag of the key.
+func (key *Key) ToHLSTag() HLSTag {
+	attributes := []string{"METHOD=" + string(key.Method)}
+	if key.Method == NONE && key.KeyFormat != "" {
+		attributes = append(attributes, "KEYFORMAT="+WrapQuotes(string(key.KeyFormat)))
+	} else if key.Method != NONE {
+		attributes = append(attributes, "URI="+WrapQuotes(key.URI))
+		if key.IV != nil {
+			attributes = append(attributes, "IV=0x"+strings.ToUpper(hex.EncodeToString(key.IV)))
+		}
+		if key.KeyFormat != "" {
+			attributes = append(attributes, "KEYFORMAT="+WrapQuotes(string(key.KeyFormat)))
+		}
+		if key.KeyFormatVersions != "" {
+			attributes = append(attributes, "KEYFORMATVERSIONS="+WrapQuotes(key.KeyFormatVersions))
+		}
+	}
+	return HLSTag{
+		TagName: EXT_X_KEY,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// format returns the KEYFORMAT of key. An empty KEYFORMAT is identity.
+func (key *Key) format() KeyFormat {
+	if key.KeyFormat == "" {
+		return IdentityKeyFormat
+	}
+	return key.KeyFormat
+}
+
+// equal reports whether key and other are the same EXT-X-KEY tag.
+func (key *Key) equal(other Key) bool {
+	return key.Method == other.Method && key.URI == other.URI && slices.Equal(key.IV, other.IV) &&
+		key.KeyFormat == other.KeyFormat && key.KeyFormatVersions == other.KeyFormatVersions
+}
+
+// minimumVersion returns the lowest EXT-X-VERSION that supports the attributes of key.
+func (key *Key) minimumVersion() int {
+	if key.Method == SAMPLE_AES || key.Method == SAMPLE_AES_CTR || key.KeyFormat != "" || key.KeyFormatVersions != "" {
+		return 5
+	} else if key.IV != nil {
+		return 2
+	}
+	return 1
+}
+
+// equalKeys reports whether a and b contain the same keys in the same order.
+func equalKeys(a, b []Key) bool {
+	return slices.EqualFunc(a, b, func(a, b Key) bool {
+		return a.equal(b)
+	})
+}
+
+// SegmentEncrypter encrypts Media Segments.
+type SegmentEncrypter interface {
+	// EncryptSegment returns a reader of the encrypted segment read from r and the EXT-X-KEY tags of the segment.
+	EncryptSegment(sequenceNumber uint64, r io.Reader) (io.Reader, []Key, error)
+}
+
+// AES128Encrypter encrypts whole Media Segments with METHOD=AES-128 and rotates the key every RotationPeriod segments.
+type AES128Encrypter struct {
+	// RotationPeriod is the number of consecutive Media Sequence Numbers encrypted with the same key. If RotationPeriod is 0 the key never changes.
+	RotationPeriod uint64
+	// KeyURI returns the URI of the key with the id. The URI is the URI attribute of the EXT-X-KEY tag.
+	KeyURI func(keyID string) string
+	// SaveKey is called with every new key before it is used so clients can fetch it from KeyURI(keyID). SaveKey can be nil.
+	SaveKey func(keyID string, key []byte) error
+	// ExplicitIV writes a random IV attribute for every key instead of using the Media Sequence Number as the IV.
+	ExplicitIV bool
+	// Rand is the source of keys, key ids and IVs. If Rand is nil crypto/rand is used.
+	Rand io.Reader
+
+	state rotationState
+}
+
+// NewAES128Encrypter returns a new AES128Encrypter.
+func NewAES128Encrypter(keyURI func(keyID string) string, rotationPeriod uint64) *AES128Encrypter {
+	return &AES128Encrypter{
+		RotationPeriod: rotationPeriod,
+		KeyURI:         keyURI,
+	}
+}
+
+// EncryptSegment encrypts a segment read from r. A new key is created when the segment is the first segment of a rotation period.
+func (e *AES128Encrypter) EncryptSegment(sequenceNumber uint64, r io.Reader) (io.Reader, []Key, error) {
+	if err := e.state.advance(sequenceNumber, e.RotationPeriod, e.Rand, e.SaveKey, e.ExplicitIV); err != nil {
+		return nil, nil, err
+	}
+	encrypted, err := encryption.NewEncrypter(r, e.state.key, e.state.segmentIV(sequenceNumber))
+	if err != nil {
+		return nil, nil, err
+	}
+	return encrypted, []Key{{Method: AES_128, URI: e.KeyURI(e.state.keyID), IV: e.state.iv}}, nil
+}
+
+// SampleAESEncrypter encrypts the media samples of Media Segments with METHOD=SAMPLE-AES and rotates the key every RotationPeriod segments.
+// MPEG-TS segments with H.264, AAC and AC-3 streams and packed audio segments of ADTS or AC-3 frames are supported.
+// The EXT-X-KEY tags have KEYFORMAT="identity".
+type SampleAESEncrypter struct {
+	// RotationPeriod is the number of consecutive Media Sequence Numbers encrypted with the same key. If RotationPeriod is 0 the key never changes.
+	RotationPeriod uint64
+	// KeyURI returns the URI of the key with the id. The URI is the URI attribute of the EXT-X-KEY tag.
+	KeyURI func(keyID string) string
+	// SaveKey is called with every new key before it is used so clients can fetch it from KeyURI(keyID). SaveKey can be nil.
+	SaveKey func(keyID string, key []byte) error
+	// ExplicitIV writes a random IV attribute for every key instead of using the Media Sequence Number as the IV.
+	ExplicitIV bool
+	// Rand is the source of keys, key ids and IVs. If Rand is nil crypto/rand is used.
+	Rand io.Reader
+
+	state rotationState
+}
+
+// NewSampleAESEncrypter returns a new SampleAESEncrypter.
+func NewSampleAESEncrypter(keyURI func(keyID string) string, rotationPeriod uint64) *SampleAESEncrypter {
+	return &SampleAESEncrypter{
+		RotationPeriod: rotationPeriod,
+		KeyURI:         keyURI,
+	}
+}
+
+// EncryptSegment reads the whole segment from r and encrypts its samples.
+// A new key is created when the segment is the first segment of a rotation period.
+func (e *SampleAESEncrypter) EncryptSegment(sequenceNumber uint64, r io.Reader) (io.Reader, []Key, error) {
+	if err := e.state.advance(sequenceNumber, e.RotationPeriod, e.Rand, e.SaveKey, e.ExplicitIV); err != nil {
+		return nil, nil, err
+	}
+	segment, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	encrypted, err := cryptSampleAES(segment, e.state.key, e.state.segmentIV(sequenceNumber), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(encrypted), []Key{{Method: SAMPLE_AES, URI: e.KeyURI(e.state.keyID), IV: e.state.iv, KeyFormat: "identity"}}, nil
+}
+
+// cryptSampleAES encrypts or decrypts a MPEG-TS or a packed audio segment with METHOD=SAMPLE-AES.
+func cryptSampleAES(segment, key, iv []byte, encrypt bool) ([]byte, error) {
+	ts := len(segment) > 0 && segment[0] == mpegts.SyncByte && len(segment)%mpegts.PacketSize == 0
+	switch {
+	case ts && encrypt:
+		return encryption.EncryptSampleAES(segment, key, iv)
+	case ts:
+		return encryption.DecryptSampleAES(segment, key, iv)
+	case encrypt:
+		return encryption.EncryptPackedAudio(segment, key, iv)
+	}
+	return encryption.DecryptPackedAudio(segment, key, iv)
+}
+
+// rotationState is the current key of a encrypter that rotates its key every rotation period.
+type rotationState struct {
+	period uint64
+	keyID  string
+	key    []byte
+	iv     []byte
+}
+
+// advance creates a new key if sequenceNumber is in a different rotation period than the current key.
+func (rs *rotationState) advance(sequenceNumber, rotationPeriod uint64, random io.Reader, saveKey func(keyID string, key []byte) error, explicitIV bool) error {
+	period := uint64(0)
+	if rotationPeriod > 0 {
+		period = sequenceNumber / rotationPeriod
+	}
+	if rs.key != nil && period == rs.period {
+		return nil
+	}
+
+	if random == nil {
+		random = rand.Reader
+	}
+	b := make([]byte, 8+2*encryption.KeySize)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return err
+	}
+
+	keyID := hex.EncodeToString(b[:8])
+	key := b[8 : 8+encryption.KeySize]
+	if saveKey != nil {
+		if err := saveKey(keyID, key); err != nil {
+			return err
+		}
+	}
+	rs.period, rs.keyID, rs.key, rs.iv = period, keyID, key, nil
+	if explicitIV {
+		rs.iv = b[8+encryption.KeySize:]
+	}
+	return nil
+}
+
+// segmentIV returns the IV of the segment with sequenceNumber.
+func (rs *rotationState) segmentIV(sequenceNumber uint64) []byte {
+	if rs.iv == nil {
+		return encryption.SequenceIV(sequenceNumber)
+	}
+	return rs.iv
+}
+
+// DecryptSegment returns a reader of the decrypted segment read from r. key is the EXT-X-KEY tag of the segment and keyData is the key fetched from its URI.
+// SAMPLE-AES segments are read completely before they are decrypted.
+// DecryptSegment returns UnsupportedEncryptionMethod if the method of key is not NONE, AES-128 or SAMPLE-AES.
+func DecryptSegment(r io.Reader, key Key, keyData []byte, sequenceNumber uint64) (io.Reader, error) {
+	switch key.Method {
+	case NONE:
+		return r, nil
+	case AES_128:
+		iv := key.IV
+		if iv == nil {
+			iv = encryption.SequenceIV(sequenceNumber)
+		}
+		return encryption.NewDecrypter(r, keyData, iv)
+	case SAMPLE_AES:
+		iv := key.IV
+		if iv == nil {
+			iv = encryption.SequenceIV(sequenceNumber)
+		}
+		segment, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		decrypted, err := cryptSampleAES(segment, keyData, iv, false)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(decrypted), nil
+	}
+	return nil, UnsupportedEncryptionMethod
+}
@@ -0,0 +1,201 @@
+package HLS_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestKeyToHLSTag(t *testing.T) {
+	testcases := []struct {
+		key      HLS.Key
+		expected string
+	}{
+		{HLS.Key{Method: HLS.NONE, URI: "ignored"}, "METHOD=NONE"},
+		{HLS.Key{Method: HLS.AES_128, URI: "key.bin"}, `METHOD=AES-128,URI="key.bin"`},
+		{
+			HLS.Key{Method: HLS.AES_128, URI: "key.bin", IV: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x1A, 0xFF}},
+			`METHOD=AES-128,URI="key.bin",IV=0x00000000000000000000000000001AFF`,
+		},
+		{
+			HLS.Key{Method: HLS.SAMPLE_AES, URI: "skd://key", KeyFormat: "com.apple.streamingkeydelivery", KeyFormatVersions: "1"},
+			`METHOD=SAMPLE-AES,URI="skd://key",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		tag := testcase.key.ToHLSTag()
+		if tag.TagName != HLS.EXT_X_KEY || tag.Value != testcase.expected {
+			t.Fatal("Expected", testcase.expected, "but got", tag.Value)
+		}
+	}
+}
+
+func TestMediaPlaylistEncode_Keys(t *testing.T) {
+	key := HLS.Key{Method: HLS.AES_128, URI: "key1"}
+	mediaPlaylist := HLS.MediaPlaylist{
+		Segments: []HLS.MediaSegment{
+			{URI: "0.ts", Duration: 4, Keys: []HLS.Key{key}},
+			{URI: "1.ts", Duration: 4, Keys: []HLS.Key{key}},
+			{URI: "2.ts", Duration: 4, Keys: []HLS.Key{{Method: HLS.AES_128, URI: "key2"}}},
+			{URI: "3.ts", Duration: 4},
+		},
+	}
+
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#EXTM3U
+#EXT-X-VERSION:1
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-KEY:METHOD=AES-128,URI="key1"
+#EXTINF:4,
+0.ts
+#EXTINF:4,
+1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key2"
+#EXTINF:4,
+2.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4,
+3.ts
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+}
+
+func TestAES128Encrypter(t *testing.T) {
+	keys := make(map[string][]byte)
+	encrypter := HLS.NewAES128Encrypter(func(keyID string) string {
+		return "https://keys.example.com/" + keyID
+	}, 2)
+	encrypter.SaveKey = func(keyID string, key []byte) error {
+		keys[keyID] = key
+		return nil
+	}
+
+	segments := make(map[string][]byte)
+	segmenter := HLS.NewSegmenter(HLS.SegmentWriterFunc(func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		segments[name] = b
+		return err
+	}), 4*time.Second)
+	segmenter.Encrypter = encrypter
+
+	stream := exampleStream(t)
+	mediaPlaylist, err := segmenter.Segment(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != (len(mediaPlaylist.Segments)+1)/2 {
+		t.Fatal("Expected a new key every 2 segments but got", len(keys), "keys for", len(mediaPlaylist.Segments), "segments")
+	}
+
+	var decrypted []byte
+	for i, segment := range mediaPlaylist.Segments {
+		if len(segment.Keys) != 1 || segment.Keys[0].Method != HLS.AES_128 || segment.Keys[0].IV != nil {
+			t.Fatal("Unexpected keys", segment.Keys)
+		} else if i%2 == 1 && segment.Keys[0].URI != mediaPlaylist.Segments[i-1].Keys[0].URI {
+			t.Fatal("Expected segment", i, "to use the key of the previous segment")
+		} else if i%2 == 0 && i > 0 && segment.Keys[0].URI == mediaPlaylist.Segments[i-1].Keys[0].URI {
+			t.Fatal("Expected segment", i, "to use a new key")
+		}
+
+		keyID := strings.TrimPrefix(segment.Keys[0].URI, "https://keys.example.com/")
+		r, err := HLS.DecryptSegment(bytes.NewReader(segments[segment.URI]), segment.Keys[0], keys[keyID], uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		} else if b[0] != 0x47 {
+			t.Fatal("Expected segment", i, "to decrypt to a transport stream")
+		}
+		decrypted = append(decrypted, b...)
+	}
+
+	//The segmenter adds a PAT and a PMT to segments that do not start with them, so the decrypted stream is at least as long as the input.
+	if len(decrypted) < len(stream) {
+		t.Fatal("Expected at least", len(stream), "decrypted bytes but got", len(decrypted))
+	}
+
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	} else if count := strings.Count(string(b), "#EXT-X-KEY:"); count != len(keys) {
+		t.Fatal("Expected", len(keys), "EXT-X-KEY tags but got", count)
+	}
+
+	if _, err := HLS.DecryptSegment(nil, HLS.Key{Method: "SAMPLE-AES-CTR"}, nil, 0); err != HLS.UnsupportedEncryptionMethod {
+		t.Fatal("Expected", HLS.UnsupportedEncryptionMethod, "but got", err)
+	}
+}
+
+func TestSampleAESEncrypter(t *testing.T) {
+	keys := make(map[string][]byte)
+	encrypter := HLS.NewSampleAESEncrypter(func(keyID string) string {
+		return "skd://" + keyID
+	}, 0)
+	encrypter.SaveKey = func(keyID string, key []byte) error {
+		keys[keyID] = key
+		return nil
+	}
+	encrypter.ExplicitIV = true
+
+	segment, err := os.ReadFile("examples/serving-a-video/video-fragments/seg001.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sequenceNumber := range uint64(2) {
+		r, segmentKeys, err := encrypter.EncryptSegment(sequenceNumber, bytes.NewReader(segment))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(segmentKeys) != 1 || segmentKeys[0].Method != HLS.SAMPLE_AES || segmentKeys[0].KeyFormat != "identity" || len(segmentKeys[0].IV) != 16 {
+			t.Fatal("Unexpected keys", segmentKeys)
+		} else if len(keys) != 1 {
+			t.Fatal("Expected a single key but got", len(keys))
+		}
+		tag := segmentKeys[0].ToHLSTag()
+		if !strings.Contains(tag.Value, `KEYFORMAT="identity"`) {
+			t.Fatal("Expected KEYFORMAT in", tag.Value)
+		}
+
+		encrypted, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		} else if bytes.Equal(encrypted, segment) {
+			t.Fatal("Expected the segment to be encrypted")
+		}
+
+		keyID := strings.TrimPrefix(segmentKeys[0].URI, "skd://")
+		r, err = HLS.DecryptSegment(bytes.NewReader(encrypted), segmentKeys[0], keys[keyID], sequenceNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(decrypted, segment) {
+			t.Fatal("Expected the decrypted segment to be the original segment")
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package HLS
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	PlaylistEnded error = errors.New("Playlist has a EXT-X-ENDLIST tag")
+)
+
+// LiveMediaPlaylist is a Media Playlist of a live stream with a sliding window of segments.
+// Segments are added as they are produced and the oldest segments are removed when the window is full.
+// It is safe for concurrent use by the goroutine that adds segments and the goroutines that render the playlist.
+type LiveMediaPlaylist struct {
+	// TargetDuration is the EXT-X-TARGETDURATION in seconds. It must not change during the stream.
+	TargetDuration int
+	// WindowSize is the maximum number of segments in the playlist. If WindowSize is 0 the number of segments is not limited.
+	WindowSize int
+	// WindowDuration is the maximum duration of the playlist. If WindowDuration is 0 the duration is not limited.
+	WindowDuration time.Duration
+	// Version is the EXT-X-VERSION. If Version is 0 the minimum version required by the playlist is used.
+	Version             int
+	IndependentSegments bool
+	// ServerControl is the EXT-X-SERVER-CONTROL tag of a Low-Latency HLS stream. It can be nil.
+	// EXT-X-DATERANGE tags are not supported yet, so CanSkipDateRanges is never advertised and Playlist Delta Updates
+	// never skip date ranges.
+	ServerControl *ServerControl
+	// PartInf is the EXT-X-PART-INF tag. It is required to add Partial Segments with AddPart.
+	PartInf *PartInf
+	// OnExpire is called with every removed segment once clients cannot request it anymore, so it can be deleted from
+	// the SegmentStore. Expiry is checked by AddSegment, End and every read of the playlist, so segments expire after
+	// the stream ended too. OnExpire is called without holding the lock, possibly from several goroutines, but once
+	// per segment. OnExpire can be nil.
+	OnExpire func(segment MediaSegment)
+	// Now returns the current time. If Now is nil time.Now is used.
+	Now func() time.Time
+
+	mu                    sync.RWMutex
+	segments              []MediaSegment
+	mediaSequence         uint64
+	discontinuitySequence uint64
+	ended                 bool
+	modTime               time.Time
+	// longestDuration is the duration of the longest playlist rendered so far in seconds.
+	longestDuration float64
+	removed         []removedSegment
+	// parts are the Partial Segments of the next segment.
+	parts        []Part
+	preloadHints []PreloadHint
+	// changed is closed and replaced when the playlist changes, so waiting goroutines are woken without polling.
+	changed chan struct{}
+	// ladder is the RenditionLadder of the playlist and ladderURI the URI of the playlist in the ladder.
+	ladder    *RenditionLadder
+	ladderURI string
+}
+
+// removedSegment is a segment that was removed from the playlist and expires at expiresAt.
+type removedSegment struct {
+	segment   MediaSegment
+	expiresAt time.Time
+}
+
+// NewLiveMediaPlaylist returns a new empty LiveMediaPlaylist that keeps windowSize segments.
+func NewLiveMediaPlaylist(targetDuration int, windowSize int) *LiveMediaPlaylist {
+	return &LiveMediaPlaylist{
+		TargetDuration: targetDuration,
+		WindowSize:     windowSize,
+	}
+}
+
+func (lp *LiveMediaPlaylist) now() time.Time {
+	if lp.Now != nil {
+		return lp.Now()
+	}
+	return time.Now()
+}
+
+// AddSegment appends a segment and removes the segments that fell out of the window.
+// AddSegment returns InvalidTargetDuration if the segment is longer than the target duration and PlaylistEnded after End.
+func (lp *LiveMediaPlaylist) AddSegment(segment MediaSegment) error {
+	return lp.addSegment(segment, false, nil)
+}
+
+// AddSegmentWithHints is AddSegment that replaces the preload hints in the same update, so clients woken by the
+// segment never see the hints of the previous segment.
+func (lp *LiveMediaPlaylist) AddSegmentWithHints(segment MediaSegment, hints ...PreloadHint) error {
+	return lp.addSegment(segment, true, hints)
+}
+
+func (lp *LiveMediaPlaylist) addSegment(segment MediaSegment, replaceHints bool, hints []PreloadHint) error {
+	now := lp.now()
+	lp.mu.Lock()
+	if lp.ended {
+		lp.mu.Unlock()
+		return PlaylistEnded
+	} else if int(math.Round(segment.Duration)) > lp.TargetDuration {
+		lp.mu.Unlock()
+		return InvalidTargetDuration
+	}
+	if segment.Parts == nil {
+		segment.Parts = lp.parts
+	}
+	lp.parts = nil
+	lp.segments = append(lp.segments, segment)
+	if replaceHints {
+		lp.preloadHints = append([]PreloadHint(nil), hints...)
+	}
+	lp.slide(now)
+	lp.trimParts()
+	lp.longestDuration = max(lp.longestDuration, lp.duration())
+	lp.modTime = now
+	expired := lp.expired(now)
+	lp.notify()
+	lp.mu.Unlock()
+
+	lp.onExpire(expired)
+	return nil
+}
+
+// AddPart appends a Partial Segment of the next segment. The parts are moved to the segment by AddSegment.
+// AddPart returns MissingPartInf if PartInf is nil, InvalidPartDuration if the part is longer than the part target
+// and PlaylistEnded after End.
+func (lp *LiveMediaPlaylist) AddPart(part Part) error {
+	return lp.addPart(part, false, nil)
+}
+
+// AddPartWithHints is AddPart that replaces the preload hints in the same update, so clients woken by the part see
+// the hint of the part that follows it.
+func (lp *LiveMediaPlaylist) AddPartWithHints(part Part, hints ...PreloadHint) error {
+	return lp.addPart(part, true, hints)
+}
+
+func (lp *LiveMediaPlaylist) addPart(part Part, replaceHints bool, hints []PreloadHint) error {
+	now := lp.now()
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	if lp.ended {
+		return PlaylistEnded
+	} else if lp.PartInf == nil {
+		return MissingPartInf
+	} else if part.Duration > lp.PartInf.PartTarget {
+		return InvalidPartDuration
+	}
+	lp.parts = append(lp.parts, part)
+	if replaceHints {
+		lp.preloadHints = append([]PreloadHint(nil), hints...)
+	} else {
+		lp.removePreloadHint(part.URI)
+	}
+	lp.trimParts()
+	lp.modTime = now
+	lp.notify()
+	return nil
+}
+
+// SetPreloadHints replaces the EXT-X-PRELOAD-HINT tags of the playlist. A hint is removed when a Partial Segment with
+// its URI is added.
+func (lp *LiveMediaPlaylist) SetPreloadHints(hints ...PreloadHint) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.preloadHints = append([]PreloadHint(nil), hints...)
+}
+
+// removePreloadHint removes the preload hints of uri. lp.mu must be held.
+func (lp *LiveMediaPlaylist) removePreloadHint(uri string) {
+	lp.preloadHints = slices.DeleteFunc(lp.preloadHints, func(hint PreloadHint) bool {
+		return hint.URI == uri
+	})
+}
+
+// trimParts removes the Partial Segments of the segments that end more than three target durations before the end
+// of the playlist.
+func (lp *LiveMediaPlaylist) trimParts() {
+	distance := 0.0
+	for _, part := range lp.parts {
+		distance += part.Duration
+	}
+	for i := len(lp.segments) - 1; i >= 0; i-- {
+		if distance > float64(3*lp.TargetDuration) {
+			lp.segments[i].Parts = nil
+		}
+		distance += lp.segments[i].Duration
+	}
+}
+
+// notify wakes the goroutines waiting in Wait. lp.mu must be held.
+func (lp *LiveMediaPlaylist) notify() {
+	if lp.changed != nil {
+		close(lp.changed)
+		lp.changed = nil
+	}
+}
+
+// available reports whether the segment with the media sequence number msn, or its Partial Segment part if part is
+// not negative, is in the playlist. lp.mu must be held.
+func (lp *LiveMediaPlaylist) available(msn uint64, part int) bool {
+	next := lp.mediaSequence + uint64(len(lp.segments))
+	if msn < next {
+		return true
+	}
+	return msn == next && part >= 0 && part < len(lp.parts)
+}
+
+// lastPosition returns the media sequence number of the last segment and the index of its last Partial Segment, or
+// -1 if it has none. If the next segment has Partial Segments they are the last. ok is false if the playlist is empty.
+// lp.mu must be held.
+func (lp *LiveMediaPlaylist) lastPosition() (msn uint64, part int, ok bool) {
+	next := lp.mediaSequence + uint64(len(lp.segments))
+	if len(lp.parts) > 0 {
+		return next, len(lp.parts) - 1, true
+	} else if len(lp.segments) == 0 {
+		return 0, -1, false
+	}
+	return next - 1, len(lp.segments[len(lp.segments)-1].Parts) - 1, true
+}
+
+// detach removes the playlist from its RenditionLadder.
+func (lp *LiveMediaPlaylist) detach() {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.ladder, lp.ladderURI = nil, ""
+}
+
+// addRenditionReports adds the rendition reports of the RenditionLadder to mediaPlaylist and returns the later of
+// modTime and the time a sibling rendition last changed. It must be called without holding lp.mu.
+func (lp *LiveMediaPlaylist) addRenditionReports(mediaPlaylist *MediaPlaylist, modTime time.Time) time.Time {
+	lp.mu.RLock()
+	ladder, uri := lp.ladder, lp.ladderURI
+	lp.mu.RUnlock()
+	if ladder == nil {
+		return modTime
+	}
+	reports, siblingModTime := ladder.reports(uri)
+	mediaPlaylist.RenditionReports = reports
+	if siblingModTime.After(modTime) {
+		return siblingModTime
+	}
+	return modTime
+}
+
+// NextPosition returns the media sequence number of the next segment and the number of its Partial Segments that
+// were added so far.
+func (lp *LiveMediaPlaylist) NextPosition() (uint64, int) {
+	lp.mu.RLock()
+	defer lp.mu.RUnlock()
+	return lp.mediaSequence + uint64(len(lp.segments)), len(lp.parts)
+}
+
+// Wait blocks until the segment with the media sequence number msn, or its Partial Segment part if part is not
+// negative, is in the playlist or the playlist ended. Wait returns ctx.Err() if ctx is done first.
+func (lp *LiveMediaPlaylist) Wait(ctx context.Context, msn uint64, part int) error {
+	for {
+		lp.mu.Lock()
+		if lp.ended || lp.available(msn, part) {
+			lp.mu.Unlock()
+			return nil
+		}
+		if lp.changed == nil {
+			lp.changed = make(chan struct{})
+		}
+		changed := lp.changed
+		lp.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// slide removes the oldest segments while the window is exceeded.
+// A segment is never removed if the remaining playlist would be shorter than three target durations.
+func (lp *LiveMediaPlaylist) slide(now time.Time) {
+	for len(lp.segments) > 1 {
+		duration := lp.duration()
+		full := lp.WindowSize > 0 && len(lp.segments) > lp.WindowSize ||
+			lp.WindowDuration > 0 && duration > lp.WindowDuration.Seconds()
+		if !full || duration-lp.segments[0].Duration < float64(3*lp.TargetDuration) {
+			return
+		}
+
+		segment := lp.segments[0]
+		lp.segments = lp.segments[1:]
+		lp.mediaSequence++
+		if segment.Discontinuity {
+			lp.discontinuitySequence++
+		}
+		//A removed segment must stay available for its duration plus the duration of the longest playlist that contained it.
+		availability := segment.Duration + max(lp.longestDuration, duration)
+		lp.removed = append(lp.removed, removedSegment{
+			segment:   segment,
+			expiresAt: now.Add(time.Duration(availability * float64(time.Second))),
+		})
+	}
+}
+
+// expire calls OnExpire with the removed segments that expired before now. lp.mu must not be held.
+func (lp *LiveMediaPlaylist) expire(now time.Time) {
+	lp.mu.RLock()
+	due := len(lp.removed) > 0 && !lp.removed[0].expiresAt.After(now)
+	lp.mu.RUnlock()
+	if !due {
+		return
+	}
+	lp.mu.Lock()
+	expired := lp.expired(now)
+	lp.mu.Unlock()
+	lp.onExpire(expired)
+}
+
+// onExpire calls OnExpire with the expired segments. lp.mu must not be held.
+func (lp *LiveMediaPlaylist) onExpire(expired []MediaSegment) {
+	if lp.OnExpire == nil {
+		return
+	}
+	for _, segment := range expired {
+		lp.OnExpire(segment)
+	}
+}
+
+// expired removes and returns the removed segments that expired before now.
+func (lp *LiveMediaPlaylist) expired(now time.Time) []MediaSegment {
+	segments := make([]MediaSegment, 0)
+	for len(lp.removed) > 0 && !lp.removed[0].expiresAt.After(now) {
+		segments = append(segments, lp.removed[0].segment)
+		lp.removed = lp.removed[1:]
+	}
+	return segments
+}
+
+// duration returns the sum of the segment durations in seconds.
+func (lp *LiveMediaPlaylist) duration() float64 {
+	duration := 0.0
+	for _, segment := range lp.segments {
+		duration += segment.Duration
+	}
+	return duration
+}
+
+// End adds the EXT-X-ENDLIST tag. No segments can be added after End.
+func (lp *LiveMediaPlaylist) End() {
+	now := lp.now()
+	lp.mu.Lock()
+	if !lp.ended {
+		lp.ended = true
+		lp.preloadHints = nil
+		lp.modTime = now
+		lp.notify()
+	}
+	expired := lp.expired(now)
+	lp.mu.Unlock()
+	lp.onExpire(expired)
+}
+
+// MediaPlaylist returns a snapshot of the current playlist. If the playlist is part of a RenditionLadder the snapshot
+// has the rendition reports of the sibling renditions.
+func (lp *LiveMediaPlaylist) MediaPlaylist() MediaPlaylist {
+	lp.expire(lp.now())
+	lp.mu.RLock()
+	mediaPlaylist := lp.mediaPlaylist()
+	lp.mu.RUnlock()
+	lp.addRenditionReports(&mediaPlaylist, time.Time{})
+	return mediaPlaylist
+}
+
+func (lp *LiveMediaPlaylist) mediaPlaylist() MediaPlaylist {
+	serverControl := lp.ServerControl
+	if serverControl != nil && serverControl.CanSkipDateRanges {
+		withoutDateRanges := *serverControl
+		withoutDateRanges.CanSkipDateRanges = false
+		serverControl = &withoutDateRanges
+	}
+	return MediaPlaylist{
+		Version:               lp.Version,
+		TargetDuration:        lp.TargetDuration,
+		MediaSequence:         lp.mediaSequence,
+		DiscontinuitySequence: lp.discontinuitySequence,
+		IndependentSegments:   lp.IndependentSegments,
+		ServerControl:         serverControl,
+		PartInf:               lp.PartInf,
+		Segments:              append([]MediaSegment(nil), lp.segments...),
+		Parts:                 append([]Part(nil), lp.parts...),
+		PreloadHints:          append([]PreloadHint(nil), lp.preloadHints...),
+		EndList:               lp.ended,
+	}
+}
+
+// DeltaMediaPlaylist returns a snapshot of the current playlist as a Playlist Delta Update. The segments that begin
+// more than ServerControl.CanSkipUntil seconds before the end of the playlist are replaced by a EXT-X-SKIP tag.
+// The full playlist is returned if the playlist cannot be skipped. Date ranges are never skipped, so the delta answers
+// _HLS_skip=v2 requests too.
+func (lp *LiveMediaPlaylist) DeltaMediaPlaylist() MediaPlaylist {
+	lp.expire(lp.now())
+	lp.mu.RLock()
+	mediaPlaylist := lp.deltaMediaPlaylist()
+	lp.mu.RUnlock()
+	lp.addRenditionReports(&mediaPlaylist, time.Time{})
+	return mediaPlaylist
+}
+
+func (lp *LiveMediaPlaylist) deltaMediaPlaylist() MediaPlaylist {
+	mediaPlaylist := lp.mediaPlaylist()
+	if lp.ServerControl == nil || lp.ServerControl.CanSkipUntil == 0 {
+		return mediaPlaylist
+	}
+
+	distance := lp.duration()
+	for _, part := range lp.parts {
+		distance += part.Duration
+	}
+	skipped := 0
+	for skipped < len(lp.segments) && distance > lp.ServerControl.CanSkipUntil {
+		distance -= lp.segments[skipped].Duration
+		skipped++
+	}
+	if skipped == 0 {
+		return mediaPlaylist
+	}
+	mediaPlaylist.Skip = &Skip{SkippedSegments: uint64(skipped)}
+	mediaPlaylist.Segments = mediaPlaylist.Segments[skipped:]
+	if mediaPlaylist.Version != 0 {
+		mediaPlaylist.Version = max(mediaPlaylist.Version, 9)
+	}
+	return mediaPlaylist
+}
+
+// DeltaPlaylist returns the encoded DeltaMediaPlaylist and the time the playlist last changed.
+func (lp *LiveMediaPlaylist) DeltaPlaylist() ([]byte, time.Time, error) {
+	lp.expire(lp.now())
+	lp.mu.RLock()
+	mediaPlaylist, modTime := lp.deltaMediaPlaylist(), lp.modTime
+	lp.mu.RUnlock()
+	modTime = lp.addRenditionReports(&mediaPlaylist, modTime)
+	return encodeMediaPlaylist(mediaPlaylist, modTime)
+}
+
+// Render returns the encoded current playlist.
+func (lp *LiveMediaPlaylist) Render() ([]byte, error) {
+	b, _, err := lp.Playlist()
+	return b, err
+}
+
+// Playlist returns the encoded current playlist and the time it last changed. LiveMediaPlaylist is a PlaylistSource.
+func (lp *LiveMediaPlaylist) Playlist() ([]byte, time.Time, error) {
+	lp.expire(lp.now())
+	lp.mu.RLock()
+	mediaPlaylist, modTime := lp.mediaPlaylist(), lp.modTime
+	lp.mu.RUnlock()
+	modTime = lp.addRenditionReports(&mediaPlaylist, modTime)
+	return encodeMediaPlaylist(mediaPlaylist, modTime)
+}
+
+// encodeMediaPlaylist returns the encoded mediaPlaylist and modTime.
+func encodeMediaPlaylist(mediaPlaylist MediaPlaylist, modTime time.Time) ([]byte, time.Time, error) {
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		return nil, modTime, err
+	}
+	b, err := io.ReadAll(&playlist)
+	return b, modTime, err
+}
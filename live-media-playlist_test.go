@@ -0,0 +1,241 @@
+package HLS_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestLiveMediaPlaylist(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := make([]string, 0)
+	livePlaylist := HLS.NewLiveMediaPlaylist(4, 3)
+	livePlaylist.Now = func() time.Time { return now }
+	livePlaylist.OnExpire = func(segment HLS.MediaSegment) {
+		expired = append(expired, segment.URI)
+	}
+
+	for i := range 6 {
+		segment := HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 4, Discontinuity: i == 1}
+		if err := livePlaylist.AddSegment(segment); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(4 * time.Second)
+	}
+
+	mediaPlaylist := livePlaylist.MediaPlaylist()
+	if len(mediaPlaylist.Segments) != 3 || mediaPlaylist.Segments[0].URI != "seg3.ts" {
+		t.Fatal("Expected segments 3 to 5 but got", mediaPlaylist.Segments)
+	} else if mediaPlaylist.MediaSequence != 3 || mediaPlaylist.DiscontinuitySequence != 1 {
+		t.Fatal("Expected media sequence 3 and discontinuity sequence 1 but got", mediaPlaylist.MediaSequence, mediaPlaylist.DiscontinuitySequence)
+	}
+
+	b, err := livePlaylist.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:1
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:3
+#EXT-X-DISCONTINUITY-SEQUENCE:1
+#EXTINF:4,
+seg3.ts
+#EXTINF:4,
+seg4.ts
+#EXTINF:4,
+seg5.ts
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+
+	//seg0.ts was removed when seg3.ts was added and stays available for its duration plus the 16 second playlist.
+	if len(expired) != 0 {
+		t.Fatal("Expected no expired segments but got", expired)
+	}
+	for i := 6; i < 10; i++ {
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 4}); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(4 * time.Second)
+	}
+	if strings.Join(expired, ",") != "seg0.ts,seg1.ts" {
+		t.Fatal("Expected seg0.ts and seg1.ts to expire but got", expired)
+	}
+
+	if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: "long.ts", Duration: 5}); err != HLS.InvalidTargetDuration {
+		t.Fatal("Expected", HLS.InvalidTargetDuration, "but got", err)
+	}
+	livePlaylist.End()
+	if strings.Join(expired, ",") != "seg0.ts,seg1.ts,seg2.ts" {
+		t.Fatal("Expected seg2.ts to expire at the end but got", expired)
+	}
+	if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: "late.ts", Duration: 4}); err != HLS.PlaylistEnded {
+		t.Fatal("Expected", HLS.PlaylistEnded, "but got", err)
+	} else if b, err := livePlaylist.Render(); err != nil || !strings.HasSuffix(string(b), "#EXT-X-ENDLIST\n") {
+		t.Fatal("Expected EXT-X-ENDLIST but got", string(b), err)
+	}
+
+	//Segments removed before the end expire when the playlist is read.
+	now = now.Add(time.Minute)
+	if _, err := livePlaylist.Render(); err != nil {
+		t.Fatal(err)
+	} else if strings.Join(expired, ",") != "seg0.ts,seg1.ts,seg2.ts,seg3.ts,seg4.ts,seg5.ts,seg6.ts" {
+		t.Fatal("Expected seg0.ts to seg6.ts to expire but got", expired)
+	}
+}
+
+func TestLiveMediaPlaylist_MinimumDuration(t *testing.T) {
+	//The playlist is never shorter than three target durations even if the window is smaller.
+	livePlaylist := HLS.NewLiveMediaPlaylist(6, 1)
+	livePlaylist.WindowDuration = 10 * time.Second
+	for i := range 10 {
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mediaPlaylist := livePlaylist.MediaPlaylist()
+	if len(mediaPlaylist.Segments) != 4 || mediaPlaylist.MediaSequence != 6 {
+		t.Fatal("Expected 4 segments from media sequence 6 but got", len(mediaPlaylist.Segments), mediaPlaylist.MediaSequence)
+	}
+}
+
+func TestLiveMediaPlaylist_Concurrent(t *testing.T) {
+	livePlaylist := HLS.NewLiveMediaPlaylist(2, 5)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				if _, _, err := livePlaylist.Playlist(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	for i := range 200 {
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if mediaPlaylist := livePlaylist.MediaPlaylist(); mediaPlaylist.MediaSequence != 195 {
+		t.Fatal("Expected media sequence 195 but got", mediaPlaylist.MediaSequence)
+	}
+}
+
+func TestLiveMediaPlaylist_Parts(t *testing.T) {
+	livePlaylist := HLS.NewLiveMediaPlaylist(2, 0)
+	if err := livePlaylist.AddPart(HLS.Part{URI: "part0.0.mp4", Duration: 1}); err != HLS.MissingPartInf {
+		t.Fatal("Expected", HLS.MissingPartInf, "but got", err)
+	}
+	livePlaylist.PartInf = &HLS.PartInf{PartTarget: 1}
+	livePlaylist.ServerControl = &HLS.ServerControl{PartHoldBack: 3, CanBlockReload: true}
+	if err := livePlaylist.AddPart(HLS.Part{URI: "long.mp4", Duration: 1.5}); err != HLS.InvalidPartDuration {
+		t.Fatal("Expected", HLS.InvalidPartDuration, "but got", err)
+	}
+
+	for i := range 5 {
+		for j := range 2 {
+			if err := livePlaylist.AddPart(HLS.Part{URI: fmt.Sprintf("part%d.%d.mp4", i, j), Duration: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.mp4", i), Duration: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	livePlaylist.AddPart(HLS.Part{URI: "part5.0.mp4", Duration: 1, Independent: true})
+
+	mediaPlaylist := livePlaylist.MediaPlaylist()
+	//Parts of segments that end more than three target durations before the end are removed.
+	for i, segment := range mediaPlaylist.Segments {
+		if expected := map[bool]int{true: 2, false: 0}[i >= 2]; len(segment.Parts) != expected {
+			t.Fatal("Expected", expected, "parts in segment", i, "but got", segment.Parts)
+		}
+	}
+	if len(mediaPlaylist.Parts) != 1 {
+		t.Fatal("Expected 1 trailing part but got", mediaPlaylist.Parts)
+	} else if next, parts := livePlaylist.NextPosition(); next != 5 || parts != 1 {
+		t.Fatal("Expected the position 5.1 but got", next, parts)
+	}
+	if b, err := livePlaylist.Render(); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(string(b), "seg4.mp4\n#EXT-X-PART:DURATION=1,URI=\"part5.0.mp4\",INDEPENDENT=YES\n") {
+		t.Fatal("Expected the trailing part but got", string(b))
+	}
+
+	//A client woken by a part sees the hint of the next part.
+	hints := make(chan []HLS.PreloadHint)
+	go func() {
+		livePlaylist.Wait(context.Background(), 5, 1)
+		hints <- livePlaylist.MediaPlaylist().PreloadHints
+	}()
+	livePlaylist.SetPreloadHints(HLS.PreloadHint{Type: HLS.PreloadHintPart, URI: "part5.1.mp4"})
+	livePlaylist.AddPartWithHints(HLS.Part{URI: "part5.1.mp4", Duration: 1}, HLS.PreloadHint{Type: HLS.PreloadHintPart, URI: "part6.0.mp4"})
+	if hints := <-hints; len(hints) != 1 || hints[0].URI != "part6.0.mp4" {
+		t.Fatal("Expected the hint part6.0.mp4 but got", hints)
+	}
+	livePlaylist.AddSegmentWithHints(HLS.MediaSegment{URI: "seg5.mp4", Duration: 2})
+	if mediaPlaylist := livePlaylist.MediaPlaylist(); len(mediaPlaylist.PreloadHints) != 0 || len(mediaPlaylist.Segments[len(mediaPlaylist.Segments)-1].Parts) != 2 {
+		t.Fatal("Unexpected playlist", mediaPlaylist)
+	}
+}
+
+func TestLiveMediaPlaylist_Delta(t *testing.T) {
+	livePlaylist := HLS.NewLiveMediaPlaylist(2, 0)
+	livePlaylist.ServerControl = &HLS.ServerControl{CanSkipUntil: 12}
+	for i := range 4 {
+		livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 2})
+	}
+	if delta := livePlaylist.DeltaMediaPlaylist(); delta.Skip != nil || len(delta.Segments) != 4 {
+		t.Fatal("Expected the full playlist but got", delta.Skip, delta.Segments)
+	}
+	for i := 4; i < 10; i++ {
+		livePlaylist.AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 2})
+	}
+
+	b, _, err := livePlaylist.DeltaPlaylist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:2
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-SERVER-CONTROL:CAN-SKIP-UNTIL=12
+#EXT-X-SKIP:SKIPPED-SEGMENTS=4
+#EXTINF:2,
+seg4.ts
+#EXTINF:2,
+seg5.ts
+#EXTINF:2,
+seg6.ts
+#EXTINF:2,
+seg7.ts
+#EXTINF:2,
+seg8.ts
+#EXTINF:2,
+seg9.ts
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+
+	delta, err := HLS.DecodeMediaPlaylist(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := HLS.MergeDelta(livePlaylist.MediaPlaylist(), delta)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(merged.Segments) != 10 || merged.Segments[3].URI != "seg3.ts" {
+		t.Fatal("Expected the full playlist but got", merged.Segments)
+	}
+}
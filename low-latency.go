@@ -0,0 +1,413 @@
+package HLS
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidPart            error = errors.New("Invalid EXT-X-PART tag")
+	InvalidPartInf         error = errors.New("Invalid EXT-X-PART-INF tag")
+	InvalidPreloadHint     error = errors.New("Invalid EXT-X-PRELOAD-HINT tag")
+	InvalidServerControl   error = errors.New("Invalid EXT-X-SERVER-CONTROL tag")
+	InvalidSkip            error = errors.New("Invalid EXT-X-SKIP tag")
+	InvalidRenditionReport error = errors.New("Invalid EXT-X-RENDITION-REPORT tag")
+	// DeltaMismatch is returned when a Playlist Delta Update skips segments that are not in the previous playlist.
+	DeltaMismatch error = errors.New("Playlist delta update does not match the previous playlist")
+	// MissingPartInf is returned for playlists with EXT-X-PART or EXT-X-PRELOAD-HINT TYPE=PART tags but without EXT-X-PART-INF.
+	MissingPartInf error = errors.New("EXT-X-PART-INF is required by EXT-X-PART tags")
+	// InvalidPartDuration is returned for EXT-X-PART tags longer than the PART-TARGET.
+	InvalidPartDuration error = errors.New("EXT-X-PART duration exceeds the part target duration")
+)
+
+// Part is a EXT-X-PART tag which identifies a Partial Segment of a Low-Latency HLS Media Playlist.
+type Part struct {
+	URI string
+	// Duration is the duration of the Partial Segment in seconds.
+	Duration float64
+	// Independent reports whether the Partial Segment contains a independent frame.
+	Independent bool
+	// ByteRange is the sub-range of the URI. The offset is always written.
+	ByteRange *ByteRange
+	// Gap reports whether the Partial Segment is not available.
+	Gap bool
+}
+
+// ToHLSTag returns the EXT-X-PART tag of the part.
+func (part *Part) ToHLSTag() HLSTag {
+	attributes := []string{
+		"DURATION=" + FormatDecimalFloatingPoint(part.Duration),
+		"URI=" + WrapQuotes(part.URI),
+	}
+	if part.Independent {
+		attributes = append(attributes, "INDEPENDENT=YES")
+	}
+	if part.ByteRange != nil {
+		attributes = append(attributes, "BYTERANGE="+WrapQuotes(part.ByteRange.String()))
+	}
+	if part.Gap {
+		attributes = append(attributes, "GAP=YES")
+	}
+	return HLSTag{
+		TagName: EXT_X_PART,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// ParsePart parses a EXT-X-PART tag. If the BYTERANGE has no offset, the offset is 0 and the second return value is false.
+func ParsePart(tag HLSTag) (Part, bool, error) {
+	part := Part{}
+	if tag.TagName != EXT_X_PART {
+		return part, false, InvalidPart
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return part, false, InvalidPart
+	}
+
+	hasOffset := true
+	for name, value := range attributes {
+		switch name {
+		case "DURATION":
+			if part.Duration, err = parseDecimalFloatingPoint(value); err != nil {
+				return part, false, InvalidPart
+			}
+		case "URI":
+			var ok bool
+			if part.URI, ok = unquote(value); !ok {
+				return part, false, InvalidPart
+			}
+		case "INDEPENDENT":
+			part.Independent = value == "YES"
+		case "GAP":
+			part.Gap = value == "YES"
+		case "BYTERANGE":
+			byteRange, ok := unquote(value)
+			if !ok {
+				return part, false, InvalidPart
+			}
+			br, offset, err := ParseByteRange(byteRange)
+			if err != nil {
+				return part, false, InvalidPart
+			}
+			part.ByteRange, hasOffset = &br, offset
+		}
+	}
+	if part.URI == "" || attributes["DURATION"] == "" {
+		return part, false, InvalidPart
+	}
+	return part, hasOffset, nil
+}
+
+// PartInf is the EXT-X-PART-INF tag.
+type PartInf struct {
+	// PartTarget is the Part Target Duration in seconds. Every Partial Segment must be shorter or equal.
+	PartTarget float64
+}
+
+// ToHLSTag returns the EXT-X-PART-INF tag.
+func (pi *PartInf) ToHLSTag() HLSTag {
+	return HLSTag{
+		TagName: EXT_X_PART_INF,
+		Value:   "PART-TARGET=" + FormatDecimalFloatingPoint(pi.PartTarget),
+	}
+}
+
+// ParsePartInf parses a EXT-X-PART-INF tag.
+func ParsePartInf(tag HLSTag) (PartInf, error) {
+	partInf := PartInf{}
+	if tag.TagName != EXT_X_PART_INF {
+		return partInf, InvalidPartInf
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return partInf, InvalidPartInf
+	}
+	if partInf.PartTarget, err = parseDecimalFloatingPoint(attributes["PART-TARGET"]); err != nil || partInf.PartTarget == 0 {
+		return partInf, InvalidPartInf
+	}
+	return partInf, nil
+}
+
+// PreloadHintType is the TYPE attribute of the EXT-X-PRELOAD-HINT tag.
+type PreloadHintType string
+
+const (
+	PreloadHintPart PreloadHintType = "PART"
+	PreloadHintMap  PreloadHintType = "MAP"
+)
+
+// PreloadHint is a EXT-X-PRELOAD-HINT tag which tells clients the resource that will be required next.
+type PreloadHint struct {
+	Type PreloadHintType
+	URI  string
+	// ByteRangeStart is the BYTERANGE-START. It is omitted if it is 0.
+	ByteRangeStart int64
+	// ByteRangeLength is the BYTERANGE-LENGTH. It is omitted if it is 0 which means the length is unknown.
+	ByteRangeLength int64
+}
+
+// ToHLSTag returns the EXT-X-PRELOAD-HINT tag.
+func (ph *PreloadHint) ToHLSTag() HLSTag {
+	attributes := []string{"TYPE=" + string(ph.Type), "URI=" + WrapQuotes(ph.URI)}
+	if ph.ByteRangeStart != 0 {
+		attributes = append(attributes, "BYTERANGE-START="+strconv.FormatInt(ph.ByteRangeStart, 10))
+	}
+	if ph.ByteRangeLength != 0 {
+		attributes = append(attributes, "BYTERANGE-LENGTH="+strconv.FormatInt(ph.ByteRangeLength, 10))
+	}
+	return HLSTag{
+		TagName: EXT_X_PRELOAD_HINT,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// ParsePreloadHint parses a EXT-X-PRELOAD-HINT tag.
+func ParsePreloadHint(tag HLSTag) (PreloadHint, error) {
+	hint := PreloadHint{}
+	if tag.TagName != EXT_X_PRELOAD_HINT {
+		return hint, InvalidPreloadHint
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return hint, InvalidPreloadHint
+	}
+
+	hint.Type = PreloadHintType(attributes["TYPE"])
+	if hint.Type != PreloadHintPart && hint.Type != PreloadHintMap {
+		return hint, InvalidPreloadHint
+	}
+	var ok bool
+	if hint.URI, ok = unquote(attributes["URI"]); !ok {
+		return hint, InvalidPreloadHint
+	}
+	for name, field := range map[string]*int64{"BYTERANGE-START": &hint.ByteRangeStart, "BYTERANGE-LENGTH": &hint.ByteRangeLength} {
+		value, ok := attributes[name]
+		if !ok {
+			continue
+		} else if !IsDecimalInteger(value) {
+			return hint, InvalidPreloadHint
+		}
+		if *field, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return hint, InvalidPreloadHint
+		}
+	}
+	return hint, nil
+}
+
+// ServerControl is the EXT-X-SERVER-CONTROL tag which announces the Low-Latency HLS features of the server.
+type ServerControl struct {
+	// CanSkipUntil is the skip boundary in seconds for Playlist Delta Updates. It is omitted if it is 0.
+	CanSkipUntil float64
+	// CanSkipDateRanges reports whether EXT-X-DATERANGE tags can be skipped in Playlist Delta Updates.
+	CanSkipDateRanges bool
+	// HoldBack is the minimum distance from the end of the playlist at which clients start playback in seconds. It is omitted if it is 0.
+	HoldBack float64
+	// PartHoldBack is the HoldBack for low-latency playback in seconds. It is omitted if it is 0.
+	PartHoldBack float64
+	// CanBlockReload reports whether the server supports Blocking Playlist Reload.
+	CanBlockReload bool
+}
+
+// ToHLSTag returns the EXT-X-SERVER-CONTROL tag.
+func (sc *ServerControl) ToHLSTag() HLSTag {
+	attributes := make([]string, 0, 5)
+	if sc.CanSkipUntil != 0 {
+		attributes = append(attributes, "CAN-SKIP-UNTIL="+FormatDecimalFloatingPoint(sc.CanSkipUntil))
+		if sc.CanSkipDateRanges {
+			attributes = append(attributes, "CAN-SKIP-DATERANGES=YES")
+		}
+	}
+	if sc.HoldBack != 0 {
+		attributes = append(attributes, "HOLD-BACK="+FormatDecimalFloatingPoint(sc.HoldBack))
+	}
+	if sc.PartHoldBack != 0 {
+		attributes = append(attributes, "PART-HOLD-BACK="+FormatDecimalFloatingPoint(sc.PartHoldBack))
+	}
+	if sc.CanBlockReload {
+		attributes = append(attributes, "CAN-BLOCK-RELOAD=YES")
+	}
+	return HLSTag{
+		TagName: EXT_X_SERVER_CONTROL,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// ParseServerControl parses a EXT-X-SERVER-CONTROL tag.
+func ParseServerControl(tag HLSTag) (ServerControl, error) {
+	serverControl := ServerControl{}
+	if tag.TagName != EXT_X_SERVER_CONTROL {
+		return serverControl, InvalidServerControl
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return serverControl, InvalidServerControl
+	}
+	for name, value := range attributes {
+		switch name {
+		case "CAN-SKIP-UNTIL":
+			serverControl.CanSkipUntil, err = parseDecimalFloatingPoint(value)
+		case "HOLD-BACK":
+			serverControl.HoldBack, err = parseDecimalFloatingPoint(value)
+		case "PART-HOLD-BACK":
+			serverControl.PartHoldBack, err = parseDecimalFloatingPoint(value)
+		case "CAN-SKIP-DATERANGES":
+			serverControl.CanSkipDateRanges = value == "YES"
+		case "CAN-BLOCK-RELOAD":
+			serverControl.CanBlockReload = value == "YES"
+		}
+		if err != nil {
+			return serverControl, InvalidServerControl
+		}
+	}
+	return serverControl, nil
+}
+
+// Skip is the EXT-X-SKIP tag of a Playlist Delta Update which replaces the oldest segments of the playlist.
+// Playlists with a EXT-X-SKIP tag require version 9.
+type Skip struct {
+	SkippedSegments uint64
+	// DateRangesSkipped reports whether EXT-X-DATERANGE tags were skipped too (_HLS_skip=v2).
+	// RECENTLY-REMOVED-DATERANGES is only written if DateRangesSkipped is true.
+	DateRangesSkipped bool
+	// RecentlyRemovedDateRanges are the IDs of the date ranges removed from the playlist recently.
+	RecentlyRemovedDateRanges []string
+}
+
+// ToHLSTag returns the EXT-X-SKIP tag.
+func (skip *Skip) ToHLSTag() HLSTag {
+	value := "SKIPPED-SEGMENTS=" + strconv.FormatUint(skip.SkippedSegments, 10)
+	if skip.DateRangesSkipped {
+		value += ",RECENTLY-REMOVED-DATERANGES=" + WrapQuotes(strings.Join(skip.RecentlyRemovedDateRanges, "\t"))
+	}
+	return HLSTag{
+		TagName: EXT_X_SKIP,
+		Value:   value,
+	}
+}
+
+// ParseSkip parses a EXT-X-SKIP tag.
+func ParseSkip(tag HLSTag) (Skip, error) {
+	skip := Skip{}
+	if tag.TagName != EXT_X_SKIP {
+		return skip, InvalidSkip
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil || !IsDecimalInteger(attributes["SKIPPED-SEGMENTS"]) {
+		return skip, InvalidSkip
+	}
+	if skip.SkippedSegments, err = strconv.ParseUint(attributes["SKIPPED-SEGMENTS"], 10, 64); err != nil {
+		return skip, InvalidSkip
+	}
+	if value, ok := attributes["RECENTLY-REMOVED-DATERANGES"]; ok {
+		if value, ok = unquote(value); !ok {
+			return skip, InvalidSkip
+		}
+		skip.DateRangesSkipped = true
+		if value != "" {
+			skip.RecentlyRemovedDateRanges = strings.Split(value, "\t")
+		}
+	}
+	return skip, nil
+}
+
+// RenditionReport is a EXT-X-RENDITION-REPORT tag which tells clients the last segment and Partial Segment of a
+// sibling rendition, so they can switch renditions without loading the sibling playlist first.
+type RenditionReport struct {
+	// URI is the URI of the sibling Media Playlist relative to the playlist that carries the report.
+	URI     string
+	LastMSN uint64
+	// LastPart is the index of the last Partial Segment of the segment LastMSN. It is omitted if it is negative.
+	LastPart int
+}
+
+// ToHLSTag returns the EXT-X-RENDITION-REPORT tag.
+func (rr *RenditionReport) ToHLSTag() HLSTag {
+	value := "URI=" + WrapQuotes(rr.URI) + ",LAST-MSN=" + strconv.FormatUint(rr.LastMSN, 10)
+	if rr.LastPart >= 0 {
+		value += ",LAST-PART=" + strconv.Itoa(rr.LastPart)
+	}
+	return HLSTag{
+		TagName: EXT_X_RENDITION_REPORT,
+		Value:   value,
+	}
+}
+
+// ParseRenditionReport parses a EXT-X-RENDITION-REPORT tag. LastPart is -1 if the LAST-PART attribute is missing.
+func ParseRenditionReport(tag HLSTag) (RenditionReport, error) {
+	report := RenditionReport{LastPart: -1}
+	if tag.TagName != EXT_X_RENDITION_REPORT {
+		return report, InvalidRenditionReport
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return report, InvalidRenditionReport
+	}
+	var ok bool
+	if report.URI, ok = unquote(attributes["URI"]); !ok || report.URI == "" {
+		return report, InvalidRenditionReport
+	}
+	if value, ok := attributes["LAST-MSN"]; ok {
+		if !IsDecimalInteger(value) {
+			return report, InvalidRenditionReport
+		} else if report.LastMSN, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return report, InvalidRenditionReport
+		}
+	}
+	if value, ok := attributes["LAST-PART"]; ok {
+		if !IsDecimalInteger(value) {
+			return report, InvalidRenditionReport
+		} else if report.LastPart, err = strconv.Atoi(value); err != nil {
+			return report, InvalidRenditionReport
+		}
+	}
+	return report, nil
+}
+
+// MergeDelta applies the Playlist Delta Update delta to previous, the last playlist fetched by the client, and returns
+// the full playlist. The skipped segments are taken from previous. If delta has no EXT-X-SKIP tag it is returned unchanged.
+// MergeDelta returns DeltaMismatch if previous does not contain every skipped segment.
+func MergeDelta(previous MediaPlaylist, delta MediaPlaylist) (MediaPlaylist, error) {
+	if delta.Skip == nil {
+		return delta, nil
+	}
+	first := delta.MediaSequence
+	end := first + delta.Skip.SkippedSegments
+	if first < previous.MediaSequence || end > previous.MediaSequence+uint64(len(previous.Segments)) {
+		return delta, DeltaMismatch
+	}
+
+	skipped := previous.Segments[first-previous.MediaSequence : end-previous.MediaSequence]
+	merged := delta
+	merged.Skip = nil
+	merged.Segments = make([]MediaSegment, 0, len(skipped)+len(delta.Segments))
+	merged.Segments = append(merged.Segments, skipped...)
+	merged.Segments = append(merged.Segments, delta.Segments...)
+	return merged, nil
+}
+
+// validate checks the Low-Latency HLS rules of the server control against the target durations.
+// HOLD-BACK must be at least three target durations, PART-HOLD-BACK at least two part target durations and
+// CAN-SKIP-UNTIL at least six target durations.
+func (sc *ServerControl) validate(targetDuration int, partInf *PartInf) error {
+	if sc.HoldBack != 0 && sc.HoldBack < float64(3*targetDuration) {
+		return InvalidServerControl
+	} else if sc.CanSkipUntil != 0 && sc.CanSkipUntil < float64(6*targetDuration) {
+		return InvalidServerControl
+	} else if sc.CanSkipDateRanges && sc.CanSkipUntil == 0 {
+		return InvalidServerControl
+	}
+	if partInf != nil && (sc.PartHoldBack == 0 || sc.PartHoldBack < 2*partInf.PartTarget) {
+		return InvalidServerControl
+	}
+	return nil
+}
+
+// parseDecimalFloatingPoint parses a decimal-floating-point attribute value.
+func parseDecimalFloatingPoint(value string) (float64, error) {
+	if !IsDecimalFloatingPoint(value) {
+		return 0, InvalidAttributeList
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,249 @@
+package HLS_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+const lowLatencyPlaylist = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:266
+#EXT-X-SERVER-CONTROL:CAN-SKIP-UNTIL=24,HOLD-BACK=12,PART-HOLD-BACK=1.002,CAN-BLOCK-RELOAD=YES
+#EXT-X-PART-INF:PART-TARGET=0.501
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:4.00008,
+fileSequence266.mp4
+#EXT-X-PART:DURATION=0.5,URI="filePart267.0.mp4",INDEPENDENT=YES
+#EXT-X-PART:DURATION=0.5,URI="filePart267.1.mp4"
+#EXTINF:1,
+fileSequence267.mp4
+#EXT-X-PART:DURATION=0.5,URI="fileSequence268.mp4",INDEPENDENT=YES,BYTERANGE="1000@0"
+#EXT-X-PART:DURATION=0.5,URI="fileSequence268.mp4",BYTERANGE="800"
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="fileSequence268.mp4",BYTERANGE-START=1800
+`
+
+func TestLowLatencyTags(t *testing.T) {
+	{
+		tag, _ := HLS.ParseHLSTag(`#EXT-X-PART:DURATION=0.33334,URI="part.mp4",INDEPENDENT=YES,BYTERANGE="100@20",GAP=YES`)
+		part, hasOffset, err := HLS.ParsePart(tag)
+		if err != nil {
+			t.Fatal(err)
+		} else if !hasOffset || part.Duration != 0.33334 || part.URI != "part.mp4" || !part.Independent || !part.Gap || *part.ByteRange != (HLS.ByteRange{Length: 100, Offset: 20}) {
+			t.Fatal("Unexpected part", part)
+		}
+		encoded := part.ToHLSTag()
+		if encoded.Value != `DURATION=0.33334,URI="part.mp4",INDEPENDENT=YES,BYTERANGE="100@20",GAP=YES` {
+			t.Fatal("Unexpected tag", encoded.Value)
+		}
+	}
+	{
+		for _, line := range []string{`#EXT-X-PART:URI="part.mp4"`, `#EXT-X-PART:DURATION=1`, `#EXT-X-PART:DURATION=1,URI="a",BYTERANGE="x"`} {
+			tag, _ := HLS.ParseHLSTag(line)
+			if _, _, err := HLS.ParsePart(tag); err != HLS.InvalidPart {
+				t.Fatal("Expected", HLS.InvalidPart, "for", line, "but got", err)
+			}
+		}
+	}
+	{
+		tag, _ := HLS.ParseHLSTag(`#EXT-X-PRELOAD-HINT:TYPE=MAP,URI="init.mp4",BYTERANGE-START=0,BYTERANGE-LENGTH=700`)
+		hint, err := HLS.ParsePreloadHint(tag)
+		if err != nil {
+			t.Fatal(err)
+		} else if hint != (HLS.PreloadHint{Type: HLS.PreloadHintMap, URI: "init.mp4", ByteRangeLength: 700}) {
+			t.Fatal("Unexpected hint", hint)
+		}
+		tag, _ = HLS.ParseHLSTag(`#EXT-X-PRELOAD-HINT:TYPE=SEGMENT,URI="a.mp4"`)
+		if _, err := HLS.ParsePreloadHint(tag); err != HLS.InvalidPreloadHint {
+			t.Fatal("Expected", HLS.InvalidPreloadHint, "but got", err)
+		}
+	}
+	{
+		tag, _ := HLS.ParseHLSTag(`#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=36,CAN-SKIP-DATERANGES=YES,PART-HOLD-BACK=3`)
+		serverControl, err := HLS.ParseServerControl(tag)
+		if err != nil {
+			t.Fatal(err)
+		} else if serverControl != (HLS.ServerControl{CanSkipUntil: 36, CanSkipDateRanges: true, PartHoldBack: 3, CanBlockReload: true}) {
+			t.Fatal("Unexpected server control", serverControl)
+		}
+		tag, _ = HLS.ParseHLSTag(`#EXT-X-SERVER-CONTROL:HOLD-BACK=-1`)
+		if _, err := HLS.ParseServerControl(tag); err != HLS.InvalidServerControl {
+			t.Fatal("Expected", HLS.InvalidServerControl, "but got", err)
+		}
+	}
+}
+
+func TestLowLatencyMediaPlaylist(t *testing.T) {
+	mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(lowLatencyPlaylist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mediaPlaylist.Segments) != 2 || len(mediaPlaylist.Segments[1].Parts) != 2 || len(mediaPlaylist.Parts) != 2 {
+		t.Fatal("Unexpected segments and parts", mediaPlaylist.Segments, mediaPlaylist.Parts)
+	} else if *mediaPlaylist.Parts[1].ByteRange != (HLS.ByteRange{Length: 800, Offset: 1000}) {
+		t.Fatal("Expected 800@1000 but got", mediaPlaylist.Parts[1].ByteRange)
+	} else if len(mediaPlaylist.PreloadHints) != 1 || mediaPlaylist.PreloadHints[0].ByteRangeStart != 1800 {
+		t.Fatal("Unexpected preload hints", mediaPlaylist.PreloadHints)
+	}
+
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(lowLatencyPlaylist, `BYTERANGE="800"`, `BYTERANGE="800@1000"`, 1)
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+}
+
+func TestLowLatencyValidity(t *testing.T) {
+	valid := func() HLS.MediaPlaylist {
+		return HLS.MediaPlaylist{
+			TargetDuration: 4,
+			ServerControl:  &HLS.ServerControl{HoldBack: 12, PartHoldBack: 2, CanBlockReload: true},
+			PartInf:        &HLS.PartInf{PartTarget: 1},
+			Segments: []HLS.MediaSegment{
+				{URI: "seg0.mp4", Duration: 4, Parts: []HLS.Part{{URI: "part0.mp4", Duration: 1}}},
+			},
+			Parts: []HLS.Part{{URI: "part1.mp4", Duration: 1}},
+		}
+	}
+	if mediaPlaylist := valid(); func() error { _, err := mediaPlaylist.Encode(); return err }() != nil {
+		t.Fatal("Expected a valid playlist")
+	}
+
+	cases := []struct {
+		modify   func(*HLS.MediaPlaylist)
+		expected error
+	}{
+		{func(mp *HLS.MediaPlaylist) { mp.PartInf = nil }, HLS.MissingPartInf},
+		{func(mp *HLS.MediaPlaylist) {
+			mp.PartInf, mp.Parts, mp.Segments[0].Parts = nil, nil, nil
+			mp.PreloadHints = []HLS.PreloadHint{{Type: HLS.PreloadHintPart, URI: "part2.mp4"}}
+		}, HLS.MissingPartInf},
+		{func(mp *HLS.MediaPlaylist) { mp.Parts[0].Duration = 1.5 }, HLS.InvalidPartDuration},
+		{func(mp *HLS.MediaPlaylist) { mp.ServerControl.PartHoldBack = 1.5 }, HLS.InvalidServerControl},
+		{func(mp *HLS.MediaPlaylist) { mp.ServerControl.PartHoldBack = 0 }, HLS.InvalidServerControl},
+		{func(mp *HLS.MediaPlaylist) { mp.ServerControl.HoldBack = 8 }, HLS.InvalidServerControl},
+		{func(mp *HLS.MediaPlaylist) { mp.ServerControl.CanSkipUntil = 20 }, HLS.InvalidServerControl},
+		{func(mp *HLS.MediaPlaylist) { mp.ServerControl = nil }, HLS.InvalidServerControl},
+	}
+	for i, c := range cases {
+		mediaPlaylist := valid()
+		c.modify(&mediaPlaylist)
+		if _, err := mediaPlaylist.Encode(); err != c.expected {
+			t.Fatal("Case", i, "expected", c.expected, "but got", err)
+		}
+	}
+
+	//The rules are checked by the encoder only, decoding accepts non-conforming live playlists.
+	tag := strings.Replace(lowLatencyPlaylist, "#EXT-X-PART-INF:PART-TARGET=0.501\n", "", 1)
+	mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(tag))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(mediaPlaylist.Parts) == 0 {
+		t.Fatal("Expected the Partial Segments to be decoded")
+	} else if _, err := mediaPlaylist.Encode(); err != HLS.MissingPartInf {
+		t.Fatal("Expected", HLS.MissingPartInf, "but got", err)
+	}
+	shortHoldBack := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-SERVER-CONTROL:HOLD-BACK=8,CAN-SKIP-UNTIL=4\n#EXTINF:4,\nseg0.ts\n"
+	if mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(shortHoldBack)); err != nil {
+		t.Fatal(err)
+	} else if mediaPlaylist.ServerControl == nil || mediaPlaylist.ServerControl.HoldBack != 8 {
+		t.Fatal("Unexpected server control", mediaPlaylist.ServerControl)
+	} else if _, err := mediaPlaylist.Encode(); err != HLS.InvalidServerControl {
+		t.Fatal("Expected", HLS.InvalidServerControl, "but got", err)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	tag, _ := HLS.ParseHLSTag("#EXT-X-SKIP:SKIPPED-SEGMENTS=3,RECENTLY-REMOVED-DATERANGES=\"splice-1\tsplice-2\"")
+	skip, err := HLS.ParseSkip(tag)
+	if err != nil {
+		t.Fatal(err)
+	} else if skip.SkippedSegments != 3 || !skip.DateRangesSkipped || strings.Join(skip.RecentlyRemovedDateRanges, ",") != "splice-1,splice-2" {
+		t.Fatal("Unexpected skip", skip)
+	} else if encoded := skip.ToHLSTag(); encoded.Value != tag.Value {
+		t.Fatal("Expected", tag.Value, "but got", encoded.Value)
+	}
+	tag, _ = HLS.ParseHLSTag("#EXT-X-SKIP:SKIPPED-SEGMENTS=2,RECENTLY-REMOVED-DATERANGES=\"\"")
+	if skip, err := HLS.ParseSkip(tag); err != nil || !skip.DateRangesSkipped || len(skip.RecentlyRemovedDateRanges) != 0 {
+		t.Fatal("Unexpected skip", skip, err)
+	}
+	tag, _ = HLS.ParseHLSTag("#EXT-X-SKIP:SKIPPED-SEGMENTS=-1")
+	if _, err := HLS.ParseSkip(tag); err != HLS.InvalidSkip {
+		t.Fatal("Expected", HLS.InvalidSkip, "but got", err)
+	}
+}
+
+func TestMergeDelta(t *testing.T) {
+	previous := HLS.MediaPlaylist{TargetDuration: 4, MediaSequence: 10}
+	for i := range 5 {
+		previous.Segments = append(previous.Segments, HLS.MediaSegment{URI: fmt.Sprintf("seg%d.ts", 10+i), Duration: 4})
+	}
+	delta := `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:12
+#EXT-X-SERVER-CONTROL:CAN-SKIP-UNTIL=24
+#EXT-X-SKIP:SKIPPED-SEGMENTS=3
+#EXTINF:4,
+seg15.ts
+#EXTINF:4,
+seg16.ts
+`
+	deltaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(delta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := HLS.MergeDelta(previous, deltaPlaylist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uris := make([]string, 0)
+	for _, segment := range merged.Segments {
+		uris = append(uris, segment.URI)
+	}
+	if merged.Skip != nil || merged.MediaSequence != 12 || strings.Join(uris, ",") != "seg12.ts,seg13.ts,seg14.ts,seg15.ts,seg16.ts" {
+		t.Fatal("Unexpected merged playlist", merged.MediaSequence, uris)
+	}
+
+	deltaPlaylist.MediaSequence = 13
+	if _, err := HLS.MergeDelta(previous, deltaPlaylist); err != HLS.DeltaMismatch {
+		t.Fatal("Expected", HLS.DeltaMismatch, "but got", err)
+	}
+	deltaPlaylist.MediaSequence = 9
+	if _, err := HLS.MergeDelta(previous, deltaPlaylist); err != HLS.DeltaMismatch {
+		t.Fatal("Expected", HLS.DeltaMismatch, "but got", err)
+	}
+}
+
+func TestRenditionReport(t *testing.T) {
+	tag, _ := HLS.ParseHLSTag(`#EXT-X-RENDITION-REPORT:URI="../1M/waitForMSN.php",LAST-MSN=273,LAST-PART=2`)
+	report, err := HLS.ParseRenditionReport(tag)
+	if err != nil {
+		t.Fatal(err)
+	} else if report != (HLS.RenditionReport{URI: "../1M/waitForMSN.php", LastMSN: 273, LastPart: 2}) {
+		t.Fatal("Unexpected report", report)
+	} else if encoded := report.ToHLSTag(); encoded.Value != tag.Value {
+		t.Fatal("Expected", tag.Value, "but got", encoded.Value)
+	}
+	tag, _ = HLS.ParseHLSTag(`#EXT-X-RENDITION-REPORT:URI="low.m3u8",LAST-MSN=4`)
+	if report, err := HLS.ParseRenditionReport(tag); err != nil || report.LastPart != -1 {
+		t.Fatal("Expected LastPart -1 but got", report, err)
+	} else if encoded := report.ToHLSTag(); encoded.Value != tag.Value {
+		t.Fatal("Expected", tag.Value, "but got", encoded.Value)
+	}
+	tag, _ = HLS.ParseHLSTag(`#EXT-X-RENDITION-REPORT:LAST-MSN=4`)
+	if _, err := HLS.ParseRenditionReport(tag); err != HLS.InvalidRenditionReport {
+		t.Fatal("Expected", HLS.InvalidRenditionReport, "but got", err)
+	}
+}
@@ -1,7 +1,6 @@
 // HLS Go module implements [HTTP Live Streaming] interface for Go.
 // HLS can encode and decode [HTTP Live Streaming] and also provide a tokenizer and a serializer for low level access.
-// This HLS module does not serve [HTTP Live Streaming] and it's users responsibility to serve [HTTP Live Streaming].
-// But HLS provides helper methods to server HTTP live streams.
+// HLS provides http.Handlers to serve playlists and keys with the correct headers and caching, and helper methods to server HTTP live streams.
 //
 // [HTTP Live Streaming]: https://datatracker.ietf.org/doc/html/rfc8216
 package HLS
@@ -266,6 +265,14 @@ const (
 	//(LL-HLS extension - not in RFC 8216 core)
 	EXT_X_PART PlaylistTag = "EXT-X-PART"
 
+	// The EXT-X-PART-INF tag provides information about the Partial
+	// Segments in the Playlist. It is required if the Playlist contains
+	// one or more EXT-X-PART tags.
+	//	#EXT-X-PART-INF:PART-TARGET=<s>
+	//
+	//(LL-HLS extension - not in RFC 8216 core)
+	EXT_X_PART_INF PlaylistTag = "EXT-X-PART-INF"
+
 	// The EXT-X-PRELOAD-HINT tag gives a hint about a resource the server
 	// is likely to upload next (used in LL-HLS push workflows).
 	//	#EXT-X-PRELOAD-HINT:<attribute-list>
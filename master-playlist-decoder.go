@@ -0,0 +1,191 @@
+package HLS
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	InvalidMasterPlaylist error = errors.New("Invalid Master Playlist")
+)
+
+// parseStreamAttributes parses the attributes shared by EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF.
+func parseStreamAttributes(attributes map[string]string) (bandwidth, averageBandwidth int, codecs []string, resolution *Resolution, ok bool) {
+	var err error
+	if bandwidth, err = parseInt(attributes["BANDWIDTH"]); err != nil {
+		return 0, 0, nil, nil, false
+	}
+	if value, exists := attributes["AVERAGE-BANDWIDTH"]; exists {
+		if averageBandwidth, err = parseInt(value); err != nil {
+			return 0, 0, nil, nil, false
+		}
+	}
+	if value, exists := attributes["CODECS"]; exists {
+		value, ok := unquote(value)
+		if !ok {
+			return 0, 0, nil, nil, false
+		}
+		for codec := range strings.SplitSeq(value, ",") {
+			codecs = append(codecs, strings.TrimSpace(codec))
+		}
+	}
+	if value, exists := attributes["RESOLUTION"]; exists {
+		r, err := ParseResolution(value)
+		if err != nil {
+			return 0, 0, nil, nil, false
+		}
+		resolution = &r
+	}
+	return bandwidth, averageBandwidth, codecs, resolution, true
+}
+
+// ParseVariantStream parses a EXT-X-STREAM-INF tag. The URI of the variant stream is the line following the tag.
+func ParseVariantStream(tag HLSTag) (VariantStream, error) {
+	variant := VariantStream{}
+	if tag.TagName != EXT_X_STREAM_INF {
+		return variant, InvalidVariant
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return variant, InvalidVariant
+	}
+	var ok bool
+	variant.Bandwidth, variant.AverageBandwidth, variant.Codecs, variant.Resolution, ok = parseStreamAttributes(attributes)
+	if !ok {
+		return variant, InvalidVariant
+	}
+	if value, exists := attributes["FRAME-RATE"]; exists {
+		if variant.FrameRate, err = parseDecimalFloatingPoint(value); err != nil {
+			return variant, InvalidVariant
+		}
+	}
+	//Unknown HDCP levels are kept, DeviceConstraints does not play them.
+	if value, exists := attributes["HDCP-LEVEL"]; exists {
+		if value == "" {
+			return variant, InvalidVariant
+		}
+		variant.HDCPLevel = HDCPLevel(value)
+	}
+	for name, group := range map[string]*string{"AUDIO": &variant.Audio, "VIDEO": &variant.Video, "SUBTITLES": &variant.Subtitles, "CLOSED-CAPTIONS": &variant.ClosedCaptions} {
+		value, exists := attributes[name]
+		if !exists {
+			continue
+		} else if name == "CLOSED-CAPTIONS" && value == "NONE" {
+			*group = value
+		} else if *group, ok = unquote(value); !ok {
+			return variant, InvalidVariant
+		}
+	}
+	return variant, nil
+}
+
+// ParseIFrameStream parses a EXT-X-I-FRAME-STREAM-INF tag.
+func ParseIFrameStream(tag HLSTag) (IFrameStream, error) {
+	iFrameStream := IFrameStream{}
+	if tag.TagName != EXT_X_I_FRAME_STREAM_INF {
+		return iFrameStream, InvalidIFrameStream
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return iFrameStream, InvalidIFrameStream
+	}
+	var ok bool
+	iFrameStream.Bandwidth, iFrameStream.AverageBandwidth, iFrameStream.Codecs, iFrameStream.Resolution, ok = parseStreamAttributes(attributes)
+	if !ok {
+		return iFrameStream, InvalidIFrameStream
+	}
+	if iFrameStream.URI, ok = unquote(attributes["URI"]); !ok || iFrameStream.URI == "" {
+		return iFrameStream, InvalidIFrameStream
+	}
+	return iFrameStream, nil
+}
+
+// DecodeMasterPlaylist reads a Master Playlist from r.
+// Every EXT-X-I-FRAME-STREAM-INF tag is added to MasterPlaylist.IFrameStreams, the position of the tag does not link it
+// to a variant stream. Unknown tags and comments are ignored.
+// DecodeMasterPlaylist returns InvalidMasterPlaylist if the playlist is malformed or if it contains Media Playlist tags.
+func DecodeMasterPlaylist(r io.Reader) (MasterPlaylist, error) {
+	tokenizer := NewPlayListTokenizer(r)
+	mp := MasterPlaylist{}
+
+	token, err := tokenizer.Advance()
+	if err != nil && err != io.EOF {
+		return mp, err
+	} else if token.Type != Tag || token.Value != EXTM3U {
+		return mp, InvalidMasterPlaylist
+	}
+
+	var variant *VariantStream
+	for {
+		token, err := tokenizer.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return mp, err
+		}
+
+		if token.Type == URI || token.Type == RelativeURI {
+			if variant == nil {
+				return mp, InvalidMasterPlaylist
+			}
+			variant.URI = token.Value
+			mp.Variants = append(mp.Variants, *variant)
+			variant = nil
+			continue
+		} else if token.Type != Tag {
+			continue
+		} else if variant != nil {
+			return mp, InvalidMasterPlaylist
+		}
+
+		tag, err := ParseHLSTag(token.Value)
+		if err != nil {
+			return mp, InvalidMasterPlaylist
+		}
+		switch tag.TagName {
+		case EXT_X_VERSION:
+			if mp.Version, err = parseInt(tag.Value); err != nil {
+				return mp, InvalidMasterPlaylist
+			}
+		case EXT_X_INDEPENDENT_SEGMENTS:
+			mp.IndependentSegments = true
+		case EXT_X_SESSION_DATA:
+			sessionData, err := ParseSessionData(tag)
+			if err != nil {
+				return mp, err
+			}
+			mp.SessionData = append(mp.SessionData, sessionData)
+		case EXT_X_SESSION_KEY:
+			key, err := ParseKey(tag)
+			if err != nil {
+				return mp, err
+			}
+			mp.SessionKeys = append(mp.SessionKeys, key)
+		case EXT_X_MEDIA:
+			rendition, err := ParseRendition(tag)
+			if err != nil {
+				return mp, err
+			}
+			mp.Renditions = append(mp.Renditions, rendition)
+		case EXT_X_STREAM_INF:
+			v, err := ParseVariantStream(tag)
+			if err != nil {
+				return mp, err
+			}
+			variant = &v
+		case EXT_X_I_FRAME_STREAM_INF:
+			iFrameStream, err := ParseIFrameStream(tag)
+			if err != nil {
+				return mp, err
+			}
+			mp.IFrameStreams = append(mp.IFrameStreams, iFrameStream)
+		case EXTINF, EXT_X_TARGETDURATION, EXT_X_MEDIA_SEQUENCE:
+			return mp, InvalidMasterPlaylist
+		}
+	}
+	if variant != nil {
+		return mp, InvalidMasterPlaylist
+	}
+	return mp, nil
+}
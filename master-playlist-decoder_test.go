@@ -0,0 +1,127 @@
+package HLS_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestDecodeMasterPlaylist(t *testing.T) {
+	{
+		example, err := os.ReadFile("./playlist-examples/master-playlist-with-i-frames.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		masterPlaylist, err := HLS.DecodeMasterPlaylist(strings.NewReader(string(example)))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(masterPlaylist.Variants) != 4 || len(masterPlaylist.IFrameStreams) != 3 {
+			t.Fatal("Expected 4 variant streams and 3 I-frame streams but got", masterPlaylist.Variants, masterPlaylist.IFrameStreams)
+		}
+		//I-frame streams are not linked to the variant stream in front of them.
+		for i, variant := range masterPlaylist.Variants {
+			if variant.IFrameStream != nil {
+				t.Fatal("Unexpected I-frame stream of variant stream", i, variant.IFrameStream)
+			}
+		}
+		if variant := masterPlaylist.Variants[1]; variant.URI != "mid/audio-video.m3u8" || variant.Bandwidth != 2560000 {
+			t.Fatal("Unexpected variant stream", variant)
+		} else if iFrameStream := masterPlaylist.IFrameStreams[1]; iFrameStream.URI != "mid/iframe.m3u8" || iFrameStream.Bandwidth != 150000 {
+			t.Fatal("Unexpected I-frame stream", iFrameStream)
+		}
+		if variant := masterPlaylist.Variants[3]; len(variant.Codecs) != 1 || variant.Codecs[0] != "mp4a.40.5" {
+			t.Fatal("Unexpected variant stream", variant)
+		}
+
+		playlist, err := masterPlaylist.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := HLS.DecodeMasterPlaylist(&playlist)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(decoded.Variants) != 4 || len(decoded.IFrameStreams) != 3 || decoded.IFrameStreams[2].URI != "hi/iframe.m3u8" || decoded.Variants[2].URI != "hi/audio-video.m3u8" {
+			t.Fatal("Expected the playlist to round trip but got", decoded)
+		}
+	}
+	{
+		example, err := os.ReadFile("./playlist-examples/master-playlist-with-alternative-video.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		//The example starts with a description in front of the EXTM3U tag.
+		example = example[strings.Index(string(example), "#EXTM3U"):]
+		masterPlaylist, err := HLS.DecodeMasterPlaylist(strings.NewReader(string(example)))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(masterPlaylist.Variants) != 3 || len(masterPlaylist.Renditions) != 9 {
+			t.Fatal("Expected 3 variant streams and 9 renditions but got", len(masterPlaylist.Variants), len(masterPlaylist.Renditions))
+		}
+		if variant := masterPlaylist.Variants[2]; variant.Video != "hi" || variant.URI != "hi/main/audio-video.m3u8" {
+			t.Fatal("Unexpected variant stream", variant)
+		}
+		expected := HLS.Rendition{Type: HLS.VIDEO, GroupID: "mid", Name: "Centerfield", URI: "mid/centerfield/audio-video.m3u8"}
+		if rendition := masterPlaylist.Renditions[4]; rendition != expected {
+			t.Fatal("Expected", expected, "but got", rendition)
+		}
+	}
+	{
+		masterPlaylist := HLS.MasterPlaylist{
+			Renditions: []HLS.Rendition{
+				{Type: HLS.AUDIO, GroupID: "aac", Name: "English", Language: "en", Default: true, Autoselect: true, Channels: "2", URI: "audio/en.m3u8"},
+				{Type: HLS.SUBTITLES, GroupID: "subs", Name: "Deutsch", Language: "de", Forced: true, URI: "subs/de.m3u8"},
+				{Type: HLS.CLOSED_CAPTIONS, GroupID: "cc", Name: "CC1", InstreamID: "CC1"},
+			},
+			Variants: []HLS.VariantStream{
+				{URI: "video/720p.m3u8", Bandwidth: 3000000, Codecs: []string{"avc1.4d401f", "mp4a.40.2"}, Resolution: &HLS.Resolution{Width: 1280, Height: 720}, FrameRate: 29.97, HDCPLevel: HLS.TYPE_0, Audio: "aac", Subtitles: "subs", ClosedCaptions: "cc"},
+				{URI: "video/360p.m3u8", Bandwidth: 800000, Audio: "aac", ClosedCaptions: "NONE"},
+			},
+			IFrameStreams: []HLS.IFrameStream{{URI: "video/iframe.m3u8", Bandwidth: 200000}},
+		}
+		playlist, err := masterPlaylist.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := HLS.DecodeMasterPlaylist(&playlist)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(decoded.Renditions) != 3 || len(decoded.Variants) != 2 || len(decoded.IFrameStreams) != 1 {
+			t.Fatal("Unexpected playlist", decoded)
+		}
+		for i := range decoded.Renditions {
+			if decoded.Renditions[i] != masterPlaylist.Renditions[i] {
+				t.Fatal("Expected", masterPlaylist.Renditions[i], "but got", decoded.Renditions[i])
+			}
+		}
+		variant := decoded.Variants[0]
+		if variant.Audio != "aac" || variant.Subtitles != "subs" || variant.ClosedCaptions != "cc" || variant.FrameRate != 29.97 || variant.HDCPLevel != HLS.TYPE_0 || *variant.Resolution != *masterPlaylist.Variants[0].Resolution || len(variant.Codecs) != 2 {
+			t.Fatal("Unexpected variant stream", variant)
+		}
+		if decoded.Variants[1].ClosedCaptions != "NONE" || decoded.IFrameStreams[0].URI != "video/iframe.m3u8" {
+			t.Fatal("Unexpected playlist", decoded)
+		}
+	}
+	{
+		//Unknown HDCP levels are kept.
+		masterPlaylist, err := HLS.DecodeMasterPlaylist(strings.NewReader("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1,HDCP-LEVEL=TYPE-2\nlow.m3u8\n"))
+		if err != nil {
+			t.Fatal(err)
+		} else if level := masterPlaylist.Variants[0].HDCPLevel; level != "TYPE-2" {
+			t.Fatal("Expected TYPE-2 but got", level)
+		}
+	}
+	for _, playlist := range []string{
+		"#EXT-X-STREAM-INF:BANDWIDTH=1\nlow.m3u8\n",
+		"#EXTM3U\nlow.m3u8\n",
+		"#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n",
+		"#EXTM3U\n#EXT-X-STREAM-INF:AVERAGE-BANDWIDTH=1\nlow.m3u8\n",
+		"#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nsegment.ts\n",
+		"#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,NAME=\"English\"\n",
+	} {
+		if _, err := HLS.DecodeMasterPlaylist(strings.NewReader(playlist)); err == nil {
+			t.Fatal("Expected a error for", playlist)
+		}
+	}
+}
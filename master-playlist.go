@@ -0,0 +1,327 @@
+package HLS
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidRendition    error = errors.New("Invalid EXT-X-MEDIA tag")
+	InvalidVariant      error = errors.New("Invalid EXT-X-STREAM-INF tag")
+	InvalidIFrameStream error = errors.New("Invalid EXT-X-I-FRAME-STREAM-INF tag")
+)
+
+// MediaType is the TYPE attribute of the EXT-X-MEDIA tag.
+type MediaType string
+
+const (
+	AUDIO           MediaType = "AUDIO"
+	VIDEO           MediaType = "VIDEO"
+	SUBTITLES       MediaType = "SUBTITLES"
+	CLOSED_CAPTIONS MediaType = "CLOSED-CAPTIONS"
+)
+
+// Rendition is a EXT-X-MEDIA tag which identifies a alternative rendition of a group. Variant streams refer to the
+// group with the AUDIO, VIDEO, SUBTITLES or CLOSED-CAPTIONS attribute.
+type Rendition struct {
+	Type    MediaType
+	GroupID string
+	Name    string
+	// URI is the URI of the Media Playlist of the rendition. It is empty for CLOSED-CAPTIONS and for renditions that are
+	// part of the variant stream.
+	URI        string
+	Language   string
+	Default    bool
+	Autoselect bool
+	Forced     bool
+	// InstreamID is the INSTREAM-ID of CLOSED-CAPTIONS renditions. e.g. "CC1"
+	InstreamID string
+	// Channels is the CHANNELS attribute of AUDIO renditions. e.g. "2" or "6"
+	Channels string
+}
+
+// ToHLSTag returns the EXT-X-MEDIA tag of the rendition.
+func (rendition *Rendition) ToHLSTag() HLSTag {
+	attributes := []string{
+		"TYPE=" + string(rendition.Type),
+		"GROUP-ID=" + WrapQuotes(rendition.GroupID),
+		"NAME=" + WrapQuotes(rendition.Name),
+	}
+	if rendition.Language != "" {
+		attributes = append(attributes, "LANGUAGE="+WrapQuotes(rendition.Language))
+	}
+	if rendition.Default {
+		attributes = append(attributes, "DEFAULT=YES")
+	}
+	if rendition.Autoselect {
+		attributes = append(attributes, "AUTOSELECT=YES")
+	}
+	if rendition.Forced {
+		attributes = append(attributes, "FORCED=YES")
+	}
+	if rendition.InstreamID != "" {
+		attributes = append(attributes, "INSTREAM-ID="+WrapQuotes(rendition.InstreamID))
+	}
+	if rendition.Channels != "" {
+		attributes = append(attributes, "CHANNELS="+WrapQuotes(rendition.Channels))
+	}
+	if rendition.URI != "" {
+		attributes = append(attributes, "URI="+WrapQuotes(rendition.URI))
+	}
+	return HLSTag{
+		TagName: EXT_X_MEDIA,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// ParseRendition parses a EXT-X-MEDIA tag.
+func ParseRendition(tag HLSTag) (Rendition, error) {
+	rendition := Rendition{}
+	if tag.TagName != EXT_X_MEDIA {
+		return rendition, InvalidRendition
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return rendition, InvalidRendition
+	}
+
+	for name, value := range attributes {
+		switch name {
+		case "GROUP-ID", "NAME", "URI", "LANGUAGE", "INSTREAM-ID", "CHANNELS":
+			var ok bool
+			if value, ok = unquote(value); !ok {
+				return rendition, InvalidRendition
+			}
+		}
+		switch name {
+		case "TYPE":
+			rendition.Type = MediaType(value)
+		case "GROUP-ID":
+			rendition.GroupID = value
+		case "NAME":
+			rendition.Name = value
+		case "URI":
+			rendition.URI = value
+		case "LANGUAGE":
+			rendition.Language = value
+		case "INSTREAM-ID":
+			rendition.InstreamID = value
+		case "CHANNELS":
+			rendition.Channels = value
+		case "DEFAULT":
+			rendition.Default = value == "YES"
+		case "AUTOSELECT":
+			rendition.Autoselect = value == "YES"
+		case "FORCED":
+			rendition.Forced = value == "YES"
+		}
+	}
+	switch rendition.Type {
+	case AUDIO, VIDEO, SUBTITLES, CLOSED_CAPTIONS:
+	default:
+		return rendition, InvalidRendition
+	}
+	if rendition.GroupID == "" || rendition.Name == "" {
+		return rendition, InvalidRendition
+	}
+	return rendition, nil
+}
+
+// HDCPLevel is the HDCP-LEVEL attribute of the EXT-X-STREAM-INF tag.
+type HDCPLevel string
+
+const (
+	HDCP_NONE HDCPLevel = "NONE"
+	// TYPE_0 requires High-bandwidth Digital Content Protection type 0 or higher.
+	TYPE_0 HDCPLevel = "TYPE-0"
+	// TYPE_1 requires High-bandwidth Digital Content Protection type 1 or higher.
+	TYPE_1 HDCPLevel = "TYPE-1"
+)
+
+// rank returns the order of the level. An empty level is NONE and unknown levels rank above TYPE_1.
+func (level HDCPLevel) rank() int {
+	switch level {
+	case "", HDCP_NONE:
+		return 0
+	case TYPE_0:
+		return 1
+	case TYPE_1:
+		return 2
+	}
+	return 3
+}
+
+// IFrameStream is a EXT-X-I-FRAME-STREAM-INF tag which identifies a I-frame Media Playlist.
+type IFrameStream struct {
+	URI string
+	// Bandwidth is the peak segment bit rate of the I-frame playlist in bits per second.
+	Bandwidth int
+	// AverageBandwidth is the average segment bit rate of the I-frame playlist. It is omitted if it is 0.
+	AverageBandwidth int
+	// Codecs is the list of formats of the I-frame playlist. It only contains video formats.
+	Codecs     []string
+	Resolution *Resolution
+}
+
+// ToHLSTag returns the EXT-X-I-FRAME-STREAM-INF tag of the I-frame stream.
+func (ifs *IFrameStream) ToHLSTag() HLSTag {
+	attributes := streamAttributes(ifs.Bandwidth, ifs.AverageBandwidth, ifs.Codecs, ifs.Resolution)
+	attributes = append(attributes, "URI="+WrapQuotes(ifs.URI))
+	return HLSTag{
+		TagName: EXT_X_I_FRAME_STREAM_INF,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// VariantStream is a EXT-X-STREAM-INF tag and the URI of its Media Playlist.
+type VariantStream struct {
+	URI string
+	// Bandwidth is the peak segment bit rate of the variant stream in bits per second.
+	Bandwidth int
+	// AverageBandwidth is the average segment bit rate of the variant stream. It is omitted if it is 0.
+	AverageBandwidth int
+	Codecs           []string
+	Resolution       *Resolution
+	// FrameRate is the maximum frame rate of the video. It is omitted if it is 0.
+	FrameRate float64
+	// HDCPLevel is the HDCP-LEVEL output protection required to play the variant stream. It is omitted if it is empty.
+	HDCPLevel HDCPLevel
+	// IFrameStream is the I-frame stream of the variant stream. Its EXT-X-I-FRAME-STREAM-INF tag is written after the variant stream.
+	IFrameStream *IFrameStream
+	// Audio, Video and Subtitles are the GROUP-IDs of the renditions of the variant stream. They are omitted if they are empty.
+	Audio     string
+	Video     string
+	Subtitles string
+	// ClosedCaptions is the GROUP-ID of the CLOSED-CAPTIONS renditions or NONE. It is omitted if it is empty.
+	ClosedCaptions string
+}
+
+// ToHLSTag returns the EXT-X-STREAM-INF tag of the variant stream.
+func (vs *VariantStream) ToHLSTag() HLSTag {
+	attributes := streamAttributes(vs.Bandwidth, vs.AverageBandwidth, vs.Codecs, vs.Resolution)
+	if vs.FrameRate != 0 {
+		attributes = append(attributes, "FRAME-RATE="+strconv.FormatFloat(vs.FrameRate, 'f', 3, 64))
+	}
+	if vs.HDCPLevel != "" {
+		attributes = append(attributes, "HDCP-LEVEL="+string(vs.HDCPLevel))
+	}
+	for _, group := range [][2]string{{"AUDIO", vs.Audio}, {"VIDEO", vs.Video}, {"SUBTITLES", vs.Subtitles}} {
+		if group[1] != "" {
+			attributes = append(attributes, group[0]+"="+WrapQuotes(group[1]))
+		}
+	}
+	if vs.ClosedCaptions == "NONE" {
+		attributes = append(attributes, "CLOSED-CAPTIONS=NONE")
+	} else if vs.ClosedCaptions != "" {
+		attributes = append(attributes, "CLOSED-CAPTIONS="+WrapQuotes(vs.ClosedCaptions))
+	}
+	return HLSTag{
+		TagName: EXT_X_STREAM_INF,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// streamAttributes returns the attributes shared by EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF in order.
+func streamAttributes(bandwidth, averageBandwidth int, codecs []string, resolution *Resolution) []string {
+	attributes := []string{"BANDWIDTH=" + strconv.Itoa(bandwidth)}
+	if averageBandwidth != 0 {
+		attributes = append(attributes, "AVERAGE-BANDWIDTH="+strconv.Itoa(averageBandwidth))
+	}
+	if len(codecs) > 0 {
+		attributes = append(attributes, "CODECS="+WrapQuotes(strings.Join(codecs, ",")))
+	}
+	if resolution != nil {
+		attributes = append(attributes, "RESOLUTION="+resolution.ToDecimalResolution())
+	}
+	return attributes
+}
+
+// MasterPlaylist is a Master Playlist.
+type MasterPlaylist struct {
+	// Version is the EXT-X-VERSION. If Version is 0 version 1 is used.
+	Version             int
+	IndependentSegments bool
+	// SessionData are written as EXT-X-SESSION-DATA tags.
+	SessionData []SessionData
+	// SessionKeys are written as EXT-X-SESSION-KEY tags. Every key must have the same attributes as the EXT-X-KEY tags of the Media Playlists.
+	SessionKeys []Key
+	// Renditions are written as EXT-X-MEDIA tags in front of the variant streams.
+	Renditions []Rendition
+	Variants   []VariantStream
+	// IFrameStreams are the I-frame streams that do not belong to a variant stream. They are written in front of the variant
+	// streams, so they are not mistaken for the I-frame stream of the last variant stream.
+	IFrameStreams []IFrameStream
+}
+
+// AppendTo appends the tags and URIs of mp to playlist.
+// The EXT-X-VERSION is raised if the session keys require a higher version.
+// AppendTo returns InvalidSessionKey if the method of a session key is NONE and InvalidSessionData if a session data has
+// no DATA-ID or not exactly one of VALUE and URI.
+func (mp *MasterPlaylist) AppendTo(playlist *Playlist) error {
+	for _, sessionData := range mp.SessionData {
+		if !sessionData.valid() {
+			return InvalidSessionData
+		}
+	}
+	version := max(mp.Version, 1)
+	for _, key := range mp.SessionKeys {
+		if key.Method == NONE {
+			return InvalidSessionKey
+		}
+		version = max(version, key.minimumVersion())
+	}
+	if err := playlist.SetHeader(version); err != nil {
+		return err
+	}
+	if mp.IndependentSegments {
+		if err := playlist.AppendTag(HLSTag{TagName: EXT_X_INDEPENDENT_SEGMENTS}); err != nil {
+			return err
+		}
+	}
+	for _, sessionData := range mp.SessionData {
+		if err := playlist.AppendTag(sessionData.ToHLSTag()); err != nil {
+			return err
+		}
+	}
+	for _, key := range mp.SessionKeys {
+		if err := playlist.AppendTag(key.ToSessionKeyTag()); err != nil {
+			return err
+		}
+	}
+
+	for _, rendition := range mp.Renditions {
+		if err := playlist.AppendTag(rendition.ToHLSTag()); err != nil {
+			return err
+		}
+	}
+
+	for _, iFrameStream := range mp.IFrameStreams {
+		if err := playlist.AppendTag(iFrameStream.ToHLSTag()); err != nil {
+			return err
+		}
+	}
+
+	for _, variant := range mp.Variants {
+		if err := playlist.AppendTag(variant.ToHLSTag()); err != nil {
+			return err
+		} else if err := playlist.AppendLine(NewPlaylistToken(getLineType(variant.URI), variant.URI)); err != nil {
+			return err
+		}
+		if variant.IFrameStream != nil {
+			if err := playlist.AppendTag(variant.IFrameStream.ToHLSTag()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Encode returns a closed Playlist containing mp.
+func (mp *MasterPlaylist) Encode() (Playlist, error) {
+	playlist := NewPlaylist()
+	if err := mp.AppendTo(&playlist); err != nil {
+		return playlist, err
+	}
+	return playlist, playlist.Close()
+}
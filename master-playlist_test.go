@@ -0,0 +1,70 @@
+package HLS_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestMasterPlaylistEncode(t *testing.T) {
+	masterPlaylist := HLS.MasterPlaylist{
+		Variants: []HLS.VariantStream{
+			{
+				URI:          "low/audio-video.m3u8",
+				Bandwidth:    1280000,
+				IFrameStream: &HLS.IFrameStream{URI: "low/iframe.m3u8", Bandwidth: 86000},
+			},
+			{
+				URI:          "mid/audio-video.m3u8",
+				Bandwidth:    2560000,
+				IFrameStream: &HLS.IFrameStream{URI: "mid/iframe.m3u8", Bandwidth: 150000},
+			},
+			{
+				URI:          "hi/audio-video.m3u8",
+				Bandwidth:    7680000,
+				IFrameStream: &HLS.IFrameStream{URI: "hi/iframe.m3u8", Bandwidth: 550000},
+			},
+			{
+				URI:       "audio-only.m3u8",
+				Bandwidth: 65000,
+				Codecs:    []string{"mp4a.40.5"},
+			},
+		},
+	}
+
+	playlist, err := masterPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	example, err := os.ReadFile("playlist-examples/master-playlist-with-i-frames.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(string(example), "#EXTM3U\n", "#EXTM3U\n#EXT-X-VERSION:1\n", 1)
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+}
+
+func TestIFrameStreamToHLSTag(t *testing.T) {
+	stream := HLS.IFrameStream{
+		URI:              "iframe.m3u8",
+		Bandwidth:        86000,
+		AverageBandwidth: 50000,
+		Codecs:           []string{"avc1.64001f"},
+		Resolution:       &HLS.Resolution{Width: 1280, Height: 720},
+	}
+	tag := stream.ToHLSTag()
+	expected := `BANDWIDTH=86000,AVERAGE-BANDWIDTH=50000,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="iframe.m3u8"`
+	if tag.TagName != HLS.EXT_X_I_FRAME_STREAM_INF || tag.Value != expected {
+		t.Fatal("Expected", expected, "but got", tag.TagName, tag.Value)
+	}
+}
@@ -0,0 +1,262 @@
+package HLS
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidMediaPlaylist error = errors.New("Invalid Media Playlist")
+	InvalidMap           error = errors.New("Invalid EXT-X-MAP tag")
+)
+
+// ParseMap parses a EXT-X-MAP tag.
+func ParseMap(tag HLSTag) (MediaInitializationSection, error) {
+	mis := MediaInitializationSection{}
+	if tag.TagName != EXT_X_MAP {
+		return mis, InvalidMap
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return mis, InvalidMap
+	}
+	var ok bool
+	if mis.URI, ok = unquote(attributes["URI"]); !ok || mis.URI == "" {
+		return mis, InvalidMap
+	}
+	if value, exists := attributes["BYTERANGE"]; exists {
+		value, ok := unquote(value)
+		if !ok {
+			return mis, InvalidMap
+		}
+		// The offset of a EXT-X-MAP BYTERANGE defaults to 0.
+		byteRange, _, err := ParseByteRange(value)
+		if err != nil {
+			return mis, InvalidMap
+		}
+		mis.ByteRange = &byteRange
+	}
+	return mis, nil
+}
+
+// mediaPlaylistDecoder holds the tags that apply to the next Media Segment while a Media Playlist is decoded.
+type mediaPlaylistDecoder struct {
+	mp      MediaPlaylist
+	segment MediaSegment
+	// hasOffset reports whether segment.ByteRange has a offset.
+	hasOffset bool
+	// keys are the active keys, one for each KEYFORMAT.
+	keys  []Key
+	mis   *MediaInitializationSection
+	parts []Part
+	// partEnds is the end of the last sub-range of each Partial Segment URI.
+	partEnds          map[string]int64
+	hasTargetDuration bool
+}
+
+// DecodeMediaPlaylist reads a Media Playlist from r.
+// EXT-X-KEY and EXT-X-MAP tags are applied to every following segment. A EXT-X-KEY tag replaces the key with the same
+// KEYFORMAT only and METHOD=NONE removes the key of its KEYFORMAT, so the keys of the other DRM systems stay active. A EXT-X-BYTERANGE without a offset begins after
+// the sub-range of the previous segment. EXT-X-PART tags belong to the next segment; the Partial Segments after the last
+// segment are returned in MediaPlaylist.Parts. Unknown tags and comments are ignored.
+// DecodeMediaPlaylist returns InvalidMediaPlaylist if the playlist is malformed. The Low-Latency HLS rules of AppendTo
+// like the minimum HOLD-BACK are not checked, so slightly non-conforming live playlists can still be played.
+func DecodeMediaPlaylist(r io.Reader) (MediaPlaylist, error) {
+	tokenizer := NewPlayListTokenizer(r)
+	decoder := mediaPlaylistDecoder{partEnds: make(map[string]int64)}
+
+	token, err := tokenizer.Advance()
+	if err != nil && err != io.EOF {
+		return decoder.mp, err
+	} else if token.Type != Tag || token.Value != EXTM3U {
+		return decoder.mp, InvalidMediaPlaylist
+	}
+	for {
+		token, err := tokenizer.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return decoder.mp, err
+		}
+
+		switch token.Type {
+		case Tag:
+			tag, err := ParseHLSTag(token.Value)
+			if err != nil {
+				return decoder.mp, InvalidMediaPlaylist
+			} else if err := decoder.decodeTag(tag); err != nil {
+				return decoder.mp, err
+			}
+		case URI, RelativeURI:
+			if err := decoder.decodeURI(token.Value); err != nil {
+				return decoder.mp, err
+			}
+		}
+	}
+
+	if !decoder.hasTargetDuration {
+		return decoder.mp, InvalidMediaPlaylist
+	}
+	decoder.mp.Parts = decoder.parts
+	return decoder.mp, nil
+}
+
+// decodeTag applies tag to the playlist or to the next segment.
+func (d *mediaPlaylistDecoder) decodeTag(tag HLSTag) error {
+	var err error
+	switch tag.TagName {
+	case EXT_X_VERSION:
+		d.mp.Version, err = parseInt(tag.Value)
+	case EXT_X_TARGETDURATION:
+		d.mp.TargetDuration, err = parseInt(tag.Value)
+		d.hasTargetDuration = true
+	case EXT_X_MEDIA_SEQUENCE:
+		d.mp.MediaSequence, err = parseUint(tag.Value)
+	case EXT_X_DISCONTINUITY_SEQUENCE:
+		d.mp.DiscontinuitySequence, err = parseUint(tag.Value)
+	case EXT_X_PLAYLIST_TYPE:
+		d.mp.PlaylistType = PlaylistType(tag.Value)
+		if d.mp.PlaylistType != VOD && d.mp.PlaylistType != EVENT {
+			return InvalidMediaPlaylist
+		}
+	case EXT_X_I_FRAMES_ONLY:
+		d.mp.IFramesOnly = true
+	case EXT_X_INDEPENDENT_SEGMENTS:
+		d.mp.IndependentSegments = true
+	case EXT_X_ENDLIST:
+		d.mp.EndList = true
+	case EXTINF:
+		duration, title, _ := strings.Cut(tag.Value, ",")
+		d.segment.Title = title
+		d.segment.Duration, err = parseDecimalFloatingPoint(duration)
+	case EXT_X_BYTERANGE:
+		byteRange, hasOffset, err := ParseByteRange(tag.Value)
+		if err != nil {
+			return InvalidMediaPlaylist
+		}
+		d.segment.ByteRange, d.hasOffset = &byteRange, hasOffset
+	case EXT_X_DISCONTINUITY:
+		d.segment.Discontinuity = true
+	case EXT_X_KEY:
+		key, err := ParseKey(tag)
+		if err != nil {
+			return err
+		}
+		d.keys = replaceKey(d.keys, key)
+	case EXT_X_MAP:
+		mis, err := ParseMap(tag)
+		if err != nil {
+			return err
+		}
+		d.mis = &mis
+	case EXT_X_SERVER_CONTROL:
+		serverControl, err := ParseServerControl(tag)
+		if err != nil {
+			return err
+		}
+		d.mp.ServerControl = &serverControl
+	case EXT_X_PART_INF:
+		partInf, err := ParsePartInf(tag)
+		if err != nil {
+			return err
+		}
+		d.mp.PartInf = &partInf
+	case EXT_X_PART:
+		part, hasOffset, err := ParsePart(tag)
+		if err != nil {
+			return err
+		}
+		if part.ByteRange != nil {
+			if !hasOffset {
+				part.ByteRange.Offset = d.partEnds[part.URI]
+			}
+			d.partEnds[part.URI] = part.ByteRange.Offset + part.ByteRange.Length
+		}
+		d.parts = append(d.parts, part)
+	case EXT_X_SKIP:
+		skip, err := ParseSkip(tag)
+		if err != nil {
+			return err
+		}
+		d.mp.Skip = &skip
+	case EXT_X_RENDITION_REPORT:
+		report, err := ParseRenditionReport(tag)
+		if err != nil {
+			return err
+		}
+		d.mp.RenditionReports = append(d.mp.RenditionReports, report)
+	case EXT_X_PRELOAD_HINT:
+		hint, err := ParsePreloadHint(tag)
+		if err != nil {
+			return err
+		}
+		d.mp.PreloadHints = append(d.mp.PreloadHints, hint)
+	}
+	if err != nil {
+		return InvalidMediaPlaylist
+	}
+	return nil
+}
+
+// replaceKey returns a copy of keys in which key replaces the key with the same KEYFORMAT. A key with METHOD=NONE
+// removes the key with its KEYFORMAT.
+func replaceKey(keys []Key, key Key) []Key {
+	keys = slices.Clone(keys)
+	i := slices.IndexFunc(keys, func(active Key) bool {
+		return active.format() == key.format()
+	})
+	switch {
+	case i < 0 && key.Method != NONE:
+		keys = append(keys, key)
+	case i >= 0 && key.Method != NONE:
+		keys[i] = key
+	case i >= 0:
+		keys = slices.Delete(keys, i, i+1)
+	}
+	return keys
+}
+
+// decodeURI completes the next segment with uri.
+func (d *mediaPlaylistDecoder) decodeURI(uri string) error {
+	segment := d.segment
+	segment.URI = uri
+	segment.Keys = d.keys
+	segment.Map = d.mis
+	segment.Parts = d.parts
+
+	if segment.ByteRange != nil && !d.hasOffset {
+		if len(d.mp.Segments) == 0 {
+			return InvalidMediaPlaylist
+		}
+		previous := d.mp.Segments[len(d.mp.Segments)-1]
+		if previous.URI != uri || previous.ByteRange == nil {
+			return InvalidMediaPlaylist
+		}
+		segment.ByteRange.Offset = previous.ByteRange.Offset + previous.ByteRange.Length
+	}
+
+	d.mp.Segments = append(d.mp.Segments, segment)
+	d.segment = MediaSegment{}
+	d.hasOffset = false
+	d.parts = nil
+	return nil
+}
+
+// parseInt parses a decimal-integer that fits in a int.
+func parseInt(value string) (int, error) {
+	if !IsDecimalInteger(value) {
+		return 0, InvalidMediaPlaylist
+	}
+	return strconv.Atoi(value)
+}
+
+// parseUint parses a decimal-integer.
+func parseUint(value string) (uint64, error) {
+	if !IsDecimalInteger(value) {
+		return 0, InvalidMediaPlaylist
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
@@ -0,0 +1,150 @@
+package HLS_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestDecodeMediaPlaylist(t *testing.T) {
+	{
+		file, err := os.Open("./playlist-examples/playlist-with-encrypted-media-segments.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		mediaPlaylist, err := HLS.DecodeMediaPlaylist(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mediaPlaylist.Version != 3 || mediaPlaylist.TargetDuration != 15 || mediaPlaylist.MediaSequence != 7794 || mediaPlaylist.EndList {
+			t.Fatal("Unexpected header", mediaPlaylist)
+		} else if len(mediaPlaylist.Segments) != 4 {
+			t.Fatal("Expected 4 segments but got", len(mediaPlaylist.Segments))
+		}
+		for i, uri := range []string{"r=52", "r=52", "r=52", "r=53"} {
+			segment := mediaPlaylist.Segments[i]
+			if len(segment.Keys) != 1 || !strings.HasSuffix(segment.Keys[0].URI, uri) {
+				t.Fatal("Expected the key", uri, "for segment", i, "but got", segment.Keys)
+			}
+		}
+		if mediaPlaylist.Segments[1].Duration != 15 || mediaPlaylist.Segments[3].URI != "http://media.example.com/fileSequence53-A.ts" {
+			t.Fatal("Unexpected segment", mediaPlaylist.Segments[1], mediaPlaylist.Segments[3])
+		}
+	}
+	{
+		playlist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:3
+#EXT-X-DISCONTINUITY-SEQUENCE:1
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MAP:URI="main.mp4",BYTERANGE="720@0"
+#EXTINF:10,First
+#EXT-X-BYTERANGE:1000@720
+main.mp4
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10,
+#EXT-X-BYTERANGE:2000
+main.mp4
+#EXT-X-KEY:METHOD=NONE,KEYFORMAT="com.apple.streamingkeydelivery"
+#EXT-X-KEY:METHOD=NONE,KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"
+#EXT-X-DISCONTINUITY
+#EXTINF:9.5,
+other.mp4
+#EXT-X-ENDLIST
+`
+		mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(playlist))
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments := mediaPlaylist.Segments
+		if len(segments) != 3 || segments[0].Title != "First" || *segments[1].ByteRange != (HLS.ByteRange{Length: 2000, Offset: 1720}) {
+			t.Fatal("Unexpected segments", segments)
+		} else if len(segments[0].Keys) != 0 || len(segments[1].Keys) != 2 || len(segments[2].Keys) != 0 {
+			t.Fatal("Unexpected keys", segments)
+		} else if !segments[2].Discontinuity || segments[2].Map == nil || *segments[2].Map.ByteRange != (HLS.ByteRange{Length: 720}) {
+			t.Fatal("Unexpected segment", segments[2])
+		} else if !mediaPlaylist.EndList || !mediaPlaylist.IndependentSegments || mediaPlaylist.PlaylistType != HLS.VOD || mediaPlaylist.DiscontinuitySequence != 1 {
+			t.Fatal("Unexpected header", mediaPlaylist)
+		}
+
+		encoded, err := mediaPlaylist.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := HLS.DecodeMediaPlaylist(&encoded)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(decoded.Segments) != 3 || len(decoded.Segments[1].Keys) != 2 || decoded.Segments[1].Keys[1].KeyFormat != HLS.WidevineKeyFormat {
+			t.Fatal("Expected the playlist to round trip but got", decoded)
+		}
+	}
+	{
+		//Only the FairPlay key rotates, the Widevine key stays active. METHOD=NONE ends the identity key only.
+		playlist := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10,
+a.mp4
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key2",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXTINF:10,
+b.mp4
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10,
+c.mp4
+#EXT-X-KEY:METHOD=NONE,KEYFORMAT="com.apple.streamingkeydelivery"
+#EXTINF:10,
+d.mp4
+`
+		mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(playlist))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := func(mediaPlaylist HLS.MediaPlaylist) {
+			t.Helper()
+			expected := [][]string{{"skd://key1", "data:text/plain;base64,AAAA"}, {"skd://key2", "data:text/plain;base64,AAAA"}, {"skd://key2", "data:text/plain;base64,AAAA"}, {"data:text/plain;base64,AAAA"}}
+			if len(mediaPlaylist.Segments) != len(expected) {
+				t.Fatal("Unexpected segments", mediaPlaylist.Segments)
+			}
+			for i, segment := range mediaPlaylist.Segments {
+				uris := make([]string, 0)
+				for _, key := range segment.Keys {
+					uris = append(uris, key.URI)
+				}
+				if strings.Join(uris, ",") != strings.Join(expected[i], ",") {
+					t.Fatal("Expected the keys", expected[i], "for segment", i, "but got", segment.Keys)
+				}
+			}
+		}
+		check(mediaPlaylist)
+
+		encoded, err := mediaPlaylist.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := HLS.DecodeMediaPlaylist(&encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(decoded)
+	}
+	{
+		for _, playlist := range []string{
+			"#EXT-X-TARGETDURATION:10\n",
+			"#EXTM3U\n#EXTINF:10,\na.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\n#EXT-X-BYTERANGE:100\na.ts\n",
+			"#EXTM3U\n#EXT-X-TARGETDURATION:ten\n",
+		} {
+			if _, err := HLS.DecodeMediaPlaylist(strings.NewReader(playlist)); err != HLS.InvalidMediaPlaylist {
+				t.Fatal("Expected", HLS.InvalidMediaPlaylist, "for", playlist, "but got", err)
+			}
+		}
+	}
+}
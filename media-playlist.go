@@ -0,0 +1,344 @@
+package HLS
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlaylistType is the value of the EXT-X-PLAYLIST-TYPE tag.
+type PlaylistType string
+
+const (
+	// VOD Media Playlists cannot change.
+	VOD PlaylistType = "VOD"
+	// EVENT Media Playlists can only be appended to.
+	EVENT PlaylistType = "EVENT"
+)
+
+var (
+	InvalidTargetDuration error = errors.New("EXTINF duration exceeds the target duration")
+	InvalidByteRange      error = errors.New("Invalid byte range")
+)
+
+// ByteRange is a sub-range of a resource. It is the value of the EXT-X-BYTERANGE tag and the BYTERANGE attribute.
+type ByteRange struct {
+	Length int64
+	Offset int64
+}
+
+// String returns the byte range as <n>@<o>.
+func (br ByteRange) String() string {
+	return fmt.Sprintf("%d@%d", br.Length, br.Offset)
+}
+
+// ParseByteRange parses a byte range of the form <n>[@<o>] and reports whether the offset is present.
+// If the offset is missing the sub-range begins at the next byte following the previous sub-range of the same resource.
+func ParseByteRange(value string) (ByteRange, bool, error) {
+	byteRange := ByteRange{}
+	length, offset, hasOffset := strings.Cut(value, "@")
+	if !IsDecimalInteger(length) || hasOffset && !IsDecimalInteger(offset) {
+		return byteRange, false, InvalidByteRange
+	}
+	var err error
+	if byteRange.Length, err = strconv.ParseInt(length, 10, 64); err != nil {
+		return byteRange, false, InvalidByteRange
+	}
+	if hasOffset {
+		if byteRange.Offset, err = strconv.ParseInt(offset, 10, 64); err != nil {
+			return byteRange, false, InvalidByteRange
+		}
+	}
+	return byteRange, hasOffset, nil
+}
+
+// MediaInitializationSection is the Media Initialization Section of the EXT-X-MAP tag.
+type MediaInitializationSection struct {
+	URI       string
+	ByteRange *ByteRange
+}
+
+// ToHLSTag returns the EXT-X-MAP tag of the Media Initialization Section.
+func (mis *MediaInitializationSection) ToHLSTag() HLSTag {
+	value := "URI=" + WrapQuotes(mis.URI)
+	if mis.ByteRange != nil {
+		value += ",BYTERANGE=" + WrapQuotes(mis.ByteRange.String())
+	}
+	return HLSTag{
+		TagName: EXT_X_MAP,
+		Value:   value,
+	}
+}
+
+// id returns the URI and the byte range of mis, which identify the Media Initialization Section.
+func (mis *MediaInitializationSection) id() string {
+	if mis.ByteRange == nil {
+		return mis.URI
+	}
+	return mis.URI + "@" + mis.ByteRange.String()
+}
+
+// equal reports whether mis and other describe the same Media Initialization Section. Both can be nil.
+func (mis *MediaInitializationSection) equal(other *MediaInitializationSection) bool {
+	if mis == nil || other == nil {
+		return mis == other
+	} else if mis.ByteRange == nil || other.ByteRange == nil {
+		return mis.URI == other.URI && mis.ByteRange == other.ByteRange
+	}
+	return mis.URI == other.URI && *mis.ByteRange == *other.ByteRange
+}
+
+// MediaSegment is a Media Segment of a Media Playlist.
+type MediaSegment struct {
+	URI string
+	// Duration is the EXTINF duration in seconds.
+	Duration float64
+	// Title is the optional human-readable title of the EXTINF tag.
+	Title string
+	// ByteRange is the EXT-X-BYTERANGE of the segment. The offset is always written.
+	ByteRange *ByteRange
+	// Discontinuity adds a EXT-X-DISCONTINUITY tag in front of the segment.
+	Discontinuity bool
+	// Map is the Media Initialization Section of the segment. The EXT-X-MAP tag is written when it differs from the previous segment.
+	Map *MediaInitializationSection
+	// Keys are the EXT-X-KEY tags of the segment, one for each KEYFORMAT. The tags are written when they differ from the previous segment.
+	// A EXT-X-KEY tag with METHOD=NONE is written for every KEYFORMAT of the previous segment that is not in Keys.
+	Keys []Key
+	// Parts are the EXT-X-PART tags of the Partial Segments that make up the segment. They are written in front of the EXTINF tag.
+	Parts []Part
+}
+
+// MediaPlaylist is a Media Playlist.
+type MediaPlaylist struct {
+	// Version is the EXT-X-VERSION. If Version is 0 the minimum version required by the playlist is used.
+	Version int
+	// TargetDuration is the EXT-X-TARGETDURATION in seconds. If TargetDuration is 0 it is computed from the segments.
+	TargetDuration        int
+	MediaSequence         uint64
+	DiscontinuitySequence uint64
+	PlaylistType          PlaylistType
+	// IFramesOnly adds the EXT-X-I-FRAMES-ONLY tag. Every segment of a I-frame playlist is a single I-frame.
+	IFramesOnly         bool
+	IndependentSegments bool
+	// ServerControl is the EXT-X-SERVER-CONTROL tag of a Low-Latency HLS playlist.
+	ServerControl *ServerControl
+	// PartInf is the EXT-X-PART-INF tag. It is required if the playlist has Partial Segments.
+	PartInf *PartInf
+	// Skip makes the playlist a Playlist Delta Update. Skip.SkippedSegments segments starting at MediaSequence are
+	// replaced by the EXT-X-SKIP tag and Segments are the segments after them.
+	Skip     *Skip
+	Segments []MediaSegment
+	// Parts are the Partial Segments of the segment that is not complete yet. They follow the last segment.
+	Parts []Part
+	// PreloadHints are the EXT-X-PRELOAD-HINT tags written at the end of the playlist.
+	PreloadHints []PreloadHint
+	// RenditionReports are the EXT-X-RENDITION-REPORT tags of the sibling renditions written after the PreloadHints.
+	RenditionReports []RenditionReport
+	// EndList adds the EXT-X-ENDLIST tag.
+	EndList bool
+}
+
+// FormatDecimalFloatingPoint returns value as a decimal-floating-point.
+func FormatDecimalFloatingPoint(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ComputeTargetDuration returns the smallest target duration that is valid for the segments of mp.
+// The EXTINF duration of each Media Segment, when rounded to the nearest integer, must be less than or equal to the target duration.
+func (mp *MediaPlaylist) ComputeTargetDuration() int {
+	targetDuration := 0
+	for _, segment := range mp.Segments {
+		targetDuration = max(targetDuration, int(math.Round(segment.Duration)))
+	}
+	return targetDuration
+}
+
+// MinimumVersion returns the lowest EXT-X-VERSION that supports every tag and attribute used by mp.
+func (mp *MediaPlaylist) MinimumVersion() int {
+	version := 1
+	if mp.IFramesOnly {
+		version = 4
+	}
+	if mp.Skip != nil {
+		version = 9
+	}
+	for _, segment := range mp.Segments {
+		if segment.Duration != math.Trunc(segment.Duration) {
+			version = max(version, 3)
+		}
+		if segment.ByteRange != nil {
+			version = max(version, 4)
+		}
+		for _, key := range segment.Keys {
+			version = max(version, key.minimumVersion())
+		}
+		if segment.Map != nil && mp.IFramesOnly {
+			version = max(version, 5)
+		} else if segment.Map != nil {
+			version = max(version, 6)
+		}
+	}
+	return version
+}
+
+// validateLowLatency checks the Low-Latency HLS rules of mp. Partial Segments and preload hints of TYPE=PART require a
+// EXT-X-PART-INF tag and a Partial Segment cannot be longer than the PART-TARGET.
+func (mp *MediaPlaylist) validateLowLatency(targetDuration int) error {
+	parts := mp.Parts
+	for _, segment := range mp.Segments {
+		parts = append(parts, segment.Parts...)
+	}
+	needsPartInf := len(parts) > 0
+	for _, hint := range mp.PreloadHints {
+		needsPartInf = needsPartInf || hint.Type == PreloadHintPart
+	}
+	if mp.PartInf == nil && needsPartInf {
+		return MissingPartInf
+	}
+	for _, part := range parts {
+		if part.Duration > mp.PartInf.PartTarget {
+			return InvalidPartDuration
+		}
+	}
+	if mp.ServerControl != nil {
+		return mp.ServerControl.validate(targetDuration, mp.PartInf)
+	} else if mp.PartInf != nil {
+		return InvalidServerControl
+	}
+	return nil
+}
+
+// AppendTo appends the tags and URIs of mp to playlist.
+// AppendTo returns InvalidTargetDuration if a segment is longer than mp.TargetDuration, MissingPartInf or
+// InvalidPartDuration for invalid Partial Segments and InvalidServerControl if the hold backs or the skip boundary are too short.
+func (mp *MediaPlaylist) AppendTo(playlist *Playlist) error {
+	version := mp.Version
+	if version == 0 {
+		version = mp.MinimumVersion()
+	}
+	targetDuration := mp.TargetDuration
+	if targetDuration == 0 {
+		targetDuration = mp.ComputeTargetDuration()
+	} else if targetDuration < mp.ComputeTargetDuration() {
+		return InvalidTargetDuration
+	}
+	if err := mp.validateLowLatency(targetDuration); err != nil {
+		return err
+	}
+
+	if err := playlist.SetHeader(version); err != nil {
+		return err
+	}
+	tags := []HLSTag{
+		{TagName: EXT_X_TARGETDURATION, Value: strconv.Itoa(targetDuration)},
+		{TagName: EXT_X_MEDIA_SEQUENCE, Value: strconv.FormatUint(mp.MediaSequence, 10)},
+	}
+	if mp.DiscontinuitySequence != 0 {
+		tags = append(tags, HLSTag{TagName: EXT_X_DISCONTINUITY_SEQUENCE, Value: strconv.FormatUint(mp.DiscontinuitySequence, 10)})
+	}
+	if mp.PlaylistType != "" {
+		tags = append(tags, HLSTag{TagName: EXT_X_PLAYLIST_TYPE, Value: string(mp.PlaylistType)})
+	}
+	if mp.IFramesOnly {
+		tags = append(tags, HLSTag{TagName: EXT_X_I_FRAMES_ONLY})
+	}
+	if mp.IndependentSegments {
+		tags = append(tags, HLSTag{TagName: EXT_X_INDEPENDENT_SEGMENTS})
+	}
+	if mp.ServerControl != nil {
+		tags = append(tags, mp.ServerControl.ToHLSTag())
+	}
+	if mp.PartInf != nil {
+		tags = append(tags, mp.PartInf.ToHLSTag())
+	}
+	if mp.Skip != nil {
+		tags = append(tags, mp.Skip.ToHLSTag())
+	}
+	for _, tag := range tags {
+		if err := playlist.AppendTag(tag); err != nil {
+			return err
+		}
+	}
+
+	var previous *MediaSegment
+	for i := range mp.Segments {
+		if err := appendSegment(playlist, &mp.Segments[i], previous); err != nil {
+			return err
+		}
+		previous = &mp.Segments[i]
+	}
+	for i := range mp.Parts {
+		if err := playlist.AppendTag(mp.Parts[i].ToHLSTag()); err != nil {
+			return err
+		}
+	}
+	for i := range mp.PreloadHints {
+		if err := playlist.AppendTag(mp.PreloadHints[i].ToHLSTag()); err != nil {
+			return err
+		}
+	}
+	for i := range mp.RenditionReports {
+		if err := playlist.AppendTag(mp.RenditionReports[i].ToHLSTag()); err != nil {
+			return err
+		}
+	}
+
+	if mp.EndList {
+		return playlist.AppendTag(HLSTag{TagName: EXT_X_ENDLIST})
+	}
+	return nil
+}
+
+// Encode returns a closed Playlist containing mp.
+func (mp *MediaPlaylist) Encode() (Playlist, error) {
+	playlist := NewPlaylist()
+	if err := mp.AppendTo(&playlist); err != nil {
+		return playlist, err
+	}
+	return playlist, playlist.Close()
+}
+
+// appendSegment appends the tags and the URI of segment to playlist.
+// previous is the segment in front of segment or nil. Tags that apply to every following segment are only appended if they differ from previous.
+func appendSegment(playlist *Playlist, segment *MediaSegment, previous *MediaSegment) error {
+	tags := make([]HLSTag, 0, 4)
+	if segment.Discontinuity {
+		tags = append(tags, HLSTag{TagName: EXT_X_DISCONTINUITY})
+	}
+	if previous == nil && len(segment.Keys) > 0 || previous != nil && !equalKeys(segment.Keys, previous.Keys) {
+		for _, key := range segment.Keys {
+			tags = append(tags, key.ToHLSTag())
+		}
+		if previous != nil {
+			for _, key := range previous.Keys {
+				if !slices.ContainsFunc(segment.Keys, func(current Key) bool { return current.format() == key.format() }) {
+					none := Key{Method: NONE, KeyFormat: key.KeyFormat}
+					tags = append(tags, none.ToHLSTag())
+				}
+			}
+		}
+	}
+	if segment.Map != nil && (previous == nil || !segment.Map.equal(previous.Map)) {
+		tags = append(tags, segment.Map.ToHLSTag())
+	}
+	for i := range segment.Parts {
+		tags = append(tags, segment.Parts[i].ToHLSTag())
+	}
+	tags = append(tags, HLSTag{
+		TagName: EXTINF,
+		Value:   FormatDecimalFloatingPoint(segment.Duration) + "," + segment.Title,
+	})
+	if segment.ByteRange != nil {
+		tags = append(tags, HLSTag{TagName: EXT_X_BYTERANGE, Value: segment.ByteRange.String()})
+	}
+
+	for _, tag := range tags {
+		if err := playlist.AppendTag(tag); err != nil {
+			return err
+		}
+	}
+	return playlist.AppendLine(NewPlaylistToken(getLineType(segment.URI), segment.URI))
+}
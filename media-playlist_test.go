@@ -0,0 +1,82 @@
+package HLS_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestMediaPlaylistEncode(t *testing.T) {
+	mediaPlaylist := HLS.MediaPlaylist{
+		PlaylistType: HLS.VOD,
+		Segments: []HLS.MediaSegment{
+			{URI: "http://media.example.com/first.ts", Duration: 9.009},
+			{URI: "http://media.example.com/second.ts", Duration: 9.009, Title: "Second"},
+			{URI: "/third.ts", Duration: 3.003, Discontinuity: true},
+		},
+		EndList: true,
+	}
+
+	playlist, err := mediaPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:9
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXTINF:9.009,
+http://media.example.com/first.ts
+#EXTINF:9.009,Second
+http://media.example.com/second.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:3.003,
+/third.ts
+#EXT-X-ENDLIST
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+}
+
+func TestMediaPlaylistTargetDuration(t *testing.T) {
+	mediaPlaylist := HLS.MediaPlaylist{
+		TargetDuration: 10,
+		Segments: []HLS.MediaSegment{
+			{URI: "first.ts", Duration: 10.4},
+		},
+	}
+	if mediaPlaylist.ComputeTargetDuration() != 10 {
+		t.Fatal("Expected 10 but got", mediaPlaylist.ComputeTargetDuration())
+	} else if _, err := mediaPlaylist.Encode(); err != nil {
+		t.Fatal(err)
+	}
+
+	mediaPlaylist.Segments = append(mediaPlaylist.Segments, HLS.MediaSegment{URI: "second.ts", Duration: 10.5})
+	if _, err := mediaPlaylist.Encode(); err != HLS.InvalidTargetDuration {
+		t.Fatal("Expected", HLS.InvalidTargetDuration, "but got", err)
+	}
+}
+
+func TestMediaPlaylistMinimumVersion(t *testing.T) {
+	mediaPlaylist := HLS.MediaPlaylist{
+		Segments: []HLS.MediaSegment{
+			{URI: "first.ts", Duration: 10},
+		},
+	}
+	if mediaPlaylist.MinimumVersion() != 1 {
+		t.Fatal("Expected version 1 but got", mediaPlaylist.MinimumVersion())
+	}
+
+	mediaPlaylist.Segments[0].Duration = 9.5
+	if mediaPlaylist.MinimumVersion() != 3 {
+		t.Fatal("Expected version 3 but got", mediaPlaylist.MinimumVersion())
+	}
+}
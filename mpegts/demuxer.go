@@ -0,0 +1,191 @@
+package mpegts
+
+import "time"
+
+// DefaultDiscontinuityThreshold is the DTS jump between two PES packets of the same PID that is treated as a discontinuity.
+const DefaultDiscontinuityThreshold = time.Second
+
+// pendingPES is a PES packet that is being reassembled.
+type pendingPES struct {
+	pid           uint16
+	offset        int64
+	end           int64
+	discontinuity bool
+	data          []byte
+}
+
+// Demuxer reassembles PES packets from transport stream packets.
+// Demuxer follows the PAT and PMT in the stream to find elementary streams.
+type Demuxer struct {
+	// DiscontinuityThreshold is the DTS jump that marks a PES packet as a discontinuity.
+	// If DiscontinuityThreshold is 0 DefaultDiscontinuityThreshold is used.
+	DiscontinuityThreshold time.Duration
+
+	pmtPIDs     map[uint16]bool
+	streams     []ElementaryStream
+	streamTypes map[uint16]StreamType
+	pending     map[uint16]*pendingPES
+	lastDTS     map[uint16]int64
+
+	hasReference bool
+	reference    int64
+}
+
+// NewDemuxer returns a new Demuxer.
+func NewDemuxer() *Demuxer {
+	return &Demuxer{
+		pmtPIDs:     make(map[uint16]bool),
+		streamTypes: make(map[uint16]StreamType),
+		pending:     make(map[uint16]*pendingPES),
+		lastDTS:     make(map[uint16]int64),
+	}
+}
+
+// Streams returns the elementary streams of the last PMT in PMT order.
+func (d *Demuxer) Streams() []ElementaryStream {
+	return d.streams
+}
+
+// IsPSI reports whether pid carries the PAT or a PMT.
+func (d *Demuxer) IsPSI(pid uint16) bool {
+	return pid == 0 || d.pmtPIDs[pid]
+}
+
+// Push passes a packet to the Demuxer and returns the PES packets completed by it.
+// A PES packet is complete when the next PES packet of the same PID starts or when PES_packet_length bytes have been received.
+// Packets of PIDs that are not listed in a PMT are ignored.
+func (d *Demuxer) Push(packet Packet) ([]PES, error) {
+	if packet.PID == 0 {
+		if packet.PayloadUnitStart {
+			programs, err := ParsePAT(packet.Payload)
+			if err != nil {
+				return nil, err
+			}
+			clear(d.pmtPIDs)
+			for _, program := range programs {
+				d.pmtPIDs[program.PMTPID] = true
+			}
+		}
+		return nil, nil
+	} else if d.pmtPIDs[packet.PID] {
+		if packet.PayloadUnitStart {
+			pmt, err := ParsePMT(packet.Payload)
+			if err != nil {
+				return nil, err
+			}
+			d.streams = pmt.Streams
+			clear(d.streamTypes)
+			for _, stream := range pmt.Streams {
+				d.streamTypes[stream.PID] = stream.StreamType
+			}
+		}
+		return nil, nil
+	} else if _, ok := d.streamTypes[packet.PID]; !ok {
+		return nil, nil
+	}
+
+	completed := make([]PES, 0, 1)
+	pending := d.pending[packet.PID]
+	if packet.PayloadUnitStart {
+		if pending != nil {
+			pes, err := d.finish(pending)
+			if err != nil {
+				return completed, err
+			}
+			completed = append(completed, pes)
+		}
+		pending = &pendingPES{
+			pid:    packet.PID,
+			offset: packet.Offset,
+		}
+		d.pending[packet.PID] = pending
+	} else if pending == nil {
+		return completed, nil
+	}
+
+	pending.data = append(pending.data, packet.Payload...)
+	pending.end = packet.Offset + PacketSize
+	pending.discontinuity = pending.discontinuity || packet.Discontinuity
+
+	//PES packets with a known length are complete as soon as all of their bytes arrived.
+	if len(pending.data) >= 6 {
+		length := int(pending.data[4])<<8 | int(pending.data[5])
+		if length > 0 && len(pending.data) >= 6+length {
+			pending.data = pending.data[:6+length]
+			delete(d.pending, packet.PID)
+			pes, err := d.finish(pending)
+			if err != nil {
+				return completed, err
+			}
+			completed = append(completed, pes)
+		}
+	}
+	return completed, nil
+}
+
+// Flush returns the PES packets that are still being reassembled. Flush should be called at the end of the stream.
+// Flush returns PES packets in PID order.
+func (d *Demuxer) Flush() ([]PES, error) {
+	completed := make([]PES, 0, len(d.pending))
+	for _, stream := range d.streams {
+		pending := d.pending[stream.PID]
+		if pending == nil {
+			continue
+		}
+		delete(d.pending, stream.PID)
+		pes, err := d.finish(pending)
+		if err != nil {
+			return completed, err
+		}
+		completed = append(completed, pes)
+	}
+	return completed, nil
+}
+
+func (d *Demuxer) finish(pending *pendingPES) (PES, error) {
+	pes := PES{
+		PID:           pending.pid,
+		StreamType:    d.streamTypes[pending.pid],
+		Offset:        pending.offset,
+		End:           pending.end,
+		Discontinuity: pending.discontinuity,
+	}
+
+	header, err := ParsePESHeader(pending.data)
+	if err != nil {
+		return pes, err
+	}
+	pes.StreamID = header.StreamID
+	pes.Data = pending.data[header.Length:]
+	pes.Keyframe = IsKeyframe(pes.StreamType, pes.Data)
+	if !header.HasPTS {
+		return pes, nil
+	}
+
+	pes.HasPTS = true
+	dts := header.PTS
+	if header.HasDTS {
+		dts = header.DTS
+	}
+	if d.hasReference {
+		pes.DTS = unwrap(d.reference, dts)
+	} else {
+		pes.DTS = dts
+	}
+	pes.PTS = unwrap(pes.DTS, header.PTS)
+	d.reference = pes.DTS
+	d.hasReference = true
+
+	threshold := d.DiscontinuityThreshold
+	if threshold == 0 {
+		threshold = DefaultDiscontinuityThreshold
+	}
+	if last, ok := d.lastDTS[pes.PID]; ok && (pes.StreamType.IsVideo() || pes.StreamType.IsAudio()) {
+		delta := ToDuration(pes.DTS - last)
+		if delta < 0 || delta > threshold {
+			pes.Discontinuity = true
+		}
+	}
+	d.lastDTS[pes.PID] = pes.DTS
+	return pes, nil
+}
@@ -0,0 +1,202 @@
+package mpegts
+
+import (
+	"io"
+	"slices"
+	"time"
+)
+
+// Keyframe is a keyframe of the video stream of a segment.
+type Keyframe struct {
+	// Offset is the byte offset of the first packet of the PES packet containing the keyframe and End is the byte offset after its last packet.
+	Offset int64
+	End    int64
+	PTS    int64
+}
+
+// Discontinuity is a PES packet whose timestamp does not follow the previous PES packet of the same PID.
+type Discontinuity struct {
+	PID    uint16
+	Offset int64
+	DTS    int64
+}
+
+// SegmentInfo describes the timing of a transport stream segment.
+type SegmentInfo struct {
+	Streams []ElementaryStream
+	// Size is the size of the segment in bytes.
+	Size int64
+	// StartPTS is the earliest PTS of the reference stream and EndPTS is the PTS right after its last frame.
+	// The reference stream is the first video stream or the first audio stream if there is no video.
+	StartPTS int64
+	EndPTS   int64
+	// Duration is EndPTS-StartPTS. Duration is the accurate value of the EXTINF tag of the segment.
+	Duration time.Duration
+	// StartsWithKeyframe is true if the first frame of the reference stream is a keyframe.
+	// Every segment of a Media Playlist with the EXT-X-INDEPENDENT-SEGMENTS tag must start with a keyframe.
+	StartsWithKeyframe bool
+	Keyframes          []Keyframe
+	// Discontinuities are timestamp discontinuities inside the segment. A segment with discontinuities should be split
+	// and the segment after the discontinuity should be marked with EXT-X-DISCONTINUITY.
+	Discontinuities []Discontinuity
+	// SPS is the first sequence parameter set of a H.264 stream or nil.
+	SPS *SPS
+}
+
+// ContinuesFrom reports whether the first frame of si directly follows the last frame of previous.
+// If ContinuesFrom returns false the segment of si should be preceded by a EXT-X-DISCONTINUITY tag.
+func (si *SegmentInfo) ContinuesFrom(previous SegmentInfo) bool {
+	delta := (si.StartPTS - previous.EndPTS) % timestampWrap
+	if delta > timestampWrap/2 {
+		delta -= timestampWrap
+	} else if delta < -timestampWrap/2 {
+		delta += timestampWrap
+	}
+	return ToDuration(max(delta, -delta)) <= DefaultDiscontinuityThreshold
+}
+
+// Inspector inspects transport stream segments.
+type Inspector struct {
+	// DiscontinuityThreshold is passed to the Demuxer.
+	DiscontinuityThreshold time.Duration
+}
+
+// Inspect reads a transport stream segment from r using the default Inspector and returns its SegmentInfo.
+func Inspect(r io.Reader) (SegmentInfo, error) {
+	inspector := Inspector{}
+	return inspector.Inspect(r)
+}
+
+// Inspect reads a transport stream segment from r and returns its SegmentInfo.
+func (inspector *Inspector) Inspect(r io.Reader) (SegmentInfo, error) {
+	info := SegmentInfo{}
+	packetReader := NewPacketReader(r)
+	demuxer := NewDemuxer()
+	demuxer.DiscontinuityThreshold = inspector.DiscontinuityThreshold
+
+	pesPackets := make([]PES, 0, 256)
+	for {
+		packet, err := packetReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return info, err
+		}
+		info.Size = packet.Offset + PacketSize
+
+		completed, err := demuxer.Push(packet)
+		if err != nil {
+			return info, err
+		}
+		info.findSPS(completed)
+		pesPackets = append(pesPackets, dropData(completed)...)
+	}
+	completed, err := demuxer.Flush()
+	if err != nil {
+		return info, err
+	}
+	info.findSPS(completed)
+	pesPackets = append(pesPackets, dropData(completed)...)
+
+	info.Streams = demuxer.Streams()
+	reference, ok := ReferenceStream(info.Streams)
+	if !ok {
+		return info, nil
+	}
+
+	pts := make([]int64, 0, len(pesPackets))
+	for _, pes := range pesPackets {
+		if pes.Discontinuity && (pes.StreamType.IsVideo() || pes.StreamType.IsAudio()) {
+			info.Discontinuities = append(info.Discontinuities, Discontinuity{
+				PID:    pes.PID,
+				Offset: pes.Offset,
+				DTS:    pes.DTS,
+			})
+		}
+		if pes.PID != reference.PID || !pes.HasPTS {
+			continue
+		}
+
+		if len(pts) == 0 {
+			info.StartsWithKeyframe = pes.Keyframe
+		}
+		if pes.Keyframe && pes.StreamType.IsVideo() {
+			info.Keyframes = append(info.Keyframes, Keyframe{
+				Offset: pes.Offset,
+				End:    pes.End,
+				PTS:    pes.PTS,
+			})
+		}
+		pts = append(pts, pes.PTS)
+	}
+	if len(pts) == 0 {
+		return info, nil
+	}
+
+	slices.Sort(pts)
+	info.StartPTS = pts[0]
+	info.EndPTS = pts[len(pts)-1] + FrameDuration(pts)
+	info.Duration = ToDuration(info.EndPTS - info.StartPTS)
+	return info, nil
+}
+
+// findSPS sets si.SPS to the first valid SPS of the H.264 keyframes of pesPackets. SPS NAL units are never encrypted by SAMPLE-AES.
+func (si *SegmentInfo) findSPS(pesPackets []PES) {
+	for _, pes := range pesPackets {
+		if si.SPS != nil {
+			return
+		} else if pes.StreamType != H264 && pes.StreamType != SampleAESH264 || !pes.Keyframe {
+			continue
+		}
+		for _, nal := range SplitNALUnits(pes.Data) {
+			if H264NALType(nal) != H264SPS {
+				continue
+			}
+			if sps, err := ParseSPS(nal); err == nil {
+				si.SPS = &sps
+				break
+			}
+		}
+	}
+}
+
+// dropData removes the elementary stream data of PES packets so they can be kept cheaply.
+func dropData(pesPackets []PES) []PES {
+	for i := range pesPackets {
+		pesPackets[i].Data = nil
+	}
+	return pesPackets
+}
+
+// ReferenceStream returns the stream used for timing a segment which is the first video stream or the first audio stream.
+func ReferenceStream(streams []ElementaryStream) (ElementaryStream, bool) {
+	for _, stream := range streams {
+		if stream.StreamType.IsVideo() {
+			return stream, true
+		}
+	}
+	for _, stream := range streams {
+		if stream.StreamType.IsAudio() {
+			return stream, true
+		}
+	}
+	return ElementaryStream{}, false
+}
+
+// FrameDuration returns the most common difference between consecutive timestamps of sorted.
+// sorted must be sorted in ascending order. FrameDuration returns 0 if there are less than two timestamps.
+func FrameDuration(sorted []int64) int64 {
+	counts := make(map[int64]int)
+	var duration int64
+	for i := 1; i < len(sorted); i++ {
+		delta := sorted[i] - sorted[i-1]
+		if delta <= 0 {
+			continue
+		}
+		counts[delta]++
+		if counts[delta] > counts[duration] || counts[delta] == counts[duration] && delta < duration {
+			duration = delta
+		}
+	}
+	return duration
+}
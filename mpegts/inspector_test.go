@@ -0,0 +1,78 @@
+package mpegts_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func inspectExampleSegment(t *testing.T, i int) mpegts.SegmentInfo {
+	t.Helper()
+	f, err := os.Open(fmt.Sprintf("../examples/serving-a-video/video-fragments/seg%03d.ts", i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := mpegts.Inspect(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestInspect(t *testing.T) {
+	//EXTINF values of ../examples/serving-a-video/video-fragments/playlist.m3u8
+	durations := []string{"11.266667", "13.766667", "7.166667", "8.533333", "11.800000", "7.433333"}
+
+	var previous mpegts.SegmentInfo
+	for i, duration := range durations {
+		info := inspectExampleSegment(t, i)
+
+		if output := fmt.Sprintf("%.6f", info.Duration.Seconds()); output != duration {
+			t.Fatal("Expected", duration, "but got", output)
+		} else if !info.StartsWithKeyframe {
+			t.Fatal("Expected segment", i, "to start with a keyframe")
+		} else if len(info.Keyframes) == 0 || info.Keyframes[0].PTS != info.StartPTS {
+			t.Fatal("Expected the first keyframe at", info.StartPTS, "but got", info.Keyframes)
+		} else if len(info.Discontinuities) != 0 {
+			t.Fatal("Expected no discontinuities but got", info.Discontinuities)
+		} else if len(info.Streams) != 2 {
+			t.Fatal("Expected 2 streams but got", len(info.Streams))
+		} else if info.SPS == nil || info.SPS.Codec() != "avc1.64001f" {
+			t.Fatal("Expected the avc1.64001f SPS but got", info.SPS)
+		}
+
+		if i > 0 && !info.ContinuesFrom(previous) {
+			t.Fatal("Expected segment", i, "to continue from the previous segment")
+		} else if i == 0 && info.ContinuesFrom(inspectExampleSegment(t, 3)) {
+			t.Fatal("Expected segment 0 not to continue from segment 3")
+		}
+		previous = info
+	}
+}
+
+func TestReferenceStream(t *testing.T) {
+	streams := []mpegts.ElementaryStream{
+		{PID: 0x101, StreamType: mpegts.AAC},
+		{PID: 0x100, StreamType: mpegts.H264},
+	}
+
+	if stream, ok := mpegts.ReferenceStream(streams); !ok || stream.PID != 0x100 {
+		t.Fatal("Expected the video stream but got", stream)
+	} else if stream, ok := mpegts.ReferenceStream(streams[:1]); !ok || stream.PID != 0x101 {
+		t.Fatal("Expected the audio stream but got", stream)
+	} else if _, ok := mpegts.ReferenceStream(nil); ok {
+		t.Fatal("Expected no reference stream")
+	}
+}
+
+func TestFrameDuration(t *testing.T) {
+	if d := mpegts.FrameDuration([]int64{0, 3000, 6000, 9000, 15000}); d != 3000 {
+		t.Fatal("Expected 3000 but got", d)
+	} else if d := mpegts.FrameDuration([]int64{0}); d != 0 {
+		t.Fatal("Expected 0 but got", d)
+	}
+}
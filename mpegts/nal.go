@@ -0,0 +1,121 @@
+package mpegts
+
+import "bytes"
+
+// H.264 NAL unit types.
+const (
+	H264NonIDRSlice = 1
+	H264IDRSlice    = 5
+	H264SEI         = 6
+	H264SPS         = 7
+	H264PPS         = 8
+	H264AUD         = 9
+)
+
+var startCode = []byte{0, 0, 1}
+
+// SplitNALUnits splits Annex B formatted data into NAL units.
+// The returned NAL units do not contain start codes and reference data.
+func SplitNALUnits(data []byte) [][]byte {
+	nalUnits := make([][]byte, 0, 4)
+	i := bytes.Index(data, startCode)
+	for i >= 0 {
+		start := i + len(startCode)
+		next := bytes.Index(data[start:], startCode)
+		end := len(data)
+		if next >= 0 {
+			end = start + next
+		}
+
+		//Trailing zero bytes belong to the next four byte start code.
+		nal := bytes.TrimRight(data[start:end], "\x00")
+		if len(nal) > 0 {
+			nalUnits = append(nalUnits, nal)
+		}
+
+		if next < 0 {
+			break
+		}
+		i = start + next
+	}
+	return nalUnits
+}
+
+// H264NALType returns the nal_unit_type of a H.264 NAL unit.
+func H264NALType(nal []byte) int {
+	if len(nal) == 0 {
+		return 0
+	}
+	return int(nal[0] & 0x1F)
+}
+
+// H265NALType returns the nal_unit_type of a H.265 NAL unit.
+func H265NALType(nal []byte) int {
+	if len(nal) == 0 {
+		return 0
+	}
+	return int(nal[0]>>1) & 0x3F
+}
+
+// IsKeyframe reports whether data of a elementary stream of streamType contains a keyframe.
+// For H.264 a keyframe is a IDR slice and for H.265 a keyframe is a IRAP picture.
+// SAMPLE-AES H.264 is handled like H.264 because NAL unit headers are not encrypted.
+// Every frame of a audio stream is a keyframe.
+// Other video stream types are never keyframes.
+func IsKeyframe(streamType StreamType, data []byte) bool {
+	switch {
+	case streamType == H264 || streamType == SampleAESH264:
+		for _, nal := range SplitNALUnits(data) {
+			if H264NALType(nal) == H264IDRSlice {
+				return true
+			}
+		}
+	case streamType == H265:
+		for _, nal := range SplitNALUnits(data) {
+			if t := H265NALType(nal); t >= 16 && t <= 21 {
+				return true
+			}
+		}
+	case streamType.IsAudio():
+		return true
+	}
+	return false
+}
+
+// UnescapeRBSP removes the emulation prevention bytes (0x03 after two zero bytes) of a NAL unit.
+func UnescapeRBSP(nal []byte) []byte {
+	rbsp := make([]byte, 0, len(nal))
+	zeros := 0
+	for _, b := range nal {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, b)
+	}
+	return rbsp
+}
+
+// EscapeRBSP inserts emulation prevention bytes so rbsp contains no start code. EscapeRBSP is the inverse of UnescapeRBSP.
+func EscapeRBSP(rbsp []byte) []byte {
+	nal := make([]byte, 0, len(rbsp)+len(rbsp)/64)
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= 3 {
+			nal = append(nal, 3)
+			zeros = 0
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		nal = append(nal, b)
+	}
+	return nal
+}
@@ -0,0 +1,65 @@
+package mpegts_test
+
+import (
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestSplitNALUnits(t *testing.T) {
+	data := []byte{0, 0, 0, 1, 0x09, 0xF0, 0, 0, 0, 1, 0x67, 0x64, 0, 0, 1, 0x65, 0x88, 0x84}
+	nalUnits := mpegts.SplitNALUnits(data)
+	if len(nalUnits) != 3 {
+		t.Fatal("Expected 3 NAL units but got", len(nalUnits))
+	}
+
+	types := []int{mpegts.H264AUD, mpegts.H264SPS, mpegts.H264IDRSlice}
+	for i, nal := range nalUnits {
+		if mpegts.H264NALType(nal) != types[i] {
+			t.Fatal("Expected NAL type", types[i], "but got", mpegts.H264NALType(nal))
+		}
+	}
+	if len(nalUnits[0]) != 2 {
+		t.Fatal("Expected trailing zero bytes to be removed but got", nalUnits[0])
+	}
+}
+
+func TestIsKeyframe(t *testing.T) {
+	testcases := []struct {
+		streamType mpegts.StreamType
+		data       []byte
+		keyframe   bool
+	}{
+		{mpegts.H264, []byte{0, 0, 1, 0x09, 0xF0, 0, 0, 1, 0x65, 0x88}, true},
+		{mpegts.H264, []byte{0, 0, 1, 0x09, 0xF0, 0, 0, 1, 0x41, 0x9A}, false},
+		{mpegts.H265, []byte{0, 0, 1, 0x26, 0x01, 0xAF}, true},
+		{mpegts.H265, []byte{0, 0, 1, 0x02, 0x01, 0xD0}, false},
+		{mpegts.AAC, []byte{0xFF, 0xF1}, true},
+		{mpegts.Metadata, []byte{0x49, 0x44, 0x33}, false},
+	}
+
+	for _, testcase := range testcases {
+		if mpegts.IsKeyframe(testcase.streamType, testcase.data) != testcase.keyframe {
+			t.Fatal("Expected", testcase.keyframe, "for", testcase.streamType.String(), testcase.data)
+		}
+	}
+}
+
+func TestUnescapeRBSP(t *testing.T) {
+	rbsp := mpegts.UnescapeRBSP([]byte{0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x03})
+	expected := []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x03}
+	if string(rbsp) != string(expected) {
+		t.Fatal("Expected", expected, "but got", rbsp)
+	}
+}
+
+func TestEscapeRBSP(t *testing.T) {
+	rbsp := []byte{0x65, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x04}
+	nal := mpegts.EscapeRBSP(rbsp)
+	expected := []byte{0x65, 0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x03, 0x00, 0x00, 0x04}
+	if string(nal) != string(expected) {
+		t.Fatal("Expected", expected, "but got", nal)
+	} else if unescaped := mpegts.UnescapeRBSP(nal); string(unescaped) != string(rbsp) {
+		t.Fatal("Expected", rbsp, "but got", unescaped)
+	}
+}
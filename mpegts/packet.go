@@ -0,0 +1,171 @@
+// Package mpegts implements a demux-lite reader for [MPEG transport streams].
+// mpegts parses PAT/PMT sections, identifies elementary streams, extracts PTS/DTS from PES headers and
+// detects keyframes so HLS Media Segments can be inspected without a full demuxer.
+// mpegts does not decode media and only understands enough of the stream to describe the timing of a segment.
+//
+// [MPEG transport streams]: https://www.itu.int/rec/T-REC-H.222.0
+package mpegts
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+const (
+	// PacketSize is the size of a transport stream packet in bytes.
+	PacketSize = 188
+	// SyncByte is the first byte of every transport stream packet.
+	SyncByte = 0x47
+	// NullPID is the PID of null packets used for padding.
+	NullPID uint16 = 0x1FFF
+)
+
+var (
+	InvalidPacket error = errors.New("Invalid MPEG-TS packet")
+)
+
+// Packet is a single transport stream packet.
+type Packet struct {
+	// Offset is the byte offset of the packet from the beginning of the stream.
+	Offset int64
+	PID    uint16
+	// PayloadUnitStart is the payload_unit_start_indicator. It is set when a PES packet or a PSI section starts in this packet.
+	PayloadUnitStart  bool
+	ContinuityCounter uint8
+	// Discontinuity is the discontinuity_indicator of the adaptation field.
+	Discontinuity bool
+	// RandomAccess is the random_access_indicator of the adaptation field.
+	RandomAccess bool
+	HasPCR       bool
+	// PCR is the program clock reference base in 90kHz units.
+	PCR int64
+	// PCRExtension is the 27MHz program_clock_reference_extension.
+	PCRExtension uint16
+	// Payload is the packet payload after the header and the adaptation field.
+	Payload []byte
+	// Raw is the whole 188 byte packet.
+	Raw []byte
+}
+
+// ParsePacket parses a 188 byte transport stream packet.
+// The returned Packet references b and does not copy it.
+func ParsePacket(b []byte) (Packet, error) {
+	packet := Packet{}
+	if len(b) != PacketSize || b[0] != SyncByte {
+		return packet, InvalidPacket
+	}
+
+	packet.Raw = b
+	packet.PayloadUnitStart = b[1]&0x40 != 0
+	packet.PID = uint16(b[1]&0x1F)<<8 | uint16(b[2])
+	packet.ContinuityCounter = b[3] & 0x0F
+	adaptationFieldControl := (b[3] >> 4) & 0x03
+
+	i := 4
+	if adaptationFieldControl&0x02 != 0 {
+		length := int(b[4])
+		i = 5 + length
+		if i > PacketSize {
+			return packet, InvalidPacket
+		}
+		if length > 0 {
+			flags := b[5]
+			packet.Discontinuity = flags&0x80 != 0
+			packet.RandomAccess = flags&0x40 != 0
+			if flags&0x10 != 0 && length >= 7 {
+				packet.HasPCR = true
+				packet.PCR = int64(b[6])<<25 | int64(b[7])<<17 | int64(b[8])<<9 | int64(b[9])<<1 | int64(b[10])>>7
+				packet.PCRExtension = uint16(b[10]&0x01)<<8 | uint16(b[11])
+			}
+		}
+	}
+	if adaptationFieldControl&0x01 != 0 {
+		packet.Payload = b[i:]
+	}
+	return packet, nil
+}
+
+// EncodePacket returns the 188 byte transport stream packet of packet. Offset and Raw are ignored.
+// A adaptation field is written if Discontinuity, RandomAccess or HasPCR is set and to stuff payloads shorter than 184 bytes.
+// EncodePacket returns InvalidPacket if the payload does not fit into the packet.
+func EncodePacket(packet Packet) ([]byte, error) {
+	b := make([]byte, 4, PacketSize)
+	b[0] = SyncByte
+	b[1] = byte(packet.PID>>8) & 0x1F
+	if packet.PayloadUnitStart {
+		b[1] |= 0x40
+	}
+	b[2] = byte(packet.PID)
+	b[3] = packet.ContinuityCounter & 0x0F
+
+	hasFlags := packet.Discontinuity || packet.RandomAccess || packet.HasPCR
+	if hasFlags || len(packet.Payload) < PacketSize-4 {
+		//adaptation_field_length excludes itself.
+		length := PacketSize - 4 - len(packet.Payload) - 1
+		if length < 0 || hasFlags && length < 1 || packet.HasPCR && length < 7 {
+			return nil, InvalidPacket
+		}
+		b[3] |= 0x20
+		b = append(b, byte(length))
+		if length > 0 {
+			var flags byte
+			if packet.Discontinuity {
+				flags |= 0x80
+			}
+			if packet.RandomAccess {
+				flags |= 0x40
+			}
+			if packet.HasPCR {
+				flags |= 0x10
+			}
+			b = append(b, flags)
+			if packet.HasPCR {
+				pcr := packet.PCR
+				b = append(b, byte(pcr>>25), byte(pcr>>17), byte(pcr>>9), byte(pcr>>1), byte(pcr<<7)|0x7E|byte(packet.PCRExtension>>8&0x01), byte(packet.PCRExtension))
+			}
+			for len(b) < 5+length {
+				b = append(b, 0xFF)
+			}
+		}
+	}
+	if len(packet.Payload) > 0 {
+		b[3] |= 0x10
+		b = append(b, packet.Payload...)
+	}
+	if len(b) != PacketSize {
+		return nil, InvalidPacket
+	}
+	return b, nil
+}
+
+// PacketReader reads transport stream packets from a io.Reader.
+type PacketReader struct {
+	rd     *bufio.Reader
+	offset int64
+	buf    [PacketSize]byte
+}
+
+// NewPacketReader returns a new PacketReader.
+func NewPacketReader(r io.Reader) PacketReader {
+	return PacketReader{
+		rd: bufio.NewReaderSize(r, PacketSize*256),
+	}
+}
+
+// Advance reads the next packet. Advance returns io.EOF when there are no more packets.
+// A stream that does not end on a packet boundary returns io.ErrUnexpectedEOF.
+// Packet.Raw and Packet.Payload are only valid until the next call to Advance.
+func (pr *PacketReader) Advance() (Packet, error) {
+	n, err := io.ReadFull(pr.rd, pr.buf[:])
+	if err != nil {
+		return Packet{}, err
+	} else if n != PacketSize {
+		return Packet{}, io.ErrUnexpectedEOF
+	}
+
+	packet, err := ParsePacket(pr.buf[:])
+	packet.Offset = pr.offset
+	pr.offset += PacketSize
+	return packet, err
+}
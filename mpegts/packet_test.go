@@ -0,0 +1,101 @@
+package mpegts_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestParsePacket(t *testing.T) {
+	{
+		if _, err := mpegts.ParsePacket(make([]byte, mpegts.PacketSize)); err != mpegts.InvalidPacket {
+			t.Fatal("Expected", mpegts.InvalidPacket, "but got", err)
+		}
+	}
+
+	{
+		if _, err := mpegts.ParsePacket([]byte{mpegts.SyncByte}); err != mpegts.InvalidPacket {
+			t.Fatal("Expected", mpegts.InvalidPacket, "but got", err)
+		}
+	}
+
+	{
+		//PID 0x100 with a adaptation field carrying a PCR and the random access indicator.
+		b := make([]byte, mpegts.PacketSize)
+		b[0] = mpegts.SyncByte
+		b[1] = 0x41
+		b[2] = 0x00
+		b[3] = 0x37
+		b[4] = 7
+		b[5] = 0x50
+		b[6], b[7], b[8], b[9], b[10] = 0x00, 0x00, 0x00, 0x01, 0x80
+
+		packet, err := mpegts.ParsePacket(b)
+		if err != nil {
+			t.Fatal(err)
+		} else if packet.PID != 0x100 {
+			t.Fatal("Expected PID 0x100 but got", packet.PID)
+		} else if !packet.PayloadUnitStart {
+			t.Fatal("Expected PayloadUnitStart to be true")
+		} else if packet.ContinuityCounter != 7 {
+			t.Fatal("Expected ContinuityCounter 7 but got", packet.ContinuityCounter)
+		} else if !packet.RandomAccess || packet.Discontinuity {
+			t.Fatal("Unexpected adaptation field flags", packet.RandomAccess, packet.Discontinuity)
+		} else if !packet.HasPCR || packet.PCR != 3 {
+			t.Fatal("Expected PCR 3 but got", packet.PCR)
+		} else if len(packet.Payload) != mpegts.PacketSize-12 {
+			t.Fatal("Expected payload length", mpegts.PacketSize-12, "but got", len(packet.Payload))
+		}
+	}
+}
+
+func TestPacketReader(t *testing.T) {
+	b := make([]byte, mpegts.PacketSize*2+10)
+	b[0] = mpegts.SyncByte
+	b[3] = 0x10
+	b[mpegts.PacketSize] = mpegts.SyncByte
+	b[mpegts.PacketSize+3] = 0x10
+
+	packetReader := mpegts.NewPacketReader(bytes.NewReader(b))
+	for i := range 2 {
+		packet, err := packetReader.Advance()
+		if err != nil {
+			t.Fatal(err)
+		} else if packet.Offset != int64(i*mpegts.PacketSize) {
+			t.Fatal("Expected offset", i*mpegts.PacketSize, "but got", packet.Offset)
+		}
+	}
+
+	if _, err := packetReader.Advance(); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected", io.ErrUnexpectedEOF, "but got", err)
+	}
+}
+
+func TestEncodePacket(t *testing.T) {
+	b, err := os.ReadFile("../examples/serving-a-video/video-fragments/seg000.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Every packet of the example uses only the adaptation field features of Packet so it is encoded unchanged.
+	for offset := 0; offset < len(b); offset += mpegts.PacketSize {
+		raw := b[offset : offset+mpegts.PacketSize]
+		packet, err := mpegts.ParsePacket(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := mpegts.EncodePacket(packet)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(encoded, raw) {
+			t.Fatal("Expected packet at offset", offset, "to be encoded unchanged\n", raw, "\n", encoded)
+		}
+	}
+
+	if _, err := mpegts.EncodePacket(mpegts.Packet{HasPCR: true, Payload: make([]byte, 180)}); err != mpegts.InvalidPacket {
+		t.Fatal("Expected", mpegts.InvalidPacket, "but got", err)
+	}
+}
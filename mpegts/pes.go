@@ -0,0 +1,122 @@
+package mpegts
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// ClockRate is the frequency of PTS and DTS timestamps.
+	ClockRate = 90000
+	// timestampWrap is the range of the 33 bit PTS and DTS timestamps.
+	timestampWrap = 1 << 33
+)
+
+var (
+	InvalidPESHeader error = errors.New("Invalid PES header")
+)
+
+// PESHeader is the header of a PES packet.
+type PESHeader struct {
+	StreamID uint8
+	// PacketLength is the PES_packet_length. 0 means the length is unbounded which is common for video streams.
+	PacketLength int
+	HasPTS       bool
+	HasDTS       bool
+	PTS          int64
+	DTS          int64
+	// Length is the number of bytes before the elementary stream data.
+	Length int
+}
+
+// hasOptionalHeader reports whether PES packets with streamID carry the optional PES header.
+func hasOptionalHeader(streamID uint8) bool {
+	switch streamID {
+	case 0xBC, 0xBE, 0xBF, 0xF0, 0xF1, 0xF2, 0xF8, 0xFF:
+		return false
+	}
+	return true
+}
+
+// ParsePESHeader parses the header at the beginning of a PES packet.
+func ParsePESHeader(b []byte) (PESHeader, error) {
+	header := PESHeader{}
+	if len(b) < 6 || b[0] != 0 || b[1] != 0 || b[2] != 1 {
+		return header, InvalidPESHeader
+	}
+
+	header.StreamID = b[3]
+	header.PacketLength = int(b[4])<<8 | int(b[5])
+	header.Length = 6
+	if !hasOptionalHeader(header.StreamID) {
+		return header, nil
+	}
+
+	if len(b) < 9 {
+		return header, InvalidPESHeader
+	}
+	header.Length = 9 + int(b[8])
+	if header.Length > len(b) {
+		return header, InvalidPESHeader
+	}
+
+	switch b[7] >> 6 {
+	case 0x02:
+		if header.Length < 14 {
+			return header, InvalidPESHeader
+		}
+		header.HasPTS = true
+		header.PTS = parseTimestamp(b[9:14])
+	case 0x03:
+		if header.Length < 19 {
+			return header, InvalidPESHeader
+		}
+		header.HasPTS = true
+		header.HasDTS = true
+		header.PTS = parseTimestamp(b[9:14])
+		header.DTS = parseTimestamp(b[14:19])
+	}
+	return header, nil
+}
+
+// parseTimestamp parses a 5 byte PTS or DTS field.
+func parseTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
+
+// unwrap returns timestamp unwrapped to be closest to previous which is already unwrapped.
+func unwrap(previous, timestamp int64) int64 {
+	timestamp += previous - previous%timestampWrap
+	if timestamp-previous > timestampWrap/2 {
+		timestamp -= timestampWrap
+	} else if previous-timestamp > timestampWrap/2 {
+		timestamp += timestampWrap
+	}
+	return timestamp
+}
+
+// ToDuration converts a 90kHz timestamp difference into a time.Duration.
+func ToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks) * time.Second / ClockRate
+}
+
+// PES is a complete PES packet of a elementary stream.
+type PES struct {
+	PID        uint16
+	StreamType StreamType
+	StreamID   uint8
+	// PTS and DTS are unwrapped timestamps in 90kHz units. DTS equals PTS if the PES header has no DTS.
+	PTS    int64
+	DTS    int64
+	HasPTS bool
+	// Keyframe is true for video PES packets that contain a IDR or IRAP picture and for every audio PES packet.
+	Keyframe bool
+	// Discontinuity is true if the discontinuity_indicator was set or if the DTS jumped by more than the discontinuity threshold from the previous PES packet of the same PID.
+	Discontinuity bool
+	// Offset is the byte offset of the first packet of the PES packet and End is the byte offset after its last packet.
+	// Packets of other PIDs may be interleaved between Offset and End.
+	Offset int64
+	End    int64
+	// Data is the elementary stream data after the PES header.
+	Data []byte
+}
@@ -0,0 +1,54 @@
+package mpegts_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestParsePESHeader(t *testing.T) {
+	{
+		if _, err := mpegts.ParsePESHeader([]byte{0, 0, 2, 0xE0, 0, 0}); err != mpegts.InvalidPESHeader {
+			t.Fatal("Expected", mpegts.InvalidPESHeader, "but got", err)
+		}
+	}
+
+	{
+		//Video PES header with PTS 900000 and DTS 897000.
+		b := []byte{0, 0, 1, 0xE0, 0, 0, 0x80, 0xC0, 10}
+		b = append(b, timestampBytes(0x3, 900000)...)
+		b = append(b, timestampBytes(0x1, 897000)...)
+		header, err := mpegts.ParsePESHeader(b)
+		if err != nil {
+			t.Fatal(err)
+		} else if header.StreamID != 0xE0 {
+			t.Fatal("Expected stream id 0xE0 but got", header.StreamID)
+		} else if !header.HasPTS || !header.HasDTS {
+			t.Fatal("Expected PTS and DTS")
+		} else if header.PTS != 900000 {
+			t.Fatal("Expected PTS 900000 but got", header.PTS)
+		} else if header.DTS != 897000 {
+			t.Fatal("Expected DTS 897000 but got", header.DTS)
+		} else if header.Length != 19 {
+			t.Fatal("Expected header length 19 but got", header.Length)
+		}
+	}
+}
+
+// timestampBytes encodes a 33 bit PTS or DTS field with a 4 bit prefix.
+func timestampBytes(prefix byte, ts int64) []byte {
+	return []byte{
+		prefix<<4 | byte(ts>>29)&0x0E | 1,
+		byte(ts >> 22),
+		byte(ts>>14) | 1,
+		byte(ts >> 7),
+		byte(ts<<1) | 1,
+	}
+}
+
+func TestToDuration(t *testing.T) {
+	if d := mpegts.ToDuration(mpegts.ClockRate * 3 / 2); d != 1500*time.Millisecond {
+		t.Fatal("Expected 1.5s but got", d)
+	}
+}
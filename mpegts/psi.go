@@ -0,0 +1,272 @@
+package mpegts
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StreamType is the stream_type of a elementary stream as written in the PMT.
+type StreamType uint8
+
+const (
+	MPEG1Video StreamType = 0x01
+	MPEG2Video StreamType = 0x02
+	MPEG1Audio StreamType = 0x03
+	MPEG2Audio StreamType = 0x04
+	// AAC is AAC audio with ADTS transport syntax.
+	AAC StreamType = 0x0F
+	// Metadata is timed metadata (ID3) carried in PES packets.
+	Metadata StreamType = 0x15
+	H264     StreamType = 0x1B
+	H265     StreamType = 0x24
+	AC3      StreamType = 0x81
+	EAC3     StreamType = 0x87
+
+	// SAMPLE-AES encrypted stream types of the MPEG-2 Stream Encryption Format for HTTP Live Streaming.
+	SampleAESH264 StreamType = 0xDB
+	SampleAESAAC  StreamType = 0xCF
+	SampleAESAC3  StreamType = 0xC1
+	SampleAESEAC3 StreamType = 0xC2
+)
+
+// IsVideo reports whether st is a video stream type.
+func (st StreamType) IsVideo() bool {
+	switch st {
+	case MPEG1Video, MPEG2Video, H264, H265, SampleAESH264:
+		return true
+	}
+	return false
+}
+
+// IsAudio reports whether st is a audio stream type.
+func (st StreamType) IsAudio() bool {
+	switch st {
+	case MPEG1Audio, MPEG2Audio, AAC, AC3, EAC3, SampleAESAAC, SampleAESAC3, SampleAESEAC3:
+		return true
+	}
+	return false
+}
+
+// String returns the name of the stream type.
+//
+//	H264.String() == "H.264"
+func (st StreamType) String() string {
+	switch st {
+	case MPEG1Video:
+		return "MPEG-1 Video"
+	case MPEG2Video:
+		return "MPEG-2 Video"
+	case MPEG1Audio:
+		return "MPEG-1 Audio"
+	case MPEG2Audio:
+		return "MPEG-2 Audio"
+	case AAC:
+		return "AAC"
+	case Metadata:
+		return "Metadata"
+	case H264:
+		return "H.264"
+	case H265:
+		return "H.265"
+	case AC3:
+		return "AC-3"
+	case EAC3:
+		return "E-AC-3"
+	case SampleAESH264:
+		return "SAMPLE-AES H.264"
+	case SampleAESAAC:
+		return "SAMPLE-AES AAC"
+	case SampleAESAC3:
+		return "SAMPLE-AES AC-3"
+	case SampleAESEAC3:
+		return "SAMPLE-AES E-AC-3"
+	}
+	return fmt.Sprintf("Unknown StreamType 0x%02X", uint8(st))
+}
+
+// ElementaryStream is a stream listed in the PMT.
+type ElementaryStream struct {
+	PID        uint16
+	StreamType StreamType
+	// Descriptors is the raw ES_info descriptor loop.
+	Descriptors []byte
+}
+
+// Program is a entry of the PAT.
+type Program struct {
+	Number uint16
+	PMTPID uint16
+}
+
+// PMT is a program map table.
+type PMT struct {
+	ProgramNumber uint16
+	// Version is the version_number of the section.
+	Version uint8
+	PCRPID  uint16
+	// ProgramInfo is the raw program_info descriptor loop.
+	ProgramInfo []byte
+	Streams     []ElementaryStream
+}
+
+var (
+	InvalidSection error = errors.New("Invalid PSI section")
+)
+
+const (
+	patTableID = 0x00
+	pmtTableID = 0x02
+)
+
+// section returns the PSI section that starts in the payload of a packet with payload_unit_start_indicator set.
+// Sections spanning more than one packet are not supported.
+func section(payload []byte, tableID byte) ([]byte, error) {
+	if len(payload) < 1 {
+		return nil, InvalidSection
+	}
+	pointer := int(payload[0])
+	if 1+pointer+3 > len(payload) {
+		return nil, InvalidSection
+	}
+	s := payload[1+pointer:]
+	length := int(s[1]&0x0F)<<8 | int(s[2])
+	if s[0] != tableID || length < 9 || 3+length > len(s) {
+		return nil, InvalidSection
+	}
+	s = s[:3+length]
+	if crc32MPEG(s) != 0 {
+		return nil, InvalidSection
+	}
+	return s, nil
+}
+
+// ParsePAT parses the program association table that starts in payload.
+// payload is the payload of a packet on PID 0 with payload_unit_start_indicator set.
+// The network PID entry (program number 0) is not returned.
+func ParsePAT(payload []byte) ([]Program, error) {
+	s, err := section(payload, patTableID)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make([]Program, 0, 1)
+	for i := 8; i+4 <= len(s)-4; i += 4 {
+		number := uint16(s[i])<<8 | uint16(s[i+1])
+		pid := uint16(s[i+2]&0x1F)<<8 | uint16(s[i+3])
+		if number == 0 {
+			continue
+		}
+		programs = append(programs, Program{
+			Number: number,
+			PMTPID: pid,
+		})
+	}
+	return programs, nil
+}
+
+// ParsePMT parses the program map table that starts in payload.
+// payload is the payload of a packet on a PMT PID with payload_unit_start_indicator set.
+func ParsePMT(payload []byte) (PMT, error) {
+	pmt := PMT{}
+	s, err := section(payload, pmtTableID)
+	if err != nil {
+		return pmt, err
+	} else if len(s) < 16 {
+		return pmt, InvalidSection
+	}
+
+	pmt.ProgramNumber = uint16(s[3])<<8 | uint16(s[4])
+	pmt.Version = s[5] >> 1 & 0x1F
+	pmt.PCRPID = uint16(s[8]&0x1F)<<8 | uint16(s[9])
+	programInfoLength := int(s[10]&0x0F)<<8 | int(s[11])
+
+	end := len(s) - 4
+	i := 12 + programInfoLength
+	if i > end {
+		return pmt, InvalidSection
+	}
+	pmt.ProgramInfo = append([]byte(nil), s[12:i]...)
+	for i+5 <= end {
+		esInfoLength := int(s[i+3]&0x0F)<<8 | int(s[i+4])
+		if i+5+esInfoLength > end {
+			return pmt, InvalidSection
+		}
+		pmt.Streams = append(pmt.Streams, ElementaryStream{
+			StreamType:  StreamType(s[i]),
+			PID:         uint16(s[i+1]&0x1F)<<8 | uint16(s[i+2]),
+			Descriptors: append([]byte(nil), s[i+5:i+5+esInfoLength]...),
+		})
+		i += 5 + esInfoLength
+	}
+	return pmt, nil
+}
+
+// EncodePMT returns the program map table section of pmt including its CRC_32.
+// The section can be written to a packet with EncodeSection.
+func EncodePMT(pmt PMT) []byte {
+	s := []byte{
+		pmtTableID, 0, 0,
+		byte(pmt.ProgramNumber >> 8), byte(pmt.ProgramNumber),
+		0xC1 | pmt.Version&0x1F<<1, 0, 0,
+		0xE0 | byte(pmt.PCRPID>>8), byte(pmt.PCRPID),
+		0xF0 | byte(len(pmt.ProgramInfo)>>8), byte(len(pmt.ProgramInfo)),
+	}
+	s = append(s, pmt.ProgramInfo...)
+	for _, stream := range pmt.Streams {
+		s = append(s,
+			byte(stream.StreamType),
+			0xE0|byte(stream.PID>>8), byte(stream.PID),
+			0xF0|byte(len(stream.Descriptors)>>8), byte(len(stream.Descriptors)),
+		)
+		s = append(s, stream.Descriptors...)
+	}
+
+	//section_length counts the bytes after it including the CRC_32.
+	length := len(s) - 3 + 4
+	s[1] = 0xB0 | byte(length>>8)
+	s[2] = byte(length)
+	crc := crc32MPEG(s)
+	return append(s, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+// EncodeSection returns the payload of a packet with payload_unit_start_indicator set that carries section.
+// The payload starts with a pointer_field of 0 and is padded with 0xFF stuffing bytes.
+// EncodeSection returns InvalidSection if the section does not fit into a single packet.
+func EncodeSection(section []byte) ([]byte, error) {
+	if 1+len(section) > PacketSize-4 {
+		return nil, InvalidSection
+	}
+	payload := make([]byte, PacketSize-4)
+	payload[0] = 0
+	copy(payload[1:], section)
+	for i := 1 + len(section); i < len(payload); i++ {
+		payload[i] = 0xFF
+	}
+	return payload, nil
+}
+
+var crcTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc32MPEG returns the CRC_32 of b as used in PSI sections.
+// The CRC of a section including its CRC_32 field is 0.
+func crc32MPEG(b []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, v := range b {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^v]
+	}
+	return crc
+}
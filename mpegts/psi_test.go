@@ -0,0 +1,96 @@
+package mpegts_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestParsePATAndPMT(t *testing.T) {
+	f, err := os.Open("../examples/serving-a-video/video-fragments/seg000.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	packetReader := mpegts.NewPacketReader(f)
+	var pmtPID uint16
+	for {
+		packet, err := packetReader.Advance()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if packet.PID == 0 {
+			programs, err := mpegts.ParsePAT(packet.Payload)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(programs) != 1 || programs[0].PMTPID != 0x1000 {
+				t.Fatal("Unexpected programs", programs)
+			}
+			pmtPID = programs[0].PMTPID
+		} else if pmtPID != 0 && packet.PID == pmtPID {
+			pmt, err := mpegts.ParsePMT(packet.Payload)
+			if err != nil {
+				t.Fatal(err)
+			} else if pmt.PCRPID != 0x101 {
+				t.Fatal("Expected PCR PID 0x101 but got", pmt.PCRPID)
+			} else if len(pmt.Streams) != 2 {
+				t.Fatal("Expected 2 streams but got", len(pmt.Streams))
+			} else if pmt.Streams[0].StreamType != mpegts.AAC || pmt.Streams[1].StreamType != mpegts.H264 {
+				t.Fatal("Unexpected stream types", pmt.Streams[0].StreamType.String(), pmt.Streams[1].StreamType.String())
+			}
+
+			//Corrupting the section must fail the CRC check.
+			payload := append([]byte(nil), packet.Payload...)
+			payload[10] ^= 0xFF
+			if _, err := mpegts.ParsePMT(payload); err != mpegts.InvalidSection {
+				t.Fatal("Expected", mpegts.InvalidSection, "but got", err)
+			}
+			return
+		}
+	}
+}
+
+func TestEncodePMT(t *testing.T) {
+	b, err := os.ReadFile("../examples/serving-a-video/video-fragments/seg000.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The third packet of the example carries the PMT.
+	packet, err := mpegts.ParsePacket(b[2*mpegts.PacketSize : 3*mpegts.PacketSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pmt, err := mpegts.ParsePMT(packet.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := mpegts.EncodeSection(mpegts.EncodePMT(pmt))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(payload, packet.Payload) {
+		t.Fatal("Expected the PMT to be encoded unchanged\n", packet.Payload, "\n", payload)
+	}
+
+	pmt.Streams[0].StreamType = mpegts.SampleAESH264
+	pmt.Streams[0].Descriptors = []byte{0x0F, 4, 'z', 'a', 'v', 'c'}
+	payload, err = mpegts.EncodeSection(mpegts.EncodePMT(pmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := mpegts.ParsePMT(payload)
+	if err != nil {
+		t.Fatal(err)
+	} else if decoded.Streams[0].StreamType != mpegts.SampleAESH264 || !bytes.Equal(decoded.Streams[0].Descriptors, pmt.Streams[0].Descriptors) {
+		t.Fatal("Unexpected stream", decoded.Streams[0])
+	}
+
+	if _, err := mpegts.EncodeSection(make([]byte, mpegts.PacketSize)); err != mpegts.InvalidSection {
+		t.Fatal("Expected", mpegts.InvalidSection, "but got", err)
+	}
+}
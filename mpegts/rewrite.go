@@ -0,0 +1,215 @@
+package mpegts
+
+// Rewriter rewrites the PMT and the elementary stream data of a transport stream segment.
+// Packets are kept in place. A rewritten PES packet is written to the packets of the original PES packet so PCRs and
+// random access indicators stay where they were. If the new PES packet is longer, packets are inserted after its last packet.
+type Rewriter struct {
+	// PMT returns the PMT written instead of pmt. PMT can be nil.
+	PMT func(pmt PMT) (PMT, error)
+	// Data returns the new elementary stream data of a PES packet of stream. stream is the stream of the original PMT. Data can be nil.
+	Data func(stream ElementaryStream, data []byte) ([]byte, error)
+}
+
+// pesGroup is a PES packet and the indexes of the packets carrying it.
+type pesGroup struct {
+	stream ElementaryStream
+	slots  []int
+	data   []byte
+	// packets maps the slots to the encoded packets of the rewritten PES packet written in their place.
+	packets map[int][][]byte
+}
+
+// Rewrite returns the rewritten segment. The PSI sections must fit into a single packet.
+// Packets of a PES packet that started before the segment are not rewritten.
+func (rw *Rewriter) Rewrite(segment []byte) ([]byte, error) {
+	if len(segment)%PacketSize != 0 {
+		return nil, InvalidPacket
+	}
+	packets := make([]Packet, 0, len(segment)/PacketSize)
+	for offset := 0; offset < len(segment); offset += PacketSize {
+		packet, err := ParsePacket(segment[offset : offset+PacketSize])
+		if err != nil {
+			return nil, err
+		}
+		packet.Offset = int64(offset)
+		packets = append(packets, packet)
+	}
+
+	pmtPIDs := make(map[uint16]bool)
+	streams := make(map[uint16]ElementaryStream)
+	pmtPayloads := make(map[int][]byte)
+	groups := make([]*pesGroup, 0)
+	pending := make(map[uint16]*pesGroup)
+	slots := make(map[int]*pesGroup)
+	for i, packet := range packets {
+		switch {
+		case packet.PID == 0 && packet.PayloadUnitStart:
+			programs, err := ParsePAT(packet.Payload)
+			if err != nil {
+				return nil, err
+			}
+			clear(pmtPIDs)
+			for _, program := range programs {
+				pmtPIDs[program.PMTPID] = true
+			}
+		case pmtPIDs[packet.PID] && packet.PayloadUnitStart:
+			pmt, err := ParsePMT(packet.Payload)
+			if err != nil {
+				return nil, err
+			}
+			clear(streams)
+			for _, stream := range pmt.Streams {
+				streams[stream.PID] = stream
+			}
+			if rw.PMT != nil {
+				if pmt, err = rw.PMT(pmt); err != nil {
+					return nil, err
+				}
+				if pmtPayloads[i], err = EncodeSection(EncodePMT(pmt)); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			stream, ok := streams[packet.PID]
+			if !ok {
+				continue
+			}
+			group := pending[packet.PID]
+			if packet.PayloadUnitStart {
+				group = &pesGroup{stream: stream}
+				groups = append(groups, group)
+				pending[packet.PID] = group
+			} else if group == nil {
+				continue
+			}
+			group.slots = append(group.slots, i)
+			group.data = append(group.data, packet.Payload...)
+			slots[i] = group
+		}
+	}
+
+	for _, group := range groups {
+		if err := rw.rewritePES(group, packets); err != nil {
+			return nil, err
+		}
+	}
+
+	//Continuity counters of rewritten PIDs are renumbered because packets can be inserted or removed.
+	counters := make(map[uint16]uint8)
+	rewritten := make([]byte, 0, len(segment)+len(segment)/16)
+	for i, packet := range packets {
+		if payload, ok := pmtPayloads[i]; ok {
+			packet.Payload = payload
+			b, err := EncodePacket(packet)
+			if err != nil {
+				return nil, err
+			}
+			rewritten = append(rewritten, b...)
+			continue
+		}
+
+		group, ok := slots[i]
+		if !ok {
+			rewritten = append(rewritten, packet.Raw...)
+			continue
+		}
+		if _, ok := counters[packet.PID]; !ok {
+			counters[packet.PID] = packet.ContinuityCounter
+		}
+		for _, b := range group.packets[i] {
+			//Packets without payload do not increment the continuity counter.
+			if b[3]&0x10 != 0 {
+				b[3] = b[3]&0xF0 | counters[packet.PID]&0x0F
+				counters[packet.PID]++
+			} else {
+				b[3] = b[3]&0xF0 | (counters[packet.PID]-1)&0x0F
+			}
+			rewritten = append(rewritten, b...)
+		}
+	}
+	return rewritten, nil
+}
+
+// rewritePES rewrites the elementary stream data of group and packetizes it into group.packets.
+// Every slot gets a packet. The last slot gets the remaining data which can span multiple packets.
+func (rw *Rewriter) rewritePES(group *pesGroup, packets []Packet) error {
+	data := group.data
+	header, err := ParsePESHeader(data)
+	if err != nil {
+		return err
+	}
+	if header.PacketLength > 0 && 6+header.PacketLength < len(data) {
+		data = data[:6+header.PacketLength]
+	}
+
+	es := data[header.Length:]
+	if rw.Data != nil {
+		if es, err = rw.Data(group.stream, es); err != nil {
+			return err
+		}
+	}
+	pes := append(append([]byte(nil), data[:header.Length]...), es...)
+	if header.PacketLength > 0 {
+		length := len(pes) - 6
+		if length > 0xFFFF {
+			//Only video PES packets can have a unbounded length.
+			if !group.stream.StreamType.IsVideo() {
+				return InvalidPESHeader
+			}
+			length = 0
+		}
+		pes[4], pes[5] = byte(length>>8), byte(length)
+	}
+
+	group.packets = make(map[int][][]byte, len(group.slots))
+	for i, slot := range group.slots {
+		packet := packets[slot]
+		last := i == len(group.slots)-1
+		n := len(packet.Payload)
+		if last {
+			n = PacketSize - 4
+			if packet.Discontinuity || packet.RandomAccess || packet.HasPCR {
+				n -= 2
+			}
+			if packet.HasPCR {
+				n -= 6
+			}
+		}
+		n = min(n, len(pes))
+
+		p := Packet{
+			PID:              packet.PID,
+			PayloadUnitStart: i == 0,
+			Discontinuity:    packet.Discontinuity,
+			RandomAccess:     packet.RandomAccess,
+			HasPCR:           packet.HasPCR,
+			PCR:              packet.PCR,
+			PCRExtension:     packet.PCRExtension,
+			Payload:          pes[:n],
+		}
+		pes = pes[n:]
+		//Empty slots are dropped unless they carry adaptation field data.
+		if n > 0 || p.Discontinuity || p.RandomAccess || p.HasPCR {
+			if err := group.appendPacket(slot, p); err != nil {
+				return err
+			}
+		}
+		for last && len(pes) > 0 {
+			n := min(PacketSize-4, len(pes))
+			if err := group.appendPacket(slot, Packet{PID: packet.PID, Payload: pes[:n]}); err != nil {
+				return err
+			}
+			pes = pes[n:]
+		}
+	}
+	return nil
+}
+
+func (group *pesGroup) appendPacket(slot int, packet Packet) error {
+	b, err := EncodePacket(packet)
+	if err != nil {
+		return err
+	}
+	group.packets[slot] = append(group.packets[slot], b)
+	return nil
+}
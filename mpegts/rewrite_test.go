@@ -0,0 +1,122 @@
+package mpegts_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// demuxAll returns the PES packets of a segment.
+func demuxAll(t *testing.T, segment []byte) []mpegts.PES {
+	t.Helper()
+	demuxer := mpegts.NewDemuxer()
+	packetReader := mpegts.NewPacketReader(bytes.NewReader(segment))
+	pesPackets := make([]mpegts.PES, 0)
+	for {
+		packet, err := packetReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		completed, err := demuxer.Push(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pes := range completed {
+			pes.Data = bytes.Clone(pes.Data)
+			pesPackets = append(pesPackets, pes)
+		}
+	}
+	completed, err := demuxer.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(pesPackets, completed...)
+}
+
+func TestRewriter(t *testing.T) {
+	segment, err := os.ReadFile("../examples/serving-a-video/video-fragments/seg001.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		rewriter := mpegts.Rewriter{}
+		rewritten, err := rewriter.Rewrite(segment)
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(rewritten, segment) {
+			t.Fatal("Expected the segment to be unchanged")
+		}
+	}
+
+	{
+		//Video PES packets grow and audio PES packets shrink.
+		rewriter := mpegts.Rewriter{
+			PMT: func(pmt mpegts.PMT) (mpegts.PMT, error) {
+				pmt.Streams[0].Descriptors = append(pmt.Streams[0].Descriptors, 0x0F, 4, 't', 'e', 's', 't')
+				return pmt, nil
+			},
+			Data: func(stream mpegts.ElementaryStream, data []byte) ([]byte, error) {
+				if stream.StreamType.IsVideo() {
+					return append(bytes.Clone(data), make([]byte, 1000)...), nil
+				}
+				return data[:len(data)/2], nil
+			},
+		}
+		rewritten, err := rewriter.Rewrite(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		original := demuxAll(t, segment)
+		pesPackets := demuxAll(t, rewritten)
+		if len(pesPackets) != len(original) {
+			t.Fatal("Expected", len(original), "PES packets but got", len(pesPackets))
+		}
+		for i, pes := range pesPackets {
+			expected := len(original[i].Data) / 2
+			if pes.StreamType.IsVideo() {
+				expected = len(original[i].Data) + 1000
+			}
+			if len(pes.Data) != expected {
+				t.Fatal("Expected", expected, "bytes of PES data but got", len(pes.Data))
+			} else if pes.PTS != original[i].PTS || pes.Keyframe != original[i].Keyframe {
+				t.Fatal("Expected the PES header to be unchanged")
+			}
+		}
+
+		originalInfo, err := mpegts.Inspect(bytes.NewReader(segment))
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := mpegts.Inspect(bytes.NewReader(rewritten))
+		if err != nil {
+			t.Fatal(err)
+		} else if info.Duration != originalInfo.Duration || len(info.Keyframes) != len(originalInfo.Keyframes) {
+			t.Fatal("Expected the timing of the segment to be unchanged")
+		}
+		if !bytes.Contains(rewritten[:3*mpegts.PacketSize], []byte("test")) {
+			t.Fatal("Expected the rewritten PMT")
+		}
+
+		//Continuity counters of every PID must be continuous.
+		counters := make(map[uint16]uint8)
+		for offset := 0; offset < len(rewritten); offset += mpegts.PacketSize {
+			packet, err := mpegts.ParsePacket(rewritten[offset : offset+mpegts.PacketSize])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if previous, ok := counters[packet.PID]; ok && len(packet.Payload) > 0 && packet.ContinuityCounter != (previous+1)&0x0F {
+				t.Fatal("Expected continuity counter", (previous+1)&0x0F, "but got", packet.ContinuityCounter, "for PID", packet.PID)
+			}
+			if len(packet.Payload) > 0 {
+				counters[packet.PID] = packet.ContinuityCounter
+			}
+		}
+	}
+}
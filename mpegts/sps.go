@@ -0,0 +1,164 @@
+package mpegts
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	InvalidSPS error = errors.New("Invalid H.264 sequence parameter set")
+)
+
+// SPS is the part of a H.264 sequence parameter set that describes the codec and the picture size.
+type SPS struct {
+	ProfileIDC uint8
+	// ConstraintFlags are the constraint_set flags and the reserved bits of the byte after profile_idc.
+	ConstraintFlags uint8
+	LevelIDC        uint8
+	// Width and Height are the cropped picture size in pixels.
+	Width  int
+	Height int
+}
+
+// Codec returns the RFC 6381 codec string of the SPS which is used in the CODECS attribute. e.g. avc1.64001f
+func (sps *SPS) Codec() string {
+	return fmt.Sprintf("avc1.%02x%02x%02x", sps.ProfileIDC, sps.ConstraintFlags, sps.LevelIDC)
+}
+
+// ParseSPS parses a H.264 SPS NAL unit. nal must not contain a start code.
+func ParseSPS(nal []byte) (SPS, error) {
+	sps := SPS{}
+	if H264NALType(nal) != H264SPS || len(nal) < 4 {
+		return sps, InvalidSPS
+	}
+	sps.ProfileIDC = nal[1]
+	sps.ConstraintFlags = nal[2]
+	sps.LevelIDC = nal[3]
+
+	br := bitReader{b: UnescapeRBSP(nal[4:])}
+	br.ue() //seq_parameter_set_id
+
+	chromaFormatIDC := uint64(1)
+	switch sps.ProfileIDC {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chromaFormatIDC = br.ue()
+		if chromaFormatIDC == 3 {
+			br.bits(1) //separate_colour_plane_flag
+		}
+		br.ue()    //bit_depth_luma_minus8
+		br.ue()    //bit_depth_chroma_minus8
+		br.bits(1) //qpprime_y_zero_transform_bypass_flag
+		if br.bits(1) == 1 {
+			count := 8
+			if chromaFormatIDC == 3 {
+				count = 12
+			}
+			for i := range count {
+				if br.bits(1) == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				skipScalingList(&br, size)
+			}
+		}
+	}
+
+	br.ue() //log2_max_frame_num_minus4
+	switch br.ue() {
+	case 0:
+		br.ue() //log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		br.bits(1) //delta_pic_order_always_zero_flag
+		br.se()    //offset_for_non_ref_pic
+		br.se()    //offset_for_top_to_bottom_field
+		for range br.ue() {
+			br.se()
+		}
+	}
+	br.ue()    //max_num_ref_frames
+	br.bits(1) //gaps_in_frame_num_value_allowed_flag
+	widthInMbs := br.ue() + 1
+	heightInMapUnits := br.ue() + 1
+	frameMbsOnly := br.bits(1)
+	if frameMbsOnly == 0 {
+		br.bits(1) //mb_adaptive_frame_field_flag
+	}
+	br.bits(1) //direct_8x8_inference_flag
+
+	var cropLeft, cropRight, cropTop, cropBottom uint64
+	if br.bits(1) == 1 {
+		cropLeft, cropRight, cropTop, cropBottom = br.ue(), br.ue(), br.ue(), br.ue()
+	}
+	if br.err != nil {
+		return sps, InvalidSPS
+	}
+
+	//The crop units depend on the chroma subsampling.
+	cropUnitX, cropUnitY := uint64(1), 2-frameMbsOnly
+	switch chromaFormatIDC {
+	case 1:
+		cropUnitX, cropUnitY = 2, 2*(2-frameMbsOnly)
+	case 2:
+		cropUnitX = 2
+	}
+	sps.Width = int(widthInMbs*16 - cropUnitX*(cropLeft+cropRight))
+	sps.Height = int((2-frameMbsOnly)*heightInMapUnits*16 - cropUnitY*(cropTop+cropBottom))
+	return sps, nil
+}
+
+func skipScalingList(br *bitReader, size int) {
+	last, next := int64(8), int64(8)
+	for range size {
+		if next != 0 {
+			next = (last + br.se() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+// bitReader reads bits and Exp-Golomb codes. err is set when reading past the end of b.
+type bitReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (br *bitReader) bits(n int) uint64 {
+	var v uint64
+	for range n {
+		if br.pos >= len(br.b)*8 {
+			br.err = InvalidSPS
+			return 0
+		}
+		v = v<<1 | uint64(br.b[br.pos/8]>>(7-br.pos%8)&1)
+		br.pos++
+	}
+	return v
+}
+
+// ue reads a unsigned Exp-Golomb code.
+func (br *bitReader) ue() uint64 {
+	zeros := 0
+	for br.bits(1) == 0 {
+		if br.err != nil || zeros == 32 {
+			br.err = InvalidSPS
+			return 0
+		}
+		zeros++
+	}
+	return 1<<zeros - 1 + br.bits(zeros)
+}
+
+// se reads a signed Exp-Golomb code.
+func (br *bitReader) se() int64 {
+	v := br.ue()
+	if v%2 == 0 {
+		return -int64(v / 2)
+	}
+	return int64(v+1) / 2
+}
@@ -0,0 +1,24 @@
+package mpegts_test
+
+import (
+	"testing"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+func TestParseSPS(t *testing.T) {
+	//High profile level 3.1 1280x720 with emulation prevention bytes.
+	nal := []byte{0x67, 0x64, 0x00, 0x1F, 0xAC, 0xD9, 0x40, 0x50, 0x05, 0xBB, 0x01, 0x10, 0x00, 0x00, 0x03, 0x00, 0x10, 0x00, 0x00, 0x03, 0x03, 0xC0, 0xF1, 0x83, 0x19, 0x60}
+	sps, err := mpegts.ParseSPS(nal)
+	if err != nil {
+		t.Fatal(err)
+	} else if sps.Width != 1280 || sps.Height != 720 {
+		t.Fatal("Expected 1280x720 but got", sps.Width, sps.Height)
+	} else if sps.Codec() != "avc1.64001f" {
+		t.Fatal("Expected avc1.64001f but got", sps.Codec())
+	}
+
+	if _, err := mpegts.ParseSPS(nal[:5]); err != mpegts.InvalidSPS {
+		t.Fatal("Expected", mpegts.InvalidSPS, "but got", err)
+	}
+}
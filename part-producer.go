@@ -0,0 +1,212 @@
+package HLS
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/udan-jayanith/HLS/fmp4"
+)
+
+// PartProducer cuts a fragmented MP4 stream of CMAF chunks into the Partial Segments and Media Segments of a
+// Low-Latency HLS LiveMediaPlaylist.
+// CMAF chunks are collected into a Partial Segment until the next chunk would exceed the PART-TARGET of the playlist.
+// A Partial Segment is INDEPENDENT=YES if it starts with a sync sample. Segments are cut at the first chunk starting with
+// a sync sample that does not fit into the target duration anymore. A complete segment is written as the concatenation
+// of its Partial Segments and replaces them in the playlist. The playlist removes the Partial Segments of older segments.
+type PartProducer struct {
+	Playlist *LiveMediaPlaylist
+	Writer   SegmentWriter
+	// InitName is the name of the Media Initialization Section (EXT-X-MAP). It is init.mp4 by default.
+	InitName string
+	// SegmentName returns the name of the segment with the media sequence number.
+	// If SegmentName is nil segments are named seg000.m4s, seg001.m4s and so on.
+	SegmentName func(sequenceNumber uint64) string
+	// PartName returns the name of a Partial Segment of the segment with the media sequence number.
+	// If PartName is nil parts are named seg000.0.m4s, seg000.1.m4s and so on.
+	PartName func(sequenceNumber uint64, part int) string
+}
+
+// NewPartProducer returns a new PartProducer that writes to w and adds the parts and segments to playlist.
+// playlist.PartInf must be set.
+func NewPartProducer(playlist *LiveMediaPlaylist, w SegmentWriter) PartProducer {
+	return PartProducer{
+		Playlist: playlist,
+		Writer:   w,
+		InitName: "init.mp4",
+	}
+}
+
+func (pp *PartProducer) segmentName(sequenceNumber uint64) string {
+	if pp.SegmentName != nil {
+		return pp.SegmentName(sequenceNumber)
+	}
+	return fmt.Sprintf("seg%03d.m4s", sequenceNumber)
+}
+
+func (pp *PartProducer) partName(sequenceNumber uint64, part int) string {
+	if pp.PartName != nil {
+		return pp.PartName(sequenceNumber, part)
+	}
+	return fmt.Sprintf("seg%03d.%d.m4s", sequenceNumber, part)
+}
+
+// initRecorder records the bytes read before the Media Initialization Section is known.
+type initRecorder struct {
+	buf  bytes.Buffer
+	done bool
+}
+
+func (ir *initRecorder) Write(p []byte) (int, error) {
+	if !ir.done {
+		ir.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// Produce reads a fragmented MP4 stream starting with the moov box from r until io.EOF. The Media Initialization Section
+// is written first. Every Partial Segment is written and added to the playlist as soon as it is complete together with
+// a EXT-X-PRELOAD-HINT of the next part, which is the first part of the next segment once the segment reached the
+// target duration. The hint is replaced when a segment is cut. The last segment is added at io.EOF, the playlist is not ended.
+// Produce returns MissingPartInf if the playlist has no PartInf and InvalidPartDuration if a chunk is longer than the
+// PART-TARGET.
+func (pp *PartProducer) Produce(r io.Reader) error {
+	if pp.Playlist.PartInf == nil {
+		return MissingPartInf
+	}
+	recorder := &initRecorder{}
+	fragmentReader := fmp4.NewFragmentReader(io.TeeReader(r, recorder), fmp4.InitSegment{})
+	nextSequence, _ := pp.Playlist.NextPosition()
+
+	var initSection *MediaInitializationSection
+	var trackID, timescale uint32
+	var partTarget, target uint64
+
+	var segment, part bytes.Buffer
+	var segmentDuration, partDuration uint64
+	var partIndependent bool
+	parts := 0
+
+	partHint := func(sequenceNumber uint64, part int) PreloadHint {
+		return PreloadHint{Type: PreloadHintPart, URI: pp.partName(sequenceNumber, part)}
+	}
+	//flushPart publishes the part together with the hints of what follows it.
+	flushPart := func(hints ...PreloadHint) error {
+		if part.Len() == 0 {
+			return nil
+		}
+		name := pp.partName(nextSequence, parts)
+		if err := pp.Writer.Put(name, bytes.NewReader(part.Bytes())); err != nil {
+			return err
+		}
+		err := pp.Playlist.AddPartWithHints(Part{
+			URI:         name,
+			Duration:    roundSeconds(float64(partDuration) / float64(timescale)),
+			Independent: partIndependent,
+		}, hints...)
+		if err != nil {
+			return err
+		}
+		segment.Write(part.Bytes())
+		part.Reset()
+		segmentDuration += partDuration
+		partDuration = 0
+		parts++
+		return nil
+	}
+	//nextPartHint returns the hint of the next part of the current segment or of the next segment if the current
+	//segment already reached the target duration.
+	nextPartHint := func() PreloadHint {
+		if segmentDuration+partDuration >= target {
+			return partHint(nextSequence+1, 0)
+		}
+		return partHint(nextSequence, parts+1)
+	}
+	//flushSegment publishes the segment together with hints. The pending part is published with the same hints
+	//because the segment ends with it.
+	flushSegment := func(hints ...PreloadHint) error {
+		if err := flushPart(hints...); err != nil {
+			return err
+		} else if segment.Len() == 0 {
+			return nil
+		}
+		name := pp.segmentName(nextSequence)
+		if err := pp.Writer.Put(name, bytes.NewReader(segment.Bytes())); err != nil {
+			return err
+		}
+		err := pp.Playlist.AddSegmentWithHints(MediaSegment{
+			URI:      name,
+			Duration: roundSeconds(float64(segmentDuration) / float64(timescale)),
+			Map:      initSection,
+		}, hints...)
+		if err != nil {
+			return err
+		}
+		segment.Reset()
+		segmentDuration = 0
+		nextSequence++
+		parts = 0
+		return nil
+	}
+
+	for {
+		fragment, err := fragmentReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if initSection == nil {
+			initSegment := fragmentReader.InitSegment()
+			if initSegment.Size == 0 {
+				return fmp4.MissingMovieBox
+			}
+			recorder.done = true
+			if err := pp.Writer.Put(pp.InitName, bytes.NewReader(recorder.buf.Bytes()[:initSegment.Size])); err != nil {
+				return err
+			}
+			initSection = &MediaInitializationSection{URI: pp.InitName}
+			trackID, timescale = referenceTrack(initSegment)
+			partTarget = uint64(math.Round(pp.Playlist.PartInf.PartTarget * float64(timescale)))
+			target = uint64(pp.Playlist.TargetDuration) * uint64(timescale)
+			pp.Playlist.SetPreloadHints(partHint(nextSequence, 0))
+		}
+
+		var duration uint64
+		sync := false
+		for _, tf := range fragment.Tracks {
+			if tf.TrackID == trackID && len(tf.Samples) > 0 {
+				duration += tf.Duration()
+				sync = tf.Samples[0].IsSync()
+			}
+		}
+
+		if sync && segmentDuration+partDuration > 0 && segmentDuration+partDuration+duration > target {
+			if err := flushSegment(partHint(nextSequence+1, 0)); err != nil {
+				return err
+			}
+		} else if partDuration > 0 && partDuration+duration > partTarget {
+			if err := flushPart(partHint(nextSequence, parts+1)); err != nil {
+				return err
+			}
+		}
+		if part.Len() == 0 {
+			partIndependent = sync
+		}
+		part.Write(fragment.Raw)
+		partDuration += duration
+		if partDuration >= partTarget {
+			if err := flushPart(nextPartHint()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flushSegment(); err != nil {
+		return err
+	}
+	pp.Playlist.SetPreloadHints()
+	return nil
+}
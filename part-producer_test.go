@@ -0,0 +1,87 @@
+package HLS_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestPartProducer(t *testing.T) {
+	b, err := os.ReadFile("fmp4/testdata/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	livePlaylist := HLS.NewLiveMediaPlaylist(2, 0)
+	store := HLS.NewMemorySegmentStore()
+	producer := HLS.NewPartProducer(livePlaylist, store)
+	if err := producer.Produce(bytes.NewReader(b)); err != HLS.MissingPartInf {
+		t.Fatal("Expected", HLS.MissingPartInf, "but got", err)
+	}
+
+	livePlaylist.PartInf = &HLS.PartInf{PartTarget: 0.5}
+	livePlaylist.ServerControl = &HLS.ServerControl{PartHoldBack: 1.5, CanBlockReload: true}
+	hints := make([]string, 0)
+	writer := HLS.SegmentWriterFunc(func(name string, r io.Reader) error {
+		if mediaPlaylist := livePlaylist.MediaPlaylist(); len(mediaPlaylist.PreloadHints) == 1 {
+			hints = append(hints, mediaPlaylist.PreloadHints[0].URI)
+		}
+		return store.Put(name, r)
+	})
+	producer = HLS.NewPartProducer(livePlaylist, writer)
+	if err := producer.Produce(bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+
+	mediaPlaylist := livePlaylist.MediaPlaylist()
+	if len(mediaPlaylist.Segments) != 2 || len(mediaPlaylist.Parts) != 0 || len(mediaPlaylist.PreloadHints) != 0 {
+		t.Fatal("Unexpected playlist", mediaPlaylist)
+	}
+	for i, segment := range mediaPlaylist.Segments {
+		if segment.Duration != 2 || segment.Map == nil || segment.Map.URI != "init.mp4" || len(segment.Parts) != 6 {
+			t.Fatal("Unexpected segment", segment)
+		}
+		data := make([]byte, 0)
+		for j, part := range segment.Parts {
+			if part.Independent != (j%3 == 0) {
+				t.Fatal("Expected INDEPENDENT on every third part but got", j, part.Independent)
+			} else if part.Duration != 0.333333 {
+				t.Fatal("Expected a part duration of 0.333333 but got", part.Duration)
+			}
+			rd, _, err := store.Get(part.URI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			partData, _ := io.ReadAll(rd)
+			rd.Close()
+			data = append(data, partData...)
+		}
+		rd, _, err := store.Get(segment.URI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segmentData, _ := io.ReadAll(rd)
+		rd.Close()
+		if !bytes.Equal(data, segmentData) {
+			t.Fatal("Expected segment", i, "to be the concatenation of its parts")
+		}
+	}
+	if rd, stat, err := store.Get("init.mp4"); err != nil || stat.Size != 648 {
+		t.Fatal("Expected a 648 byte init segment but got", stat.Size, err)
+	} else {
+		rd.Close()
+	}
+
+	//Every part is announced by a preload hint before it is written. The last part ends the stream and is not followed by a hint.
+	expected := "seg000.0.m4s,seg000.1.m4s,seg000.2.m4s,seg000.3.m4s,seg000.4.m4s,seg000.5.m4s,seg001.0.m4s,seg001.0.m4s," +
+		"seg001.1.m4s,seg001.2.m4s,seg001.3.m4s,seg001.4.m4s,seg001.5.m4s"
+	if strings.Join(hints, ",") != expected {
+		t.Fatal("Expected the preload hints", expected, "but got", hints)
+	}
+	if _, err := livePlaylist.Render(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,278 @@
+package HLS
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
+
+// Rebuffer is a stall of a Player. At is the virtual time the buffer ran empty.
+type Rebuffer struct {
+	At       time.Duration
+	Duration time.Duration
+}
+
+// VariantSwitch is a change of the variant stream of a Player. From and To are indexes into the variant streams of the
+// Master Playlist.
+type VariantSwitch struct {
+	At   time.Duration
+	From int
+	To   int
+}
+
+// PlaybackError is a failed download of a Player. The player skips the segment and continues.
+type PlaybackError struct {
+	At  time.Duration
+	URI string
+	Err error
+}
+
+// PlaybackReport is the result of a Player session. Times are virtual times since the session started.
+type PlaybackReport struct {
+	// StartupTime is the time until playback started. It is 0 if playback never started.
+	StartupTime time.Duration
+	Rebuffers   []Rebuffer
+	Switches    []VariantSwitch
+	Errors      []PlaybackError
+	// Played is the duration of the media played.
+	Played time.Duration
+	// Elapsed is the duration of the session.
+	Elapsed time.Duration
+	// Segments is the number of downloaded segments.
+	Segments int
+}
+
+// RebufferDuration returns the total duration of the rebuffer events.
+func (report *PlaybackReport) RebufferDuration() time.Duration {
+	var total time.Duration
+	for _, rebuffer := range report.Rebuffers {
+		total += rebuffer.Duration
+	}
+	return total
+}
+
+// Player is a headless player for automated tests of HLS streams. It selects variant streams with a VariantSelector,
+// downloads the segments of the selected variant stream one after another and models the playback buffer in virtual time:
+// Every download advances the virtual clock and playback drains the buffer at real-time speed, so a buffer that runs
+// empty is reported as a rebuffer event. The segments are not decoded and keys are not loaded.
+// The position is kept by Media Sequence Number when the variant stream changes.
+type Player struct {
+	Client   *Client
+	Selector VariantSelector
+	Device   DeviceConstraints
+	// Bandwidth returns the bandwidth of the simulated network in bits per second at the virtual time. A download takes
+	// the time the bytes need at this bandwidth or the measured time if it is longer.
+	// If Bandwidth is nil the measured download time is used.
+	Bandwidth func(at time.Duration) float64
+	// StartupBuffer is the buffer needed to start or resume playback. If StartupBuffer is 0 playback starts after the first segment.
+	StartupBuffer time.Duration
+	// MaxBuffer is the buffer at which downloading pauses until the next segment fits.
+	MaxBuffer time.Duration
+	// Duration is the duration of the media played before the session ends. If Duration is 0 the session ends with the
+	// EXT-X-ENDLIST tag. Live streams need a Duration.
+	Duration time.Duration
+}
+
+// NewPlayer returns a new Player of the stream of client that selects variant streams with selector and buffers 30 seconds.
+func NewPlayer(client *Client, selector VariantSelector) *Player {
+	return &Player{
+		Client:    client,
+		Selector:  selector,
+		MaxBuffer: 30 * time.Second,
+	}
+}
+
+// playback is the state of a Player session.
+type playback struct {
+	player    *Player
+	report    PlaybackReport
+	clock     time.Duration
+	buffer    time.Duration
+	playing   bool
+	started   bool
+	stalledAt time.Duration
+	state     PlaybackState
+}
+
+// advance advances the virtual clock by d and plays the buffer.
+func (pb *playback) advance(d time.Duration) {
+	if pb.playing {
+		if d > pb.buffer {
+			pb.report.Played += pb.buffer
+			pb.stalledAt = pb.clock + pb.buffer
+			pb.buffer, pb.playing = 0, false
+		} else {
+			pb.report.Played += d
+			pb.buffer -= d
+		}
+	}
+	pb.clock += d
+}
+
+// buffered adds a segment of duration to the buffer and starts playback if enough is buffered or if force is true.
+func (pb *playback) buffered(duration time.Duration, force bool) {
+	pb.buffer += duration
+	if pb.playing || (pb.buffer < pb.player.StartupBuffer && !force) || pb.buffer == 0 {
+		return
+	}
+	pb.playing = true
+	if !pb.started {
+		pb.started = true
+		pb.report.StartupTime = pb.clock
+	} else {
+		pb.report.Rebuffers = append(pb.report.Rebuffers, Rebuffer{At: pb.stalledAt, Duration: pb.clock - pb.stalledAt})
+	}
+}
+
+// download downloads uri and advances the clock by the download time. It returns the number of bytes.
+func (pb *playback) download(ctx context.Context, uri string, byteRange *ByteRange) (int64, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	body, _, err := pb.player.Client.open(ctx, u, byteRange)
+	if err != nil {
+		pb.advance(time.Since(start))
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.Copy(io.Discard, body)
+	elapsed := time.Since(start)
+	if pb.player.Bandwidth != nil {
+		if bandwidth := pb.player.Bandwidth(pb.clock); bandwidth > 0 {
+			elapsed = max(elapsed, time.Duration(float64(n*8)/bandwidth*float64(time.Second)))
+		}
+	}
+	pb.advance(elapsed)
+	if err == nil && elapsed > 0 {
+		pb.state.Throughput = append(pb.state.Throughput, float64(n*8)/elapsed.Seconds())
+	}
+	return n, err
+}
+
+// mediaPlaylist loads the Media Playlist of the variant stream and advances the clock by the load time.
+func (pb *playback) mediaPlaylist(ctx context.Context, uri string) (MediaPlaylist, error) {
+	start := time.Now()
+	mp, err := pb.player.Client.MediaPlaylist(ctx, uri)
+	pb.advance(time.Since(start))
+	return mp, err
+}
+
+// liveStart returns the Media Sequence Number of the first segment that starts at least three target durations from
+// the end of the playlist as described by RFC 8216 section 6.3.3.
+func liveStart(mp MediaPlaylist) uint64 {
+	remaining := 0.0
+	for i := len(mp.Segments) - 1; i >= 0; i-- {
+		remaining += mp.Segments[i].Duration
+		if remaining >= float64(3*mp.TargetDuration) {
+			return mp.MediaSequence + uint64(i)
+		}
+	}
+	return mp.MediaSequence
+}
+
+// Play plays the stream until the media of Duration is played, the playlist ends or ctx is done and returns the report.
+// Waiting for new segments of a live stream takes real time which advances the virtual clock too.
+// Play returns the error of loading the Master Playlist, of the VariantSelector and of loading a Media Playlist.
+func (p *Player) Play(ctx context.Context) (PlaybackReport, error) {
+	pb := &playback{player: p, state: PlaybackState{Current: -1}}
+	finish := func(err error) (PlaybackReport, error) {
+		pb.report.Elapsed = pb.clock
+		return pb.report, err
+	}
+
+	start := time.Now()
+	master, err := p.Client.MasterPlaylist(ctx)
+	pb.advance(time.Since(start))
+	if err != nil {
+		return finish(err)
+	}
+
+	var mp MediaPlaylist
+	var next uint64
+	var initSection string
+	loaded, positioned := false, false
+	for p.Duration == 0 || pb.report.Played+pb.buffer < p.Duration {
+		if err := ctx.Err(); err != nil {
+			return finish(err)
+		}
+
+		pb.state.Buffer = pb.buffer
+		variant, err := p.Selector.SelectVariant(master, pb.state, p.Device)
+		if err != nil {
+			return finish(err)
+		}
+		if variant != pb.state.Current {
+			if pb.state.Current >= 0 {
+				pb.report.Switches = append(pb.report.Switches, VariantSwitch{At: pb.clock, From: pb.state.Current, To: variant})
+			}
+			pb.state.Current, loaded = variant, false
+		}
+		if !loaded || (!mp.EndList && next >= mp.MediaSequence+uint64(len(mp.Segments))) {
+			if mp, err = pb.mediaPlaylist(ctx, master.Variants[variant].URI); err != nil {
+				return finish(err)
+			}
+			loaded = true
+			if !positioned {
+				next, positioned = mp.MediaSequence, true
+				if !mp.EndList {
+					next = liveStart(mp)
+				}
+			}
+		}
+
+		if next < mp.MediaSequence {
+			//The segments were removed from the live playlist before they were loaded.
+			next = mp.MediaSequence
+		} else if next >= mp.MediaSequence+uint64(len(mp.Segments)) {
+			if mp.EndList {
+				break
+			}
+			//Wait half a target duration for the live playlist to change.
+			wait := time.Duration(mp.TargetDuration) * time.Second / 2
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return finish(ctx.Err())
+			case <-timer.C:
+			}
+			pb.advance(wait)
+			continue
+		}
+
+		segment := mp.Segments[next-mp.MediaSequence]
+		next++
+		//The Media Initialization Section is identified by its URI and byte range.
+		if segment.Map != nil && segment.Map.id() != initSection {
+			if _, err := pb.download(ctx, segment.Map.URI, segment.Map.ByteRange); err != nil {
+				pb.report.Errors = append(pb.report.Errors, PlaybackError{At: pb.clock, URI: segment.Map.URI, Err: err})
+				continue
+			}
+			initSection = segment.Map.id()
+		}
+		if _, err := pb.download(ctx, segment.URI, segment.ByteRange); err != nil {
+			if ctx.Err() != nil {
+				return finish(ctx.Err())
+			}
+			pb.report.Errors = append(pb.report.Errors, PlaybackError{At: pb.clock, URI: segment.URI, Err: err})
+			continue
+		}
+		pb.report.Segments++
+		pb.buffered(time.Duration(segment.Duration*float64(time.Second)), false)
+
+		//Downloading pauses until the next segment fits into the buffer.
+		if p.MaxBuffer > 0 {
+			if wait := pb.buffer + time.Duration(float64(mp.TargetDuration)*float64(time.Second)) - p.MaxBuffer; wait > 0 && pb.playing {
+				pb.advance(wait)
+			}
+		}
+	}
+
+	//The rest of the buffer is played.
+	pb.buffered(0, true)
+	pb.advance(pb.buffer)
+	return finish(nil)
+}
@@ -0,0 +1,172 @@
+package HLS_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+// playerOrigin returns a VOD stream with a 500 kbit/s and a 4 Mbit/s variant stream of ten 2 second segments.
+func playerOrigin() fstest.MapFS {
+	origin := fstest.MapFS{
+		"vod/master.m3u8": {Data: []byte(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=600000,RESOLUTION=640x360
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=4800000,RESOLUTION=1920x1080
+hi/index.m3u8
+`)},
+	}
+	for name, size := range map[string]int{"low": 125000, "hi": 1000000} {
+		playlist := strings.Builder{}
+		playlist.WriteString("#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+		segment := bytes.Repeat([]byte{0x47}, size)
+		for i := range 10 {
+			fmt.Fprintf(&playlist, "#EXTINF:2.0,\nseg%d.ts\n", i)
+			origin[fmt.Sprintf("vod/%s/seg%d.ts", name, i)] = &fstest.MapFile{Data: segment}
+		}
+		playlist.WriteString("#EXT-X-ENDLIST\n")
+		origin["vod/"+name+"/index.m3u8"] = &fstest.MapFile{Data: []byte(playlist.String())}
+	}
+	return origin
+}
+
+func TestPlayer(t *testing.T) {
+	origin := playerOrigin()
+	server := httptest.NewServer(http.FileServerFS(origin))
+	defer server.Close()
+	client, err := HLS.NewClient(server.Client(), server.URL+"/vod/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		//A fast network starts with the lowest variant stream and switches up after the first segment.
+		player := HLS.NewPlayer(client, HLS.NewThroughputSelector())
+		player.Bandwidth = func(at time.Duration) float64 {
+			return 20e6
+		}
+		report, err := player.Play(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.StartupTime < 50*time.Millisecond || report.StartupTime > time.Second {
+			t.Fatal("Unexpected startup time", report.StartupTime)
+		} else if len(report.Rebuffers) != 0 || len(report.Errors) != 0 {
+			t.Fatal("Unexpected rebuffers or errors", report.Rebuffers, report.Errors)
+		} else if len(report.Switches) != 1 || report.Switches[0].From != 0 || report.Switches[0].To != 1 {
+			t.Fatal("Expected one switch to the high variant stream but got", report.Switches)
+		} else if report.Played != 20*time.Second || report.Segments != 10 || report.Elapsed < report.StartupTime+report.Played {
+			t.Fatal("Unexpected report", report)
+		}
+	}
+	{
+		//A bandwidth drop stalls the high variant stream until the player switches down.
+		player := HLS.NewPlayer(client, HLS.NewThroughputSelector())
+		player.Bandwidth = func(at time.Duration) float64 {
+			if at < time.Second {
+				return 20e6
+			}
+			return 1e6
+		}
+		report, err := player.Play(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Rebuffers) == 0 || report.RebufferDuration() <= 0 {
+			t.Fatal("Expected rebuffers but got", report.Rebuffers)
+		} else if last := report.Switches[len(report.Switches)-1]; last.From != 1 || last.To != 0 {
+			t.Fatal("Expected a switch to the low variant stream but got", report.Switches)
+		} else if report.Played != 20*time.Second || report.Elapsed < report.Played+report.RebufferDuration() {
+			t.Fatal("Unexpected report", report)
+		}
+	}
+	{
+		//A device without 1080p plays the low variant stream only and a missing segment is reported and skipped.
+		broken := maps.Clone(origin)
+		delete(broken, "vod/low/seg3.ts")
+		server := httptest.NewServer(http.FileServerFS(broken))
+		defer server.Close()
+		client, err := HLS.NewClient(server.Client(), server.URL+"/vod/master.m3u8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		player := HLS.NewPlayer(client, HLS.NewBufferSelector())
+		player.Device.MaxResolution = &HLS.Resolution{Width: 1280, Height: 720}
+		player.Duration = 12 * time.Second
+		report, err := player.Play(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Errors) != 1 || !strings.HasSuffix(report.Errors[0].URI, "/vod/low/seg3.ts") || !errors.Is(report.Errors[0].Err, HLS.UnexpectedStatus) {
+			t.Fatal("Expected the missing segment to be reported but got", report.Errors)
+		} else if len(report.Switches) != 0 || report.Played != 12*time.Second || report.Segments != 6 {
+			t.Fatal("Unexpected report", report)
+		}
+	}
+}
+
+func TestPlayer_Live(t *testing.T) {
+	//The live window slides by one segment every second reload, so the player has to wait for some reloads.
+	var mu sync.Mutex
+	reloads, initRequests := 0, 0
+	requested := make([]string, 0)
+	initSection := bytes.Repeat([]byte{0x47}, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/live/master.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=600000\nindex.m3u8\n"))
+		case r.URL.Path == "/live/index.m3u8":
+			first := reloads / 2
+			reloads++
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:%d\n", first)
+			for i := first; i < first+6; i++ {
+				//The Media Initialization Section changes its byte range at segment 5.
+				fmt.Fprintf(w, "#EXT-X-MAP:URI=\"init.mp4\",BYTERANGE=\"4@%d\"\n#EXTINF:1.0,\nseg%d.ts\n", map[bool]int{true: 4, false: 0}[i >= 5], i)
+			}
+		case r.URL.Path == "/live/init.mp4":
+			initRequests++
+			http.ServeContent(w, r, "init.mp4", time.Time{}, bytes.NewReader(initSection))
+		default:
+			requested = append(requested, path.Base(r.URL.Path))
+			w.Write(bytes.Repeat([]byte{0x47}, 1000))
+		}
+	}))
+	defer server.Close()
+	client, err := HLS.NewClient(server.Client(), server.URL+"/live/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player := HLS.NewPlayer(client, HLS.NewThroughputSelector())
+	player.Duration = 6 * time.Second
+	report, err := player.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	//Playback starts three target durations from the end of the first playlist.
+	if strings.Join(requested, ",") != "seg3.ts,seg4.ts,seg5.ts,seg6.ts,seg7.ts,seg8.ts" {
+		t.Fatal("Unexpected segments", requested)
+	} else if initRequests != 2 {
+		t.Fatal("Expected both byte ranges of the Media Initialization Section but got", initRequests, "requests")
+	} else if reloads <= 4 {
+		t.Fatal("Expected the player to wait for the playlist but got", reloads, "reloads")
+	} else if len(report.Errors) != 0 || report.Played != 6*time.Second || report.Segments != 6 {
+		t.Fatal("Unexpected report", report)
+	}
+}
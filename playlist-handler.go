@@ -0,0 +1,169 @@
+package HLS
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlaylistMIMEType is the Content-Type of playlists.
+const PlaylistMIMEType = "application/vnd.apple.mpegurl"
+
+// PlaylistSource returns the current encoded playlist and the time it was last modified.
+// The modification time can be zero if it is unknown.
+type PlaylistSource interface {
+	Playlist() ([]byte, time.Time, error)
+}
+
+// PlaylistSourceFunc is a function that implements PlaylistSource.
+type PlaylistSourceFunc func() ([]byte, time.Time, error)
+
+func (f PlaylistSourceFunc) Playlist() ([]byte, time.Time, error) {
+	return f()
+}
+
+// StaticPlaylist is a PlaylistSource of a playlist that never changes.
+type StaticPlaylist struct {
+	Data    []byte
+	ModTime time.Time
+}
+
+// NewStaticPlaylist reads a closed playlist and returns a StaticPlaylist modified now.
+func NewStaticPlaylist(playlist *Playlist) (StaticPlaylist, error) {
+	b, err := io.ReadAll(playlist)
+	return StaticPlaylist{Data: b, ModTime: time.Now()}, err
+}
+
+func (sp StaticPlaylist) Playlist() ([]byte, time.Time, error) {
+	return sp.Data, sp.ModTime, nil
+}
+
+// PlaylistHandler is a http.Handler that serves the playlist of a PlaylistSource with a strong ETag, Last-Modified and
+// a Cache-Control derived from the playlist. Conditional and HEAD requests are handled by http.ServeContent.
+type PlaylistHandler struct {
+	Source PlaylistSource
+	// Gzip compresses the playlist if the client accepts gzip.
+	Gzip bool
+	// CORS is the CORS policy of the playlist. CORS headers are not written if CORS is nil.
+	CORS *CORS
+	// VODMaxAge is the max-age of playlists that do not change anymore: Media Playlists with EXT-X-ENDLIST and
+	// Master Playlists. Live Media Playlists are cached for half of their target duration.
+	VODMaxAge time.Duration
+
+	mu sync.Mutex
+	// etag and compressed are the last compressed playlist so it is not compressed again for every request.
+	etag       string
+	compressed []byte
+}
+
+// NewPlaylistHandler returns a new PlaylistHandler with gzip enabled and VODMaxAge set to a day.
+func NewPlaylistHandler(source PlaylistSource) *PlaylistHandler {
+	return &PlaylistHandler{
+		Source:    source,
+		Gzip:      true,
+		VODMaxAge: 24 * time.Hour,
+	}
+}
+
+// CacheControl returns the Cache-Control of a encoded playlist.
+// Live Media Playlists are cached for half of their target duration so clients see new segments in time.
+func (ph *PlaylistHandler) CacheControl(playlist []byte) string {
+	live, targetDuration := false, 0
+	for line := range strings.SplitSeq(string(playlist), "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "#"+EXT_X_TARGETDURATION+":"); ok {
+			live = true
+			targetDuration, _ = strconv.Atoi(value)
+		} else if line == "#"+EXT_X_ENDLIST || line == "#"+EXT_X_PLAYLIST_TYPE+":VOD" {
+			return "public, max-age=" + strconv.Itoa(int(ph.VODMaxAge.Seconds()))
+		}
+	}
+	if !live {
+		return "public, max-age=" + strconv.Itoa(int(ph.VODMaxAge.Seconds()))
+	}
+	return "public, max-age=" + strconv.Itoa(max(targetDuration/2, 1))
+}
+
+func (ph *PlaylistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ph.CORS != nil {
+		ph.CORS.SetHeaders(w.Header())
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	playlist, modTime, err := ph.Source.Playlist()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(playlist)
+	etag := hex.EncodeToString(sum[:16])
+	header := w.Header()
+	header.Set("Content-Type", PlaylistMIMEType)
+	header.Set("Cache-Control", ph.CacheControl(playlist))
+	if ph.Gzip {
+		header.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			//The compressed playlist is a different representation so it has a different strong ETag.
+			playlist, err = ph.compress(etag, playlist)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			etag += "-gzip"
+			header.Set("Content-Encoding", "gzip")
+		}
+	}
+	header.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(playlist))
+}
+
+// compress returns the gzip compressed playlist with the etag.
+func (ph *PlaylistHandler) compress(etag string, playlist []byte) ([]byte, error) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	if ph.etag == etag {
+		return ph.compressed, nil
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(playlist); err != nil {
+		return nil, err
+	} else if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	ph.etag, ph.compressed = etag, buf.Bytes()
+	return ph.compressed, nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding of r contains gzip without q=0.
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for coding := range strings.SplitSeq(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if strings.TrimSpace(name) != "gzip" {
+				continue
+			}
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
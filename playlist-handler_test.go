@@ -0,0 +1,89 @@
+package HLS_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestPlaylistHandler(t *testing.T) {
+	vod := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6,\n0.ts\n#EXT-X-ENDLIST\n"
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := HLS.NewPlaylistHandler(HLS.StaticPlaylist{Data: []byte(vod), ModTime: modTime})
+	handler.CORS = HLS.NewCORS("*")
+
+	serve := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, "/playlist.m3u8", nil)
+		for name, value := range header {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != vod {
+		t.Fatal("Expected the playlist but got", w.Code, w.Body.String())
+	} else if contentType := w.Header().Get("Content-Type"); contentType != HLS.PlaylistMIMEType {
+		t.Fatal("Expected", HLS.PlaylistMIMEType, "but got", contentType)
+	} else if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "public, max-age=86400" {
+		t.Fatal("Expected a long max-age but got", cacheControl)
+	} else if len(etag) < 3 || etag[0] != '"' {
+		t.Fatal("Expected a strong ETag but got", etag)
+	} else if lastModified := w.Header().Get("Last-Modified"); lastModified != modTime.Format(http.TimeFormat) {
+		t.Fatal("Expected", modTime.Format(http.TimeFormat), "but got", lastModified)
+	} else if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Fatal("Expected CORS headers but got", origin)
+	}
+
+	if w := serve(http.MethodGet, map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Fatal("Expected", http.StatusNotModified, "but got", w.Code)
+	} else if w := serve(http.MethodOptions, nil); w.Code != http.StatusNoContent {
+		t.Fatal("Expected", http.StatusNoContent, "but got", w.Code)
+	} else if w := serve(http.MethodPost, nil); w.Code != http.StatusMethodNotAllowed {
+		t.Fatal("Expected", http.StatusMethodNotAllowed, "but got", w.Code)
+	}
+
+	{
+		w := serve(http.MethodGet, map[string]string{"Accept-Encoding": "br, gzip"})
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatal("Expected a gzip response")
+		} else if w.Header().Get("ETag") == etag {
+			t.Fatal("Expected the gzip response to have a different ETag")
+		}
+		gr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(gr)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != vod {
+			t.Fatal("Expected", vod, "but got", string(b))
+		}
+
+		if w := serve(http.MethodGet, map[string]string{"Accept-Encoding": "gzip;q=0"}); w.Header().Get("Content-Encoding") != "" {
+			t.Fatal("Expected a uncompressed response")
+		}
+	}
+
+	cacheControls := map[string]string{
+		"#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6,\n0.ts\n":                           "public, max-age=3",
+		"#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1,\n0.ts\n":                           "public, max-age=1",
+		"#EXTM3U\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-TARGETDURATION:6\n#EXTINF:6,\n0.ts\n": "public, max-age=86400",
+		"#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000\nvideo.m3u8\n":                        "public, max-age=86400",
+	}
+	for playlist, expected := range cacheControls {
+		if cacheControl := handler.CacheControl([]byte(playlist)); cacheControl != expected {
+			t.Fatal("Expected", expected, "but got", cacheControl, "for", playlist)
+		}
+	}
+}
@@ -100,11 +100,7 @@ func (pl *Playlist) Close() error {
 // p[:n] data can be send to a clint for streaming.
 func (pl *Playlist) Read(p []byte) (n int, err error) {
 	n = copy(p, pl.buf)
-	if n+1 >= len(pl.buf) {
-		pl.buf = make([]byte, 0)
-	} else {
-		pl.buf = pl.buf[n+1:]
-	}
+	pl.buf = pl.buf[n:]
 
 	if len(pl.buf) == 0 {
 		return n, pl.err
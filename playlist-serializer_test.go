@@ -260,3 +260,28 @@ func ExamplePlaylist() {
 
 	//...
 }
+
+func TestPlaylist_ReadSmallBuffer(t *testing.T) {
+	playlist := HLS.NewPlaylist()
+	if err := playlist.SetHeader(3); err != nil {
+		t.Fatal(err)
+	} else if err := playlist.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var output []byte
+	p := make([]byte, 3)
+	for {
+		n, err := playlist.Read(p)
+		output = append(output, p[:n]...)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if string(output) != "#EXTM3U\n#EXT-X-VERSION:3\n" {
+		t.Fatal("Expected the whole playlist but got", string(output))
+	}
+}
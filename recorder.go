@@ -0,0 +1,219 @@
+package HLS
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/udan-jayanith/HLS/encryption"
+)
+
+var (
+	NoVariantSelected error = errors.New("No variant stream selected")
+)
+
+// Recorder records a live HLS stream into a SegmentStore as a VOD package.
+// Every Media Playlist of the selected variant streams and of the renditions they refer to is followed with
+// Client.Follow. The segments, keys and Media Initialization Sections are downloaded into a directory per playlist and
+// a VOD Media Playlist with relative URIs and a EXT-X-ENDLIST tag is written for each of them. The Master Playlist
+// refers to the recorded playlists. I-frame streams and session keys are not recorded.
+type Recorder struct {
+	Client *Client
+	Store  SegmentStore
+	// Select reports whether variant is recorded. If Select is nil every variant stream is recorded.
+	Select func(variant VariantStream) bool
+	// MasterName is the name of the recorded Master Playlist. It is master.m3u8 by default.
+	MasterName string
+}
+
+// NewRecorder returns a new Recorder that records the stream of client into store.
+func NewRecorder(client *Client, store SegmentStore) *Recorder {
+	return &Recorder{
+		Client:     client,
+		Store:      store,
+		MasterName: "master.m3u8",
+	}
+}
+
+// Record records the stream until every recorded playlist has a EXT-X-ENDLIST tag or ctx is done and writes the
+// playlists. Playlists are named stream0/index.m3u8, stream1/index.m3u8 and so on.
+// Segments that are gone before they are downloaded, that is the origin answers 404 Not Found or 410 Gone, are left out
+// and the next segment gets a EXT-X-DISCONTINUITY tag. Any other failed download fails the recording with the error,
+// a *StatusError for other HTTP statuses including a 200 OK answer to a byte range request.
+// Record returns NoVariantSelected if Select does not select any variant stream.
+func (rec *Recorder) Record(ctx context.Context) error {
+	master, err := rec.Client.MasterPlaylist(ctx)
+	if err != nil {
+		return err
+	}
+
+	local := MasterPlaylist{
+		Version:             master.Version,
+		IndependentSegments: master.IndependentSegments,
+		SessionData:         master.SessionData,
+	}
+	var uris []string
+	names := make(map[string]string)
+	name := func(uri string) string {
+		if name, ok := names[uri]; ok {
+			return name
+		}
+		names[uri] = fmt.Sprintf("stream%d/index.m3u8", len(uris))
+		uris = append(uris, uri)
+		return names[uri]
+	}
+	groups := make(map[string]bool)
+	for _, variant := range master.Variants {
+		if rec.Select != nil && !rec.Select(variant) {
+			continue
+		}
+		groups[string(AUDIO)+":"+variant.Audio] = true
+		groups[string(VIDEO)+":"+variant.Video] = true
+		groups[string(SUBTITLES)+":"+variant.Subtitles] = true
+		groups[string(CLOSED_CAPTIONS)+":"+variant.ClosedCaptions] = true
+		variant.URI = name(variant.URI)
+		variant.IFrameStream = nil
+		local.Variants = append(local.Variants, variant)
+	}
+	if len(local.Variants) == 0 {
+		return NoVariantSelected
+	}
+	for _, rendition := range master.Renditions {
+		if !groups[string(rendition.Type)+":"+rendition.GroupID] {
+			continue
+		}
+		if rendition.URI != "" {
+			rendition.URI = name(rendition.URI)
+		}
+		local.Renditions = append(local.Renditions, rendition)
+	}
+
+	//The first failing playlist stops the others.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	for _, uri := range uris {
+		wg.Go(func() {
+			if err := rec.recordPlaylist(ctx, uri, path.Dir(names[uri])); err != nil {
+				cancel(err)
+			}
+		})
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil && err != ctx.Err() {
+		return err
+	}
+
+	playlist, err := local.Encode()
+	if err != nil {
+		return err
+	}
+	return rec.Store.Put(rec.MasterName, &playlist)
+}
+
+// recordPlaylist records the Media Playlist at uri into dir.
+func (rec *Recorder) recordPlaylist(ctx context.Context, uri string, dir string) error {
+	segments, err := rec.Client.Follow(ctx, uri)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	mp := MediaPlaylist{PlaylistType: VOD, EndList: true}
+	// files are the local names of the downloaded keys and Media Initialization Sections by URI and byte range.
+	files := make(map[string]string)
+	maps := make(map[string]*MediaInitializationSection)
+	gap := false
+	for segment := range segments {
+		if segment.Err == PlaylistEnded {
+			break
+		} else if segment.Err != nil {
+			return segment.Err
+		}
+
+		local := segment.MediaSegment
+		local.URI = fmt.Sprintf("seg%05d%s", segment.SequenceNumber, extension(segment.URI))
+		var statusErr *StatusError
+		if err := rec.download(ctx, segment.URI, segment.ByteRange, path.Join(dir, local.URI)); errors.As(err, &statusErr) && statusErr.Gone() {
+			gap = true
+			continue
+		} else if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		local.ByteRange, local.Parts = nil, nil
+		local.Discontinuity = len(mp.Segments) > 0 && (local.Discontinuity || gap || segment.Skipped > 0)
+		gap = false
+
+		if local.Map != nil {
+			id := local.Map.id()
+			if _, ok := maps[id]; !ok {
+				name := fmt.Sprintf("init%d%s", len(maps), extension(local.Map.URI))
+				if err := rec.download(ctx, local.Map.URI, local.Map.ByteRange, path.Join(dir, name)); err != nil {
+					return err
+				}
+				maps[id] = &MediaInitializationSection{URI: name}
+			}
+			local.Map = maps[id]
+		}
+
+		//The recorded playlist has different Media Sequence Numbers, so IVs derived from them are written explicitly.
+		local.Keys = append([]Key(nil), local.Keys...)
+		for i := range local.Keys {
+			key := &local.Keys[i]
+			if key.Method == NONE {
+				continue
+			} else if key.IV == nil {
+				key.IV = encryption.SequenceIV(segment.SequenceNumber)
+			}
+			if u, err := url.Parse(key.URI); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			if _, ok := files[key.URI]; !ok {
+				name := fmt.Sprintf("key%d.key", len(files))
+				if err := rec.download(ctx, key.URI, nil, path.Join(dir, name)); err != nil {
+					return err
+				}
+				files[key.URI] = name
+			}
+			key.URI = files[key.URI]
+		}
+		mp.Segments = append(mp.Segments, local)
+	}
+
+	playlist, err := mp.Encode()
+	if err != nil {
+		return err
+	}
+	return rec.Store.Put(path.Join(dir, "index.m3u8"), &playlist)
+}
+
+// download stores the byte range of the file at uri with the name. The whole file is stored if byteRange is nil.
+func (rec *Recorder) download(ctx context.Context, uri string, byteRange *ByteRange, name string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	body, _, err := rec.Client.open(ctx, u, byteRange)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return rec.Store.Put(name, body)
+}
+
+// extension returns the file extension of the path of uri.
+func extension(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return path.Ext(u.Path)
+}
@@ -0,0 +1,176 @@
+package HLS_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+var recorderOrigin = fstest.MapFS{
+	"live/master.m3u8": {Data: []byte(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,URI="audio/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac"
+low/index.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="low/iframe.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO="aac"
+hi/index.m3u8
+`)},
+	"live/low/index.m3u8": {Data: []byte(`#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="../keys/1.key"
+#EXTINF:4.0,
+seg7.m4s
+#EXTINF:4.0,
+seg8.m4s
+#EXT-X-BYTERANGE:4@2
+#EXTINF:2.0,
+all.m4s
+#EXT-X-ENDLIST
+`)},
+	"live/low/init.mp4": {Data: []byte("init")},
+	"live/low/seg7.m4s": {Data: []byte("segment 7")},
+	"live/low/seg8.m4s": {Data: []byte("segment 8")},
+	"live/low/all.m4s":  {Data: []byte("0123456789")},
+	"live/keys/1.key":   {Data: []byte("0123456789abcdef")},
+	"live/audio/index.m3u8": {Data: []byte(`#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXTINF:4.0,
+a0.aac
+#EXTINF:4.0,
+missing.aac
+#EXTINF:4.0,
+a2.aac
+#EXT-X-ENDLIST
+`)},
+	"live/audio/a0.aac": {Data: []byte("audio 0")},
+	"live/audio/a2.aac": {Data: []byte("audio 2")},
+}
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.FileServerFS(recorderOrigin))
+	defer server.Close()
+	client, err := HLS.NewClient(server.Client(), server.URL+"/live/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readFile := func(store HLS.SegmentStore, name string) []byte {
+		r, _, err := store.Get(name)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	{
+		store := HLS.NewMemorySegmentStore()
+		recorder := HLS.NewRecorder(client, store)
+		recorder.Select = func(variant HLS.VariantStream) bool {
+			return variant.Bandwidth < 2000000
+		}
+		if err := recorder.Record(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		master, err := HLS.DecodeMasterPlaylist(bytes.NewReader(readFile(store, "master.m3u8")))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(master.Variants) != 1 || master.Variants[0].URI != "stream0/index.m3u8" || master.Variants[0].IFrameStream != nil {
+			t.Fatal("Unexpected variant streams", master.Variants)
+		} else if len(master.Renditions) != 1 || master.Renditions[0].URI != "stream1/index.m3u8" {
+			t.Fatal("Unexpected renditions", master.Renditions)
+		}
+
+		video, err := HLS.DecodeMediaPlaylist(bytes.NewReader(readFile(store, "stream0/index.m3u8")))
+		if err != nil {
+			t.Fatal(err)
+		} else if video.PlaylistType != HLS.VOD || !video.EndList || len(video.Segments) != 3 {
+			t.Fatal("Unexpected playlist", video)
+		}
+		for i, expected := range []string{"segment 7", "segment 8", "2345"} {
+			segment := video.Segments[i]
+			if segment.ByteRange != nil || segment.Map == nil || segment.Map.URI != "init0.mp4" {
+				t.Fatal("Unexpected segment", segment)
+			} else if b := readFile(store, "stream0/"+segment.URI); string(b) != expected {
+				t.Fatal("Expected", expected, "but got", string(b))
+			}
+			//The IV is the Media Sequence Number of the segment in the origin playlist.
+			if len(segment.Keys) != 1 || segment.Keys[0].URI != "key0.key" || segment.Keys[0].IV[15] != byte(7+i) {
+				t.Fatal("Unexpected keys", segment.Keys)
+			}
+		}
+		if string(readFile(store, "stream0/init0.mp4")) != "init" || string(readFile(store, "stream0/key0.key")) != "0123456789abcdef" {
+			t.Fatal("Unexpected Media Initialization Section or key")
+		}
+
+		audio, err := HLS.DecodeMediaPlaylist(bytes.NewReader(readFile(store, "stream1/index.m3u8")))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(audio.Segments) != 2 || audio.Segments[0].Discontinuity || !audio.Segments[1].Discontinuity {
+			t.Fatal("Expected the missing segment to be replaced by a discontinuity but got", audio.Segments)
+		} else if string(readFile(store, "stream1/"+audio.Segments[1].URI)) != "audio 2" {
+			t.Fatal("Unexpected segment", audio.Segments[1])
+		}
+		if _, err := store.Stat("stream2/index.m3u8"); err != HLS.SegmentNotFound {
+			t.Fatal("Expected the high variant stream not to be recorded but got", err)
+		}
+	}
+	{
+		store := HLS.NewMemorySegmentStore()
+		recorder := HLS.NewRecorder(client, store)
+		recorder.Select = func(variant HLS.VariantStream) bool {
+			return false
+		}
+		if err := recorder.Record(context.Background()); err != HLS.NoVariantSelected {
+			t.Fatal("Expected", HLS.NoVariantSelected, "but got", err)
+		}
+		//The high variant stream does not exist on the origin.
+		recorder.Select = nil
+		if err := recorder.Record(context.Background()); !errors.Is(err, HLS.UnexpectedStatus) {
+			t.Fatal("Expected", HLS.UnexpectedStatus, "but got", err)
+		}
+	}
+	{
+		//Only 404 Not Found and 410 Gone are gaps, other statuses fail the recording.
+		for path, code := range map[string]int{"/live/audio/a2.aac": http.StatusServiceUnavailable, "/live/low/all.m4s": http.StatusOK} {
+			fileServer := http.FileServerFS(recorderOrigin)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != path {
+					fileServer.ServeHTTP(w, r)
+					return
+				}
+				//A 200 OK answer ignores the Range header of the byte range request.
+				w.WriteHeader(code)
+				w.Write([]byte("0123456789"))
+			}))
+			defer server.Close()
+			client, err := HLS.NewClient(server.Client(), server.URL+"/live/master.m3u8")
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := HLS.NewRecorder(client, HLS.NewMemorySegmentStore())
+			recorder.Select = func(variant HLS.VariantStream) bool {
+				return variant.Bandwidth < 2000000
+			}
+			var statusErr *HLS.StatusError
+			if err := recorder.Record(context.Background()); !errors.As(err, &statusErr) || statusErr.Code != code {
+				t.Fatal("Expected the status", code, "but got", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package HLS
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RenditionLadder knows the live renditions of a Master Playlist and adds EXT-X-RENDITION-REPORT tags of every sibling
+// rendition to a LiveMediaPlaylist when it is rendered, so the reports are always up to date.
+// It is safe for concurrent use.
+type RenditionLadder struct {
+	mu         sync.RWMutex
+	renditions []rendition
+}
+
+// rendition is a LiveMediaPlaylist of a RenditionLadder and its URI relative to the Master Playlist.
+type rendition struct {
+	uri      string
+	playlist *LiveMediaPlaylist
+}
+
+// NewRenditionLadder returns a new empty RenditionLadder.
+func NewRenditionLadder() *RenditionLadder {
+	return &RenditionLadder{}
+}
+
+// Add adds playlist to the ladder. uri is the URI of the playlist in the Master Playlist. A playlist that was added
+// with the same uri before is replaced. A playlist can only be part of one ladder.
+func (rl *RenditionLadder) Add(uri string, playlist *LiveMediaPlaylist) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	playlist.mu.Lock()
+	playlist.ladder, playlist.ladderURI = rl, uri
+	playlist.mu.Unlock()
+
+	for i := range rl.renditions {
+		if rl.renditions[i].uri == uri {
+			rl.renditions[i].playlist.detach()
+			rl.renditions[i].playlist = playlist
+			return
+		}
+	}
+	rl.renditions = append(rl.renditions, rendition{uri: uri, playlist: playlist})
+}
+
+// Remove removes the playlist with uri from the ladder.
+func (rl *RenditionLadder) Remove(uri string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for i := range rl.renditions {
+		if rl.renditions[i].uri == uri {
+			rl.renditions[i].playlist.detach()
+			rl.renditions = append(rl.renditions[:i], rl.renditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// Playlist returns the playlist with uri.
+func (rl *RenditionLadder) Playlist(uri string) (*LiveMediaPlaylist, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	for _, rendition := range rl.renditions {
+		if rendition.uri == uri {
+			return rendition.playlist, true
+		}
+	}
+	return nil, false
+}
+
+// RenditionReports returns the rendition reports of the siblings of the playlist with uri in the order they were added.
+// The URIs of the reports are relative to uri. Renditions without segments are not reported.
+func (rl *RenditionLadder) RenditionReports(uri string) []RenditionReport {
+	reports, _ := rl.reports(uri)
+	return reports
+}
+
+// reports returns the rendition reports of the siblings of uri and the time the last sibling changed.
+func (rl *RenditionLadder) reports(uri string) ([]RenditionReport, time.Time) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	reports := make([]RenditionReport, 0, len(rl.renditions))
+	modTime := time.Time{}
+	for _, rendition := range rl.renditions {
+		if rendition.uri == uri {
+			continue
+		}
+		rendition.playlist.mu.RLock()
+		msn, part, ok := rendition.playlist.lastPosition()
+		siblingModTime := rendition.playlist.modTime
+		rendition.playlist.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		reports = append(reports, RenditionReport{URI: relativeURI(uri, rendition.uri), LastMSN: msn, LastPart: part})
+		if siblingModTime.After(modTime) {
+			modTime = siblingModTime
+		}
+	}
+	return reports, modTime
+}
+
+// relativeURI returns the URI of to relative to the playlist at from. Both are relative to the Master Playlist.
+// Absolute URIs are returned unchanged.
+func relativeURI(from string, to string) string {
+	if getLineType(to) == URI || strings.HasPrefix(to, "/") {
+		return to
+	}
+	fromDir := strings.Split(path.Dir(path.Clean(from)), "/")
+	toParts := strings.Split(path.Clean(to), "/")
+	if fromDir[0] == "." {
+		fromDir = fromDir[1:]
+	}
+
+	common := 0
+	for common < len(fromDir) && common < len(toParts)-1 && fromDir[common] == toParts[common] {
+		common++
+	}
+	parts := make([]string, 0, len(fromDir)-common+len(toParts)-common)
+	for range fromDir[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[common:]...)
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,72 @@
+package HLS_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestRenditionLadder(t *testing.T) {
+	ladder := HLS.NewRenditionLadder()
+	uris := []string{"video/720p.m3u8", "video/1080p.m3u8", "audio/en/main.m3u8", "https://cdn.example.com/subs.m3u8"}
+	playlists := make([]*HLS.LiveMediaPlaylist, len(uris))
+	for i, uri := range uris {
+		playlists[i] = HLS.NewLiveMediaPlaylist(2, 0)
+		playlists[i].PartInf = &HLS.PartInf{PartTarget: 1}
+		playlists[i].ServerControl = &HLS.ServerControl{PartHoldBack: 3, CanBlockReload: true}
+		ladder.Add(uri, playlists[i])
+	}
+	for i := range 3 {
+		playlists[0].AddSegment(HLS.MediaSegment{URI: fmt.Sprintf("seg%d.mp4", i), Duration: 2})
+		playlists[1].AddPart(HLS.Part{URI: fmt.Sprintf("part%d.mp4", i), Duration: 1})
+	}
+	playlists[2].AddPart(HLS.Part{URI: "a0.mp4", Duration: 1})
+	playlists[2].AddSegment(HLS.MediaSegment{URI: "a0.mp4", Duration: 1})
+
+	reports := ladder.RenditionReports("video/720p.m3u8")
+	expected := []HLS.RenditionReport{
+		{URI: "1080p.m3u8", LastMSN: 0, LastPart: 2},
+		{URI: "../audio/en/main.m3u8", LastMSN: 0, LastPart: 0},
+	}
+	if fmt.Sprint(reports) != fmt.Sprint(expected) {
+		t.Fatal("Expected", expected, "but got", reports)
+	}
+	reports = ladder.RenditionReports("audio/en/main.m3u8")
+	if len(reports) != 2 || reports[0].URI != "../../video/720p.m3u8" || reports[0].LastMSN != 2 || reports[0].LastPart != -1 {
+		t.Fatal("Unexpected reports", reports)
+	}
+
+	b, err := playlists[0].Render()
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(string(b), "seg2.mp4\n#EXT-X-RENDITION-REPORT:URI=\"1080p.m3u8\",LAST-MSN=0,LAST-PART=2\n#EXT-X-RENDITION-REPORT:URI=\"../audio/en/main.m3u8\",LAST-MSN=0,LAST-PART=0\n") {
+		t.Fatal("Expected rendition reports but got", string(b))
+	}
+	mediaPlaylist, err := HLS.DecodeMediaPlaylist(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(mediaPlaylist.RenditionReports) != fmt.Sprint(expected) {
+		t.Fatal("Expected", expected, "but got", mediaPlaylist.RenditionReports)
+	}
+
+	//A change of a sibling changes the rendered playlist.
+	playlists[1].AddSegment(HLS.MediaSegment{URI: "seg0.mp4", Duration: 2})
+	if reports := playlists[0].MediaPlaylist().RenditionReports; reports[0].LastMSN != 0 || reports[0].LastPart != 2 {
+		t.Fatal("Unexpected reports", reports)
+	}
+	playlists[1].AddPart(HLS.Part{URI: "part3.mp4", Duration: 1})
+	if reports := playlists[0].MediaPlaylist().RenditionReports; reports[0].LastMSN != 1 || reports[0].LastPart != 0 {
+		t.Fatal("Unexpected reports", reports)
+	}
+
+	ladder.Remove("video/1080p.m3u8")
+	if _, ok := ladder.Playlist("video/1080p.m3u8"); ok {
+		t.Fatal("Expected the playlist to be removed")
+	} else if reports := playlists[1].MediaPlaylist().RenditionReports; len(reports) != 0 {
+		t.Fatal("Expected no reports for a removed playlist but got", reports)
+	} else if reports := ladder.RenditionReports("video/720p.m3u8"); len(reports) != 1 {
+		t.Fatal("Expected 1 report but got", reports)
+	}
+}
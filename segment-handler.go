@@ -0,0 +1,95 @@
+package HLS
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// segmentMIMETypes are the Content-Types by file extension.
+var segmentMIMETypes = map[string]string{
+	".ts":   "video/mp2t",
+	".m4s":  "video/iso.segment",
+	".mp4":  "video/mp4",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ac3":  "audio/ac3",
+	".ec3":  "audio/eac3",
+	".mp3":  "audio/mpeg",
+	".vtt":  "text/vtt",
+	".key":  "application/octet-stream",
+	".m3u8": PlaylistMIMEType,
+}
+
+// SegmentMIMEType returns the Content-Type of a segment by its file extension or application/octet-stream.
+func SegmentMIMEType(name string) string {
+	if mimeType, ok := segmentMIMETypes[strings.ToLower(path.Ext(name))]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+// SegmentHandler is a http.Handler that serves the segments of a SegmentStore. The segment name is the request path
+// without the leading slash. Range and If-Range requests for byte-range playlists are handled by http.ServeContent.
+type SegmentHandler struct {
+	Store SegmentStore
+	// CORS is the CORS policy of the segments. CORS headers are not written if CORS is nil.
+	CORS *CORS
+}
+
+// NewSegmentHandler returns a new SegmentHandler.
+func NewSegmentHandler(store SegmentStore) *SegmentHandler {
+	return &SegmentHandler{
+		Store: store,
+	}
+}
+
+// ServeHTTP responds with the segment of the request. Missing segments are 404 Not Found and removed segments are
+// 410 Gone so caches and clients stop requesting them. Completed segments never change and are cached as immutable.
+// Playlists are never immutable, live playlists should be served by a PlaylistHandler. Keys are never cached by shared caches.
+func (sh *SegmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if sh.CORS != nil {
+		sh.CORS.SetHeaders(w.Header())
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	content, stat, err := sh.Store.Get(name)
+	switch {
+	case err == nil:
+	case errors.Is(err, SegmentNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, SegmentGone):
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", SegmentMIMEType(name))
+	switch {
+	case strings.EqualFold(path.Ext(name), ".key"):
+		header.Set("Cache-Control", "private, no-store")
+	case stat.Complete && !isPlaylist(name):
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+		header.Set("ETag", `"`+strconv.FormatInt(stat.Size, 16)+"-"+strconv.FormatInt(stat.ModTime.UnixNano(), 16)+`"`)
+	default:
+		header.Set("Cache-Control", "no-cache")
+	}
+	http.ServeContent(w, r, name, stat.ModTime, content)
+}
@@ -0,0 +1,117 @@
+package HLS_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+// partialSegmentStore is a MemorySegmentStore whose .m4s segments are still being written.
+type partialSegmentStore struct {
+	*HLS.MemorySegmentStore
+}
+
+func (ps partialSegmentStore) Get(name string) (io.ReadSeekCloser, HLS.SegmentStat, error) {
+	content, stat, err := ps.MemorySegmentStore.Get(name)
+	stat.Complete = !strings.HasSuffix(name, ".m4s")
+	return content, stat, err
+}
+
+// wrappingSegmentStore is a MemorySegmentStore that wraps its errors.
+type wrappingSegmentStore struct {
+	*HLS.MemorySegmentStore
+}
+
+func (ws wrappingSegmentStore) Get(name string) (io.ReadSeekCloser, HLS.SegmentStat, error) {
+	content, stat, err := ws.MemorySegmentStore.Get(name)
+	if err != nil {
+		err = fmt.Errorf("segment %s: %w", name, err)
+	}
+	return content, stat, err
+}
+
+func TestSegmentHandler(t *testing.T) {
+	store := partialSegmentStore{HLS.NewMemorySegmentStore()}
+	segments := map[string]int{"seg0.ts": 1000, "seg1.m4s": 10, "keys/1.key": 16, "keys/2.KEY": 16, "live.m3u8": 10, "old.ts": 10}
+	for name, size := range segments {
+		if err := store.Put(name, bytes.NewReader(bytes.Repeat([]byte{'a'}, size))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete("old.ts"); err != nil {
+		t.Fatal(err)
+	}
+	handler := HLS.NewSegmentHandler(store)
+
+	serve := func(uri string, header map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		for name, value := range header {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/seg0.ts", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.Len() != 1000 {
+		t.Fatal("Expected the segment but got", w.Code, w.Body.Len())
+	} else if contentType := w.Header().Get("Content-Type"); contentType != "video/mp2t" {
+		t.Fatal("Expected video/mp2t but got", contentType)
+	} else if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "public, max-age=31536000, immutable" {
+		t.Fatal("Expected a immutable segment but got", cacheControl)
+	} else if etag == "" {
+		t.Fatal("Expected a ETag")
+	}
+
+	{
+		w := serve("/seg0.ts", map[string]string{"Range": "bytes=100-199", "If-Range": etag})
+		if w.Code != http.StatusPartialContent || w.Body.Len() != 100 {
+			t.Fatal("Expected", http.StatusPartialContent, "but got", w.Code, w.Body.Len())
+		} else if contentRange := w.Header().Get("Content-Range"); contentRange != "bytes 100-199/1000" {
+			t.Fatal("Expected bytes 100-199/1000 but got", contentRange)
+		}
+
+		//A changed segment is sent completely.
+		if w := serve("/seg0.ts", map[string]string{"Range": "bytes=100-199", "If-Range": `"changed"`}); w.Code != http.StatusOK || w.Body.Len() != 1000 {
+			t.Fatal("Expected", http.StatusOK, "but got", w.Code, w.Body.Len())
+		}
+	}
+
+	if w := serve("/seg1.m4s", nil); w.Header().Get("Content-Type") != "video/iso.segment" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatal("Unexpected headers of a incomplete segment", w.Header())
+	} else if w := serve("/keys/1.key", nil); w.Header().Get("Cache-Control") != "private, no-store" {
+		t.Fatal("Unexpected headers of a key", w.Header())
+	} else if w := serve("/keys/2.KEY", nil); w.Header().Get("Cache-Control") != "private, no-store" {
+		t.Fatal("Unexpected headers of a key", w.Header())
+	} else if w := serve("/live.m3u8", nil); w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("Content-Type") != HLS.PlaylistMIMEType {
+		t.Fatal("Unexpected headers of a playlist", w.Header())
+	} else if w := serve("/missing.ts", nil); w.Code != http.StatusNotFound {
+		t.Fatal("Expected", http.StatusNotFound, "but got", w.Code)
+	} else if w := serve("/old.ts", nil); w.Code != http.StatusGone {
+		t.Fatal("Expected", http.StatusGone, "but got", w.Code)
+	}
+
+	//Wrapped errors are matched too.
+	handler.Store = wrappingSegmentStore{store.MemorySegmentStore}
+	if w := serve("/missing.ts", nil); w.Code != http.StatusNotFound {
+		t.Fatal("Expected", http.StatusNotFound, "but got", w.Code)
+	} else if w := serve("/old.ts", nil); w.Code != http.StatusGone {
+		t.Fatal("Expected", http.StatusGone, "but got", w.Code)
+	}
+
+	mimeTypes := map[string]string{"a.aac": "audio/aac", "a.vtt": "text/vtt", "init.mp4": "video/mp4", "a.bin": "application/octet-stream"}
+	for name, expected := range mimeTypes {
+		if mimeType := HLS.SegmentMIMEType(name); mimeType != expected {
+			t.Fatal("Expected", expected, "but got", mimeType)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package HLS
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	SegmentNotFound error = errors.New("Segment not found")
+	// SegmentGone is returned for segments that existed but were deleted e.g. because they slid out of a live window.
+	SegmentGone        error = errors.New("Segment was removed")
+	InvalidSegmentName error = errors.New("Invalid segment name")
+)
+
+// SegmentStat describes a stored segment.
+type SegmentStat struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Complete reports whether the content is final. It is false for playlists, for segments that replaced a segment
+	// with the same name and while the segment is still being written. e.g. a segment whose parts are still produced.
+	Complete bool
+}
+
+// SegmentStore stores segments, keys and other files of a stream by name. Names are slash separated relative paths
+// like segment URIs. A SegmentStore is shared by the packagers that write segments, the live window that deletes
+// them and the SegmentHandler that serves them, so implementations must be safe for concurrent use.
+// A SegmentStore is a SegmentWriter.
+type SegmentStore interface {
+	// Put stores the segment read from r with the name. A segment with the same name is replaced.
+	Put(name string, r io.Reader) error
+	// Get returns the content of the segment with the name. Get returns SegmentNotFound or SegmentGone if there is no segment.
+	Get(name string) (io.ReadSeekCloser, SegmentStat, error)
+	// Stat returns the stat of the segment with the name. Stat returns SegmentNotFound or SegmentGone if there is no segment.
+	Stat(name string) (SegmentStat, error)
+	// Delete deletes the segment with the name. Get and Stat return SegmentGone for recently deleted segments until they
+	// are put again. The stores of this package remember the last 1024 deleted segments.
+	Delete(name string) error
+	// List returns the stats of the segments whose name starts with prefix sorted by name.
+	List(prefix string) ([]SegmentStat, error)
+}
+
+// validSegmentName reports whether name is a clean relative slash separated path.
+func validSegmentName(name string) bool {
+	return name != "" && fs.ValidPath(name) && name != "."
+}
+
+// isPlaylist reports whether name is a playlist. Playlists are replaced while the stream is live, so they are never complete.
+func isPlaylist(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".m3u8")
+}
+
+// nameSet is a set of segment names that is safe for concurrent use.
+type nameSet struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+func (ns *nameSet) set(name string, member bool) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.names == nil {
+		ns.names = make(map[string]bool)
+	}
+	if member {
+		ns.names[name] = true
+	} else {
+		delete(ns.names, name)
+	}
+}
+
+func (ns *nameSet) has(name string) bool {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	return ns.names[name]
+}
+
+// maxTombstones is the number of deleted segments a store remembers. Older deleted segments are not found anymore.
+const maxTombstones = 1024
+
+// tombstones are the names of the recently deleted segments.
+type tombstones struct {
+	mu sync.Mutex
+	// names are the sequence numbers of the deletions by name.
+	names map[string]uint64
+	// order are the deletions in the order they happened. It can contain deletions that were undone by a Put.
+	order    []tombstone
+	sequence uint64
+}
+
+type tombstone struct {
+	name     string
+	sequence uint64
+}
+
+func (ts *tombstones) set(name string, deleted bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.names == nil {
+		ts.names = make(map[string]uint64)
+	}
+	if !deleted {
+		delete(ts.names, name)
+		return
+	}
+	ts.sequence++
+	ts.names[name] = ts.sequence
+	ts.order = append(ts.order, tombstone{name: name, sequence: ts.sequence})
+	for len(ts.order) > maxTombstones {
+		if oldest := ts.order[0]; ts.names[oldest.name] == oldest.sequence {
+			delete(ts.names, oldest.name)
+		}
+		ts.order = ts.order[1:]
+	}
+}
+
+// notFound returns SegmentGone if name was deleted and SegmentNotFound otherwise.
+func (ts *tombstones) notFound(name string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if _, ok := ts.names[name]; ok {
+		return SegmentGone
+	}
+	return SegmentNotFound
+}
+
+// MemorySegmentStore is a SegmentStore that keeps the segments in memory. It is meant for tests and short live streams.
+type MemorySegmentStore struct {
+	mu       sync.RWMutex
+	segments map[string]memorySegment
+	deleted  tombstones
+}
+
+type memorySegment struct {
+	data    []byte
+	modTime time.Time
+	// replaced is true if the segment replaced a segment with the same name.
+	replaced bool
+}
+
+// NewMemorySegmentStore returns a new empty MemorySegmentStore.
+func NewMemorySegmentStore() *MemorySegmentStore {
+	return &MemorySegmentStore{
+		segments: make(map[string]memorySegment),
+	}
+}
+
+func (ms *MemorySegmentStore) Put(name string, r io.Reader) error {
+	if !validSegmentName(name) {
+		return InvalidSegmentName
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	_, replaced := ms.segments[name]
+	ms.segments[name] = memorySegment{data: data, modTime: time.Now(), replaced: replaced}
+	ms.deleted.set(name, false)
+	return nil
+}
+
+func (ms *MemorySegmentStore) Get(name string) (io.ReadSeekCloser, SegmentStat, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	segment, ok := ms.segments[name]
+	if !ok {
+		return nil, SegmentStat{}, ms.deleted.notFound(name)
+	}
+	//The data is never modified because Put replaces the whole segment.
+	return readSeekNopCloser{bytes.NewReader(segment.data)}, segment.stat(name), nil
+}
+
+func (ms *MemorySegmentStore) Stat(name string) (SegmentStat, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	segment, ok := ms.segments[name]
+	if !ok {
+		return SegmentStat{}, ms.deleted.notFound(name)
+	}
+	return segment.stat(name), nil
+}
+
+func (ms *MemorySegmentStore) Delete(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if _, ok := ms.segments[name]; !ok {
+		return ms.deleted.notFound(name)
+	}
+	delete(ms.segments, name)
+	ms.deleted.set(name, true)
+	return nil
+}
+
+func (ms *MemorySegmentStore) List(prefix string) ([]SegmentStat, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	stats := make([]SegmentStat, 0, len(ms.segments))
+	for name, segment := range ms.segments {
+		if strings.HasPrefix(name, prefix) {
+			stats = append(stats, segment.stat(name))
+		}
+	}
+	sortStats(stats)
+	return stats, nil
+}
+
+func (segment *memorySegment) stat(name string) SegmentStat {
+	return SegmentStat{Name: name, Size: int64(len(segment.data)), ModTime: segment.modTime, Complete: !segment.replaced && !isPlaylist(name)}
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
+func sortStats(stats []SegmentStat) {
+	slices.SortFunc(stats, func(a, b SegmentStat) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// DirSegmentStore is a SegmentStore that stores every segment as a file in Dir.
+// Segments are written to a temporary file and renamed so a segment is never served while it is written.
+type DirSegmentStore struct {
+	Dir     string
+	deleted tombstones
+	// replaced are the names of segments that replaced a file with the same name.
+	replaced nameSet
+}
+
+// NewDirSegmentStore returns a new DirSegmentStore. The directory is created when the first segment is put.
+func NewDirSegmentStore(dir string) *DirSegmentStore {
+	return &DirSegmentStore{
+		Dir: dir,
+	}
+}
+
+func (ds *DirSegmentStore) path(name string) string {
+	return filepath.Join(ds.Dir, filepath.FromSlash(name))
+}
+
+func (ds *DirSegmentStore) Put(name string, r io.Reader) error {
+	if !validSegmentName(name) {
+		return InvalidSegmentName
+	}
+	p := ds.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(p), "."+path.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	} else if err := file.Close(); err != nil {
+		return err
+	} else if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	_, err = os.Stat(p)
+	replaced := err == nil
+	if err := os.Rename(file.Name(), p); err != nil {
+		return err
+	}
+	ds.replaced.set(name, replaced)
+	ds.deleted.set(name, false)
+	return nil
+}
+
+func (ds *DirSegmentStore) Get(name string) (io.ReadSeekCloser, SegmentStat, error) {
+	if !validSegmentName(name) {
+		return nil, SegmentStat{}, SegmentNotFound
+	}
+	file, err := os.Open(ds.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, SegmentStat{}, ds.deleted.notFound(name)
+	} else if err != nil {
+		return nil, SegmentStat{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, SegmentStat{}, err
+	} else if info.IsDir() {
+		file.Close()
+		return nil, SegmentStat{}, SegmentNotFound
+	}
+	return file, ds.fileStat(name, info), nil
+}
+
+func (ds *DirSegmentStore) Stat(name string) (SegmentStat, error) {
+	if !validSegmentName(name) {
+		return SegmentStat{}, SegmentNotFound
+	}
+	info, err := os.Stat(ds.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return SegmentStat{}, ds.deleted.notFound(name)
+	} else if err != nil {
+		return SegmentStat{}, err
+	} else if info.IsDir() {
+		return SegmentStat{}, SegmentNotFound
+	}
+	return ds.fileStat(name, info), nil
+}
+
+func (ds *DirSegmentStore) Delete(name string) error {
+	if !validSegmentName(name) {
+		return SegmentNotFound
+	}
+	err := os.Remove(ds.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ds.deleted.notFound(name)
+	} else if err != nil {
+		return err
+	}
+	ds.replaced.set(name, false)
+	ds.deleted.set(name, true)
+	return nil
+}
+
+// List walks Dir and returns the stats of the files whose name starts with prefix. Temporary files are skipped.
+func (ds *DirSegmentStore) List(prefix string) ([]SegmentStat, error) {
+	stats := make([]SegmentStat, 0)
+	err := filepath.WalkDir(ds.Dir, func(p string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == ds.Dir {
+			return filepath.SkipAll
+		} else if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(ds.Dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		stats = append(stats, ds.fileStat(name, info))
+		return nil
+	})
+	sortStats(stats)
+	return stats, err
+}
+
+func (ds *DirSegmentStore) fileStat(name string, info fs.FileInfo) SegmentStat {
+	return SegmentStat{Name: name, Size: info.Size(), ModTime: info.ModTime(), Complete: !ds.replaced.has(name) && !isPlaylist(name)}
+}
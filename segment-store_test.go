@@ -0,0 +1,113 @@
+package HLS_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestSegmentStores(t *testing.T) {
+	stores := map[string]HLS.SegmentStore{
+		"memory": HLS.NewMemorySegmentStore(),
+		"dir":    HLS.NewDirSegmentStore(t.TempDir() + "/segments"),
+	}
+	for storeName, store := range stores {
+		if stats, err := store.List(""); err != nil || len(stats) != 0 {
+			t.Fatal(storeName, "Expected a empty store but got", stats, err)
+		}
+
+		for _, name := range []string{"720p/seg1.ts", "720p/seg0.ts", "480p/seg0.ts"} {
+			if err := store.Put(name, strings.NewReader("segment "+name)); err != nil {
+				t.Fatal(storeName, err)
+			}
+		}
+		if err := store.Put("../escape.ts", strings.NewReader("")); err != HLS.InvalidSegmentName {
+			t.Fatal(storeName, "Expected", HLS.InvalidSegmentName, "but got", err)
+		}
+
+		content, stat, err := store.Get("720p/seg0.ts")
+		if err != nil {
+			t.Fatal(storeName, err)
+		}
+		b, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			t.Fatal(storeName, err)
+		} else if string(b) != "segment 720p/seg0.ts" || stat.Size != int64(len(b)) || !stat.Complete || stat.Name != "720p/seg0.ts" {
+			t.Fatal(storeName, "Unexpected segment", string(b), stat)
+		}
+
+		stats, err := store.List("720p/")
+		if err != nil {
+			t.Fatal(storeName, err)
+		} else if len(stats) != 2 || stats[0].Name != "720p/seg0.ts" || stats[1].Name != "720p/seg1.ts" {
+			t.Fatal(storeName, "Unexpected list", stats)
+		}
+
+		if err := store.Delete("720p/seg0.ts"); err != nil {
+			t.Fatal(storeName, err)
+		} else if _, err := store.Stat("720p/seg0.ts"); err != HLS.SegmentGone {
+			t.Fatal(storeName, "Expected", HLS.SegmentGone, "but got", err)
+		} else if _, _, err := store.Get("720p/missing.ts"); err != HLS.SegmentNotFound {
+			t.Fatal(storeName, "Expected", HLS.SegmentNotFound, "but got", err)
+		} else if err := store.Delete("720p/missing.ts"); err != HLS.SegmentNotFound {
+			t.Fatal(storeName, "Expected", HLS.SegmentNotFound, "but got", err)
+		}
+
+		//A segment that is put again is not gone anymore.
+		if err := store.Put("720p/seg0.ts", strings.NewReader("new")); err != nil {
+			t.Fatal(storeName, err)
+		} else if stat, err := store.Stat("720p/seg0.ts"); err != nil || stat.Size != 3 {
+			t.Fatal(storeName, "Unexpected stat", stat, err)
+		}
+		if stats, err := store.List(""); err != nil || len(stats) != 3 {
+			t.Fatal(storeName, "Expected 3 segments but got", stats, err)
+		}
+
+		//Only the recently deleted segments are gone, older ones are not found anymore.
+		for i := range 1100 {
+			name := fmt.Sprintf("old/seg%d.ts", i)
+			if err := store.Put(name, strings.NewReader("old")); err != nil {
+				t.Fatal(storeName, err)
+			} else if err := store.Delete(name); err != nil {
+				t.Fatal(storeName, err)
+			}
+		}
+		if _, err := store.Stat("old/seg0.ts"); err != HLS.SegmentNotFound {
+			t.Fatal(storeName, "Expected", HLS.SegmentNotFound, "but got", err)
+		} else if _, err := store.Stat("old/seg1099.ts"); err != HLS.SegmentGone {
+			t.Fatal(storeName, "Expected", HLS.SegmentGone, "but got", err)
+		}
+
+		//Replaced segments and playlists can still change.
+		if stat, err := store.Stat("720p/seg0.ts"); err != nil || !stat.Complete {
+			t.Fatal(storeName, "Expected a segment put after it was deleted to be complete", stat, err)
+		}
+		for _, name := range []string{"720p/seg1.ts", "720p/index.m3u8"} {
+			if err := store.Put(name, strings.NewReader("new")); err != nil {
+				t.Fatal(storeName, err)
+			} else if stat, err := store.Stat(name); err != nil || stat.Complete {
+				t.Fatal(storeName, "Expected", name, "not to be complete but got", stat, err)
+			}
+		}
+	}
+}
+
+func TestSegmenter_SegmentStore(t *testing.T) {
+	store := HLS.NewMemorySegmentStore()
+	segmenter := HLS.NewSegmenter(store, 4*time.Second)
+	mediaPlaylist, err := segmenter.Segment(bytes.NewReader(exampleStream(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, segment := range mediaPlaylist.Segments {
+		if _, err := store.Stat(segment.URI); err != nil {
+			t.Fatal(segment.URI, err)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package HLS
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// SegmentWriter stores the segments produced by a Segmenter.
+type SegmentWriter interface {
+	// Put stores the segment read from r with the name.
+	Put(name string, r io.Reader) error
+}
+
+// SegmentWriterFunc is a function that implements SegmentWriter.
+type SegmentWriterFunc func(name string, r io.Reader) error
+
+// Put calls f(name, r).
+func (f SegmentWriterFunc) Put(name string, r io.Reader) error {
+	return f(name, r)
+}
+
+// Segmenter cuts a continuous MPEG-TS stream into Media Segments.
+// Segments are cut at the first keyframe after TargetDuration and every segment starts with a PAT and a PMT.
+type Segmenter struct {
+	// TargetDuration is the duration after which a segment is cut at the next keyframe.
+	TargetDuration time.Duration
+	Writer         SegmentWriter
+	// SegmentName returns the name of the segment with the media sequence number. The name is used as the segment URI.
+	// If SegmentName is nil segments are named seg000.ts, seg001.ts and so on.
+	SegmentName func(sequenceNumber uint64) string
+	// MediaSequence is the media sequence number of the first segment.
+	MediaSequence uint64
+	// Encrypter encrypts every segment before it is written and provides the EXT-X-KEY tags of the segment. Segments are not encrypted if Encrypter is nil.
+	Encrypter SegmentEncrypter
+}
+
+// NewSegmenter returns a new Segmenter that writes segments to w.
+func NewSegmenter(w SegmentWriter, targetDuration time.Duration) Segmenter {
+	return Segmenter{
+		TargetDuration: targetDuration,
+		Writer:         w,
+	}
+}
+
+func (s *Segmenter) segmentName(sequenceNumber uint64) string {
+	if s.SegmentName != nil {
+		return s.SegmentName(sequenceNumber)
+	}
+	return fmt.Sprintf("seg%03d.ts", sequenceNumber)
+}
+
+// Segment reads a MPEG-TS stream from r until io.EOF, writes the segments to s.Writer and returns a VOD Media Playlist of the segments.
+// The EXTINF durations are computed from the timestamps of the stream and EXT-X-TARGETDURATION is computed from the durations.
+func (s *Segmenter) Segment(r io.Reader) (MediaPlaylist, error) {
+	mediaPlaylist := MediaPlaylist{
+		MediaSequence:       s.MediaSequence,
+		PlaylistType:        VOD,
+		IndependentSegments: true,
+		EndList:             true,
+	}
+
+	packetReader := mpegts.NewPacketReader(r)
+	cutter := newTSCutter(s.TargetDuration)
+	var pending bytes.Buffer
+	var pendingOffset int64
+	var pat, pmt []byte
+
+	write := func(cuts []tsCut) error {
+		for _, cut := range cuts {
+			data := pending.Bytes()[cut.offset-pendingOffset : cut.offset-pendingOffset+cut.size]
+			segment := make([]byte, 0, len(data)+2*mpegts.PacketSize)
+			if len(data) < mpegts.PacketSize || pid(data) != 0 {
+				segment = append(append(segment, pat...), pmt...)
+			}
+			segment = append(segment, data...)
+
+			sequenceNumber := s.MediaSequence + uint64(len(mediaPlaylist.Segments))
+			name := s.segmentName(sequenceNumber)
+			var r io.Reader = bytes.NewReader(segment)
+			var keys []Key
+			if s.Encrypter != nil {
+				var err error
+				if r, keys, err = s.Encrypter.EncryptSegment(sequenceNumber, r); err != nil {
+					return err
+				}
+			}
+			if err := s.Writer.Put(name, r); err != nil {
+				return err
+			}
+			pending.Next(int(cut.offset + cut.size - pendingOffset))
+			pendingOffset = cut.offset + cut.size
+
+			mediaPlaylist.Segments = append(mediaPlaylist.Segments, MediaSegment{
+				URI:           name,
+				Duration:      cut.duration,
+				Discontinuity: cut.discontinuity,
+				Keys:          keys,
+			})
+			mediaPlaylist.IndependentSegments = mediaPlaylist.IndependentSegments && cut.keyframe
+		}
+		return nil
+	}
+
+	for {
+		packet, err := packetReader.Advance()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return mediaPlaylist, err
+		}
+		pending.Write(packet.Raw)
+
+		if packet.PID == 0 && packet.PayloadUnitStart {
+			pat = append(pat[:0], packet.Raw...)
+		} else if cutter.demuxer.IsPSI(packet.PID) && packet.PayloadUnitStart {
+			pmt = append(pmt[:0], packet.Raw...)
+		}
+
+		cuts, err := cutter.push(packet)
+		if err != nil {
+			return mediaPlaylist, err
+		} else if err := write(cuts); err != nil {
+			return mediaPlaylist, err
+		}
+	}
+
+	cuts, err := cutter.flush()
+	if err != nil {
+		return mediaPlaylist, err
+	} else if err := write(cuts); err != nil {
+		return mediaPlaylist, err
+	}
+	mediaPlaylist.TargetDuration = mediaPlaylist.ComputeTargetDuration()
+	return mediaPlaylist, nil
+}
+
+// pid returns the PID of the transport stream packet at the beginning of b.
+func pid(b []byte) uint16 {
+	return uint16(b[1]&0x1F)<<8 | uint16(b[2])
+}
+
+// tsCut is a segment of a transport stream.
+type tsCut struct {
+	offset int64
+	size   int64
+	// duration is the duration of the segment in seconds.
+	duration      float64
+	discontinuity bool
+	keyframe      bool
+	// psi is true if the segment starts with PSI packets.
+	psi bool
+}
+
+// tsCutter finds the positions at which a transport stream is split into segments.
+// Segments start at a keyframe of the reference stream. If the keyframe PES packet is directly preceded by PSI packets the segment starts at the PSI packets.
+type tsCutter struct {
+	target  int64
+	demuxer *mpegts.Demuxer
+
+	// psiStart is the offset of the first PSI packet of the current run of PSI packets or -1.
+	psiStart int64
+	// cutOffsets maps the offset of reference PES packets to the offset a segment starting with the PES packet starts at.
+	cutOffsets map[int64]int64
+
+	started       bool
+	start         int64
+	keyframe      bool
+	discontinuity bool
+	psi           bool
+	pts           []int64
+	end           int64
+
+	// keyframes are the keyframes of the reference stream. runEnds maps the index of the last keyframe in front of a discontinuity
+	// or the end of the stream to the PTS after the last frame in front of it.
+	keyframes []mpegts.Keyframe
+	runEnds   map[int]int64
+	endPTS    int64
+	// psiRange is the byte range of the first PAT directly followed by a PMT or nil.
+	psiRange *ByteRange
+	patStart int64
+}
+
+func newTSCutter(targetDuration time.Duration) *tsCutter {
+	return &tsCutter{
+		target:     int64(targetDuration * mpegts.ClockRate / time.Second),
+		demuxer:    mpegts.NewDemuxer(),
+		psiStart:   -1,
+		cutOffsets: make(map[int64]int64),
+		runEnds:    make(map[int]int64),
+		patStart:   -1,
+	}
+}
+
+func (tc *tsCutter) reference() (mpegts.ElementaryStream, bool) {
+	return mpegts.ReferenceStream(tc.demuxer.Streams())
+}
+
+func (tc *tsCutter) push(packet mpegts.Packet) ([]tsCut, error) {
+	tc.end = packet.Offset + mpegts.PacketSize
+	if packet.Offset == 0 {
+		tc.psi = packet.PID == 0 || packet.PID == 0x11
+	}
+	if tc.psiRange == nil && packet.PayloadUnitStart {
+		if packet.PID == 0 {
+			tc.patStart = packet.Offset
+		} else if tc.patStart == packet.Offset-mpegts.PacketSize && packet.PID != 0x11 && tc.demuxer.IsPSI(packet.PID) {
+			tc.psiRange = &ByteRange{Length: 2 * mpegts.PacketSize, Offset: tc.patStart}
+		}
+	}
+	//PID 0x11 carries the SDT which is written in front of the PAT by common muxers.
+	if packet.PID == 0x11 || tc.demuxer.IsPSI(packet.PID) {
+		if tc.psiStart < 0 {
+			tc.psiStart = packet.Offset
+		}
+	} else if packet.PID != mpegts.NullPID {
+		if reference, ok := tc.reference(); ok && packet.PID == reference.PID && packet.PayloadUnitStart {
+			if tc.psiStart >= 0 {
+				tc.cutOffsets[packet.Offset] = tc.psiStart
+			} else {
+				tc.cutOffsets[packet.Offset] = packet.Offset
+			}
+		}
+		tc.psiStart = -1
+	}
+
+	completed, err := tc.demuxer.Push(packet)
+	if err != nil {
+		return nil, err
+	}
+	return tc.process(completed), nil
+}
+
+func (tc *tsCutter) flush() ([]tsCut, error) {
+	completed, err := tc.demuxer.Flush()
+	if err != nil {
+		return nil, err
+	}
+	cuts := tc.process(completed)
+	if tc.started && tc.end > tc.start {
+		cuts = append(cuts, tc.cut(tc.end, -1, false))
+		if len(tc.keyframes) > 0 {
+			tc.runEnds[len(tc.keyframes)-1] = tc.endPTS
+		}
+	}
+	return cuts, nil
+}
+
+func (tc *tsCutter) process(completed []mpegts.PES) []tsCut {
+	reference, ok := tc.reference()
+	if !ok {
+		return nil
+	}
+
+	cuts := make([]tsCut, 0)
+	for _, pes := range completed {
+		if pes.PID != reference.PID || !pes.HasPTS {
+			continue
+		}
+		offset, ok := tc.cutOffsets[pes.Offset]
+		if !ok {
+			offset = pes.Offset
+		}
+		delete(tc.cutOffsets, pes.Offset)
+
+		if !tc.started {
+			tc.started = true
+			tc.keyframe = pes.Keyframe
+		} else if pes.Keyframe && len(tc.pts) > 0 && (pes.Discontinuity || pes.PTS-slices.Min(tc.pts) >= tc.target) {
+			next := pes.PTS
+			if pes.Discontinuity {
+				next = -1
+			}
+			cuts = append(cuts, tc.cut(offset, next, pes.Discontinuity))
+			tc.psi = offset != pes.Offset
+			if pes.Discontinuity && len(tc.keyframes) > 0 {
+				tc.runEnds[len(tc.keyframes)-1] = tc.endPTS
+			}
+		}
+		if pes.Keyframe && pes.StreamType.IsVideo() {
+			tc.keyframes = append(tc.keyframes, mpegts.Keyframe{
+				Offset: pes.Offset,
+				End:    pes.End,
+				PTS:    pes.PTS,
+			})
+		}
+		tc.pts = append(tc.pts, pes.PTS)
+	}
+	return cuts
+}
+
+// cut ends the current segment at offset and starts the next segment. next is the PTS of the first frame of the next segment or -1 if it does not follow the current segment.
+func (tc *tsCutter) cut(offset int64, next int64, discontinuity bool) tsCut {
+	var duration float64
+	if len(tc.pts) > 0 {
+		slices.Sort(tc.pts)
+		end := next
+		if next < 0 {
+			end = tc.pts[len(tc.pts)-1] + mpegts.FrameDuration(tc.pts)
+		}
+		duration = float64(end-tc.pts[0]) / mpegts.ClockRate
+		tc.endPTS = end
+	}
+
+	cut := tsCut{
+		offset:        tc.start,
+		size:          offset - tc.start,
+		duration:      roundSeconds(duration),
+		discontinuity: tc.discontinuity,
+		keyframe:      tc.keyframe,
+		psi:           tc.psi,
+	}
+	tc.start = offset
+	tc.pts = tc.pts[:0]
+	tc.keyframe = true
+	tc.discontinuity = discontinuity
+	return cut
+}
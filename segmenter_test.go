@@ -0,0 +1,80 @@
+package HLS_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+	"github.com/udan-jayanith/HLS/mpegts"
+)
+
+// exampleStream returns the segments of the serving-a-video example concatenated into one continuous stream.
+func exampleStream(t *testing.T) []byte {
+	t.Helper()
+	var stream []byte
+	for i := range 6 {
+		b, err := os.ReadFile(fmt.Sprintf("examples/serving-a-video/video-fragments/seg%03d.ts", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = append(stream, b...)
+	}
+	return stream
+}
+
+func TestSegmenter(t *testing.T) {
+	segments := make(map[string][]byte)
+	segmenter := HLS.NewSegmenter(HLS.SegmentWriterFunc(func(name string, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		segments[name] = b
+		return err
+	}), 4*time.Second)
+
+	mediaPlaylist, err := segmenter.Segment(bytes.NewReader(exampleStream(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mediaPlaylist.Segments) < 6 {
+		t.Fatal("Expected at least 6 segments but got", len(mediaPlaylist.Segments))
+	} else if len(segments) != len(mediaPlaylist.Segments) {
+		t.Fatal("Expected", len(mediaPlaylist.Segments), "written segments but got", len(segments))
+	} else if !mediaPlaylist.IndependentSegments || !mediaPlaylist.EndList || mediaPlaylist.PlaylistType != HLS.VOD {
+		t.Fatal("Unexpected media playlist", mediaPlaylist)
+	} else if mediaPlaylist.TargetDuration != mediaPlaylist.ComputeTargetDuration() {
+		t.Fatal("Expected target duration", mediaPlaylist.ComputeTargetDuration(), "but got", mediaPlaylist.TargetDuration)
+	}
+
+	var total float64
+	for i, segment := range mediaPlaylist.Segments {
+		if segment.URI != fmt.Sprintf("seg%03d.ts", i) {
+			t.Fatal("Unexpected segment URI", segment.URI)
+		}
+		b := segments[segment.URI]
+		if pid := uint16(b[1]&0x1F)<<8 | uint16(b[2]); pid != 0 {
+			t.Fatal("Expected segment", segment.URI, "to start with a PAT but got PID", pid)
+		}
+
+		info, err := mpegts.Inspect(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		} else if !info.StartsWithKeyframe {
+			t.Fatal("Expected segment", segment.URI, "to start with a keyframe")
+		} else if math.Abs(info.Duration.Seconds()-segment.Duration) > 0.000001 {
+			t.Fatal("Expected duration", info.Duration.Seconds(), "but got", segment.Duration)
+		} else if i < len(mediaPlaylist.Segments)-1 && segment.Duration < 4 {
+			t.Fatal("Expected segment", segment.URI, "to be at least 4 seconds but got", segment.Duration)
+		}
+		total += segment.Duration
+	}
+
+	//The durations of the example playlist add up to 59.966667 seconds.
+	if math.Abs(total-59.966667) > 0.00001 {
+		t.Fatal("Expected a total duration of 59.966667 but got", total)
+	}
+}
@@ -0,0 +1,75 @@
+package HLS
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	InvalidSessionData error = errors.New("Invalid EXT-X-SESSION-DATA tag")
+)
+
+// SessionData is a EXT-X-SESSION-DATA tag which carries session-level data in a Master Playlist.
+// Exactly one of Value and URI must be set. URI can be a DataURI e.g. of a JSON document.
+type SessionData struct {
+	// DataID identifies the data. It should use reverse DNS naming. e.g. "com.example.movie.title"
+	DataID   string
+	Value    string
+	URI      string
+	Language string
+}
+
+// ToHLSTag returns the EXT-X-SESSION-DATA tag of the session data.
+func (sd *SessionData) ToHLSTag() HLSTag {
+	attributes := []string{"DATA-ID=" + WrapQuotes(sd.DataID)}
+	if sd.URI != "" {
+		attributes = append(attributes, "URI="+WrapQuotes(sd.URI))
+	} else {
+		attributes = append(attributes, "VALUE="+WrapQuotes(sd.Value))
+	}
+	if sd.Language != "" {
+		attributes = append(attributes, "LANGUAGE="+WrapQuotes(sd.Language))
+	}
+	return HLSTag{
+		TagName: EXT_X_SESSION_DATA,
+		Value:   strings.Join(attributes, ","),
+	}
+}
+
+// valid reports whether the session data has a DATA-ID and exactly one of Value and URI.
+func (sd *SessionData) valid() bool {
+	return sd.DataID != "" && (sd.Value == "") != (sd.URI == "")
+}
+
+// ParseSessionData parses a EXT-X-SESSION-DATA tag.
+func ParseSessionData(tag HLSTag) (SessionData, error) {
+	sessionData := SessionData{}
+	if tag.TagName != EXT_X_SESSION_DATA {
+		return sessionData, InvalidSessionData
+	}
+	attributes, err := ParseAttributeList(tag.Value)
+	if err != nil {
+		return sessionData, InvalidSessionData
+	}
+
+	for name, value := range attributes {
+		value, ok := unquote(value)
+		if !ok {
+			return sessionData, InvalidSessionData
+		}
+		switch name {
+		case "DATA-ID":
+			sessionData.DataID = value
+		case "VALUE":
+			sessionData.Value = value
+		case "URI":
+			sessionData.URI = value
+		case "LANGUAGE":
+			sessionData.Language = value
+		}
+	}
+	if !sessionData.valid() {
+		return sessionData, InvalidSessionData
+	}
+	return sessionData, nil
+}
@@ -0,0 +1,58 @@
+package HLS_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+func TestMasterPlaylistEncode_SessionData(t *testing.T) {
+	dataURI := HLS.DataURI{MediaType: "application/json", Data: []byte(`{"title": "Big Buck Bunny"}`)}
+	masterPlaylist := HLS.MasterPlaylist{
+		SessionData: []HLS.SessionData{
+			{DataID: "com.example.title", Value: "This is an example", Language: "en"},
+			{DataID: "com.example.metadata", URI: dataURI.String()},
+		},
+		Variants: []HLS.VariantStream{{URI: "video.m3u8", Bandwidth: 1000000}},
+	}
+	playlist, err := masterPlaylist.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(&playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#EXTM3U
+#EXT-X-VERSION:1
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="This is an example",LANGUAGE="en"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.metadata",URI="data:application/json,{%22title%22:%20%22Big%20Buck%20Bunny%22}"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000
+video.m3u8
+`
+	if string(b) != expected {
+		t.Fatal("Expected", expected, "but got", string(b))
+	}
+
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines[2:4] {
+		tag, err := HLS.ParseHLSTag(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessionData, err := HLS.ParseSessionData(tag)
+		if err != nil {
+			t.Fatal(err)
+		} else if sessionData != masterPlaylist.SessionData[i] {
+			t.Fatal("Expected", masterPlaylist.SessionData[i], "but got", sessionData)
+		}
+	}
+
+	masterPlaylist.SessionData = []HLS.SessionData{{DataID: "com.example.title"}}
+	if _, err := masterPlaylist.Encode(); err != HLS.InvalidSessionData {
+		t.Fatal("Expected", HLS.InvalidSessionData, "but got", err)
+	}
+}
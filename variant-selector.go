@@ -0,0 +1,182 @@
+package HLS
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+)
+
+var (
+	NoPlayableVariant error = errors.New("No variant stream can be played by the device")
+)
+
+// PlaybackState is the state of a player a VariantSelector bases its decision on.
+type PlaybackState struct {
+	// Throughput is the measured download throughput of the recent segments in bits per second, the oldest first.
+	Throughput []float64
+	// Buffer is the duration of the media buffered in front of the playhead.
+	Buffer time.Duration
+	// Current is the index of the variant stream that is played or -1 before playback starts.
+	Current int
+}
+
+// DeviceConstraints are the capabilities of the device that plays the stream.
+type DeviceConstraints struct {
+	// MaxResolution is the largest video resolution the device can display. If MaxResolution is nil the resolution is not limited.
+	MaxResolution *Resolution
+	// SupportsCodec reports whether the device can decode the format. e.g. "avc1.640028"
+	// If SupportsCodec is nil every format is supported.
+	SupportsCodec func(codec string) bool
+	// HDCPLevel is the highest HDCP-LEVEL the output of the device supports. An empty level is NONE.
+	HDCPLevel HDCPLevel
+}
+
+// Plays reports whether the device can play variant. Variant streams without RESOLUTION or CODECS are assumed to be playable,
+// variant streams with a unknown HDCP-LEVEL are not.
+func (dc *DeviceConstraints) Plays(variant VariantStream) bool {
+	if dc.MaxResolution != nil && variant.Resolution != nil &&
+		(variant.Resolution.Width > dc.MaxResolution.Width || variant.Resolution.Height > dc.MaxResolution.Height) {
+		return false
+	}
+	if dc.SupportsCodec != nil && slices.ContainsFunc(variant.Codecs, func(codec string) bool { return !dc.SupportsCodec(codec) }) {
+		return false
+	}
+	return variant.HDCPLevel.rank() <= min(dc.HDCPLevel.rank(), TYPE_1.rank())
+}
+
+// playableVariants returns the indexes of the variant streams of mp the device can play sorted by BANDWIDTH.
+func playableVariants(mp MasterPlaylist, device DeviceConstraints) []int {
+	playable := make([]int, 0, len(mp.Variants))
+	for i, variant := range mp.Variants {
+		if device.Plays(variant) {
+			playable = append(playable, i)
+		}
+	}
+	slices.SortStableFunc(playable, func(a, b int) int {
+		return mp.Variants[a].Bandwidth - mp.Variants[b].Bandwidth
+	})
+	return playable
+}
+
+// VariantSelector selects the variant stream of a Master Playlist a player loads next.
+type VariantSelector interface {
+	// SelectVariant returns the index of the variant stream of mp to play.
+	// SelectVariant returns NoPlayableVariant if the device cannot play any variant stream.
+	SelectVariant(mp MasterPlaylist, state PlaybackState, device DeviceConstraints) (int, error)
+}
+
+// VariantSelectorFunc is a function that implements VariantSelector.
+type VariantSelectorFunc func(mp MasterPlaylist, state PlaybackState, device DeviceConstraints) (int, error)
+
+// SelectVariant calls f(mp, state, device).
+func (f VariantSelectorFunc) SelectVariant(mp MasterPlaylist, state PlaybackState, device DeviceConstraints) (int, error) {
+	return f(mp, state, device)
+}
+
+// ThroughputSelector selects the variant stream with the highest BANDWIDTH that fits into the estimated throughput.
+// The throughput is estimated as the harmonic mean of the recent samples, which keeps single fast downloads from
+// raising the estimate. Without samples the current variant stream is kept and the lowest one is selected at startup.
+type ThroughputSelector struct {
+	// Samples is the number of recent throughput samples the estimate is based on. If Samples is 0 every sample is used.
+	Samples int
+	// SafetyFactor is the share of the estimated throughput the BANDWIDTH of the selected variant stream may use.
+	SafetyFactor float64
+}
+
+// NewThroughputSelector returns a new ThroughputSelector that estimates from the last 5 samples and uses 80% of the throughput.
+func NewThroughputSelector() *ThroughputSelector {
+	return &ThroughputSelector{
+		Samples:      5,
+		SafetyFactor: 0.8,
+	}
+}
+
+// EstimateThroughput returns the harmonic mean of the last samples of throughput. It returns 0 if there are no samples.
+func (ts *ThroughputSelector) EstimateThroughput(throughput []float64) float64 {
+	if ts.Samples > 0 && len(throughput) > ts.Samples {
+		throughput = throughput[len(throughput)-ts.Samples:]
+	}
+	sum := 0.0
+	for _, sample := range throughput {
+		if sample <= 0 {
+			return 0
+		}
+		sum += 1 / sample
+	}
+	if sum == 0 {
+		return 0
+	}
+	return float64(len(throughput)) / sum
+}
+
+func (ts *ThroughputSelector) SelectVariant(mp MasterPlaylist, state PlaybackState, device DeviceConstraints) (int, error) {
+	playable := playableVariants(mp, device)
+	if len(playable) == 0 {
+		return -1, NoPlayableVariant
+	}
+	estimate := ts.EstimateThroughput(state.Throughput)
+	if estimate == 0 {
+		if slices.Contains(playable, state.Current) {
+			return state.Current, nil
+		}
+		return playable[0], nil
+	}
+
+	selected := playable[0]
+	for _, i := range playable[1:] {
+		if float64(mp.Variants[i].Bandwidth) <= estimate*ts.SafetyFactor {
+			selected = i
+		}
+	}
+	return selected, nil
+}
+
+// BufferSelector is a buffer based VariantSelector like BOLA (Spiteri, Urgaonkar and Sitaraman, 2016).
+// The utility of a variant stream is one plus the logarithm of its BANDWIDTH relative to the lowest one. The variant stream that
+// maximizes (V·(utility + γp) - buffer) / BANDWIDTH is selected, so the lowest variant stream is played while the buffer
+// is below MinimumBuffer and the highest one once the buffer reaches StableBuffer. The throughput is not used.
+type BufferSelector struct {
+	// MinimumBuffer is the buffer level up to which the lowest variant stream is selected.
+	MinimumBuffer time.Duration
+	// StableBuffer is the buffer level from which the highest variant stream is selected. It must be above MinimumBuffer.
+	StableBuffer time.Duration
+}
+
+// NewBufferSelector returns a new BufferSelector with a minimum buffer of 10 seconds and a stable buffer of 30 seconds.
+func NewBufferSelector() *BufferSelector {
+	return &BufferSelector{
+		MinimumBuffer: 10 * time.Second,
+		StableBuffer:  30 * time.Second,
+	}
+}
+
+func (bs *BufferSelector) SelectVariant(mp MasterPlaylist, state PlaybackState, device DeviceConstraints) (int, error) {
+	playable := playableVariants(mp, device)
+	if len(playable) == 0 {
+		return -1, NoPlayableVariant
+	}
+	lowest := float64(max(mp.Variants[playable[0]].Bandwidth, 1))
+	utility := func(i int) float64 {
+		return math.Log(float64(max(mp.Variants[i].Bandwidth, 1))/lowest) + 1
+	}
+	highestUtility := utility(playable[len(playable)-1])
+	if highestUtility == 1 || bs.StableBuffer <= bs.MinimumBuffer {
+		return playable[len(playable)-1], nil
+	}
+
+	//V·γp is MinimumBuffer, so the lowest variant stream has the highest score up to it, and V·(utility + γp) of the
+	//highest variant stream is StableBuffer, so every other variant stream has a lower score from there.
+	v := (bs.StableBuffer.Seconds() - bs.MinimumBuffer.Seconds()) / highestUtility
+	gp := bs.MinimumBuffer.Seconds() / v
+	buffer := state.Buffer.Seconds()
+
+	selected, bestScore := playable[0], math.Inf(-1)
+	for _, i := range playable {
+		score := (v*(utility(i)+gp) - buffer) / float64(max(mp.Variants[i].Bandwidth, 1))
+		if score >= bestScore {
+			selected, bestScore = i, score
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,128 @@
+package HLS_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udan-jayanith/HLS"
+)
+
+var selectorMasterPlaylist = HLS.MasterPlaylist{
+	Variants: []HLS.VariantStream{
+		{URI: "1080p.m3u8", Bandwidth: 6000000, Codecs: []string{"avc1.640028", "mp4a.40.2"}, Resolution: &HLS.Resolution{Width: 1920, Height: 1080}, HDCPLevel: HLS.TYPE_0},
+		{URI: "360p.m3u8", Bandwidth: 800000, Codecs: []string{"avc1.4d401e", "mp4a.40.2"}, Resolution: &HLS.Resolution{Width: 640, Height: 360}},
+		{URI: "720p.m3u8", Bandwidth: 3000000, Codecs: []string{"avc1.4d401f", "mp4a.40.2"}, Resolution: &HLS.Resolution{Width: 1280, Height: 720}, HDCPLevel: HLS.HDCP_NONE},
+		{URI: "2160p.m3u8", Bandwidth: 16000000, Codecs: []string{"hvc1.2.4.L150.B0", "mp4a.40.2"}, Resolution: &HLS.Resolution{Width: 3840, Height: 2160}, HDCPLevel: HLS.TYPE_1},
+	},
+}
+
+func TestDeviceConstraints(t *testing.T) {
+	device := HLS.DeviceConstraints{}
+	for i, variant := range selectorMasterPlaylist.Variants {
+		if expected := variant.HDCPLevel == "" || variant.HDCPLevel == HLS.HDCP_NONE; device.Plays(variant) != expected {
+			t.Fatal("Expected", expected, "for variant stream", i)
+		}
+	}
+	device = HLS.DeviceConstraints{
+		MaxResolution: &HLS.Resolution{Width: 1920, Height: 1080},
+		SupportsCodec: func(codec string) bool {
+			return !strings.HasPrefix(codec, "hvc1")
+		},
+		HDCPLevel: HLS.TYPE_1,
+	}
+	for i, expected := range []bool{true, true, true, false} {
+		if device.Plays(selectorMasterPlaylist.Variants[i]) != expected {
+			t.Fatal("Expected", expected, "for variant stream", i)
+		}
+	}
+	device.SupportsCodec = nil
+	device.MaxResolution = &HLS.Resolution{Width: 3840, Height: 2160}
+	if !device.Plays(selectorMasterPlaylist.Variants[3]) {
+		t.Fatal("Expected the 2160p variant stream to be playable")
+	}
+	if device.HDCPLevel = HLS.TYPE_0; device.Plays(selectorMasterPlaylist.Variants[3]) {
+		t.Fatal("Expected the TYPE-1 variant stream not to be playable")
+	}
+	//Unknown HDCP levels are never playable.
+	unknown := HLS.VariantStream{URI: "unknown.m3u8", Bandwidth: 1, HDCPLevel: "TYPE-2"}
+	for _, level := range []HLS.HDCPLevel{HLS.TYPE_1, "TYPE-2"} {
+		if device.HDCPLevel = level; device.Plays(unknown) {
+			t.Fatal("Expected the TYPE-2 variant stream not to be playable by", level)
+		}
+	}
+}
+
+func TestThroughputSelector(t *testing.T) {
+	selector := HLS.NewThroughputSelector()
+	if estimate := selector.EstimateThroughput([]float64{100e6, 1e6, 4e6, 4e6, 4e6, 4e6}); estimate != 2.5e6 {
+		t.Fatal("Expected the harmonic mean of the last 5 samples 2500000 but got", estimate)
+	}
+
+	device := HLS.DeviceConstraints{HDCPLevel: HLS.TYPE_1}
+	for _, test := range []struct {
+		state    HLS.PlaybackState
+		expected int
+	}{
+		{HLS.PlaybackState{Current: -1}, 1},
+		{HLS.PlaybackState{Current: 2}, 2},
+		{HLS.PlaybackState{Throughput: []float64{500000}, Current: 2}, 1},
+		{HLS.PlaybackState{Throughput: []float64{3750000}, Current: 1}, 2},
+		{HLS.PlaybackState{Throughput: []float64{7000000}, Current: 1}, 2},
+		{HLS.PlaybackState{Throughput: []float64{50000000}, Current: 1}, 3},
+	} {
+		selected, err := selector.SelectVariant(selectorMasterPlaylist, test.state, device)
+		if err != nil {
+			t.Fatal(err)
+		} else if selected != test.expected {
+			t.Fatal("Expected", test.expected, "for", test.state, "but got", selected)
+		}
+	}
+
+	//The device limits the selection.
+	device.HDCPLevel = HLS.HDCP_NONE
+	if selected, err := selector.SelectVariant(selectorMasterPlaylist, HLS.PlaybackState{Throughput: []float64{50000000}}, device); err != nil || selected != 2 {
+		t.Fatal("Expected 2 but got", selected, err)
+	}
+	device.MaxResolution = &HLS.Resolution{Width: 320, Height: 180}
+	if _, err := selector.SelectVariant(selectorMasterPlaylist, HLS.PlaybackState{}, device); err != HLS.NoPlayableVariant {
+		t.Fatal("Expected", HLS.NoPlayableVariant, "but got", err)
+	}
+}
+
+func TestBufferSelector(t *testing.T) {
+	selector := HLS.NewBufferSelector()
+	device := HLS.DeviceConstraints{HDCPLevel: HLS.TYPE_1}
+	previous := 0
+	order := []int{1, 2, 0, 3}
+	for buffer := time.Duration(0); buffer <= 40*time.Second; buffer += time.Second {
+		selected, err := selector.SelectVariant(selectorMasterPlaylist, HLS.PlaybackState{Buffer: buffer, Current: -1}, device)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buffer <= selector.MinimumBuffer && selected != 1 {
+			t.Fatal("Expected the lowest variant stream at", buffer, "but got", selected)
+		} else if buffer >= selector.StableBuffer && selected != 3 {
+			t.Fatal("Expected the highest variant stream at", buffer, "but got", selected)
+		}
+		//The selected BANDWIDTH never decreases while the buffer grows.
+		rank := 0
+		for i, variant := range order {
+			if variant == selected {
+				rank = i
+			}
+		}
+		if rank < previous {
+			t.Fatal("Expected a higher variant stream at", buffer, "but got", selected)
+		}
+		previous = rank
+	}
+	if previous != len(order)-1 {
+		t.Fatal("Expected the highest variant stream to be selected")
+	}
+
+	device.HDCPLevel = HLS.HDCP_NONE
+	if selected, err := selector.SelectVariant(selectorMasterPlaylist, HLS.PlaybackState{Buffer: time.Minute}, device); err != nil || selected != 2 {
+		t.Fatal("Expected 2 but got", selected, err)
+	}
+}